@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+type AuthConfig struct {
+	Secret     string
+	AccessTTL  time.Duration // lifetime of the bearer token
+	RefreshTTL time.Duration // how long a session can go without refreshing
+
+	LastSeenInterval time.Duration // how often a session's last seen time is written, not on every request
+	NewDeviceOtp     bool          // password logins from a new device need a code sent to the verified phone
+}
+
+func LoadAuthConfig() AuthConfig {
+	return AuthConfig{
+		Secret:     getEnvOrDefault("JWT_SECRET", ""),
+		AccessTTL:  durationEnv("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTTL: durationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		LastSeenInterval: durationEnv("SESSION_LAST_SEEN_INTERVAL", time.Minute),
+		NewDeviceOtp:     getEnvOrDefault("NEW_DEVICE_OTP", "false") == "true",
+	}
+}
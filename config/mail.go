@@ -0,0 +1,37 @@
+package config
+
+import "time"
+
+type MailConfig struct {
+	Driver         string        // log, file or smtp
+	File           string        // where the file driver appends messages
+	From           string        // sender address
+	SMTPHost       string        // smtp driver only
+	SMTPPort       string        // smtp driver only
+	SMTPUsername   string        // smtp driver only, empty skips auth
+	SMTPPassword   string        // smtp driver only
+	AppURL         string        // base of the links in verification and reset mails
+	VerifyTTL      time.Duration // how long an email verification link works
+	ResetTTL       time.Duration // how long a password reset link works
+	ResendCooldown time.Duration // minimum wait before another mail of the same kind goes to a user
+
+	RequireVerifiedForPayments bool // payments are blocked until the user verified their email
+}
+
+func LoadMailConfig() MailConfig {
+	return MailConfig{
+		Driver:         getEnvOrDefault("MAIL_DRIVER", "log"),
+		File:           getEnvOrDefault("MAIL_FILE", "mail.log"),
+		From:           getEnvOrDefault("MAIL_FROM", "no-reply@goclone.local"),
+		SMTPHost:       getEnvOrDefault("SMTP_HOST", "localhost"),
+		SMTPPort:       getEnvOrDefault("SMTP_PORT", "587"),
+		SMTPUsername:   getEnvOrDefault("SMTP_USERNAME", ""),
+		SMTPPassword:   getEnvOrDefault("SMTP_PASSWORD", ""),
+		AppURL:         getEnvOrDefault("APP_URL", "http://localhost:3000"),
+		VerifyTTL:      durationEnv("EMAIL_VERIFY_TTL", 24*time.Hour),
+		ResetTTL:       durationEnv("PASSWORD_RESET_TTL", 30*time.Minute),
+		ResendCooldown: durationEnv("MAIL_RESEND_COOLDOWN", time.Minute),
+
+		RequireVerifiedForPayments: getEnvOrDefault("REQUIRE_VERIFIED_EMAIL", "false") == "true",
+	}
+}
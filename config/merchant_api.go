@@ -0,0 +1,32 @@
+package config
+
+import (
+	"log"
+	"time"
+)
+
+type MerchantApiConfig struct {
+	SignatureTolerance time.Duration // how far the signed timestamp may be from the server clock
+	RotationGrace      time.Duration // the old secret keeps working this long after a rotation
+	MaxKeys            int           // active keys per merchant
+	QrTTL              time.Duration // default lifetime of a dynamic QR
+	MaxQrTTL           time.Duration // longest lifetime a merchant may ask for
+	EncryptionKey      string        // seals the signing keys at rest, changing it invalidates every key
+}
+
+// LoadMerchantApiConfig stops the server when MERCHANT_KEY_ENCRYPTION_KEY is missing, a default
+// would seal every key under a value anyone can guess
+func LoadMerchantApiConfig() MerchantApiConfig {
+	encryptionKey := getEnvOrDefault("MERCHANT_KEY_ENCRYPTION_KEY", "")
+	if encryptionKey == "" {
+		log.Fatal("MERCHANT_KEY_ENCRYPTION_KEY is required")
+	}
+	return MerchantApiConfig{
+		SignatureTolerance: durationEnv("MERCHANT_SIGNATURE_TOLERANCE", 5*time.Minute),
+		RotationGrace:      durationEnv("MERCHANT_KEY_ROTATION_GRACE", 24*time.Hour),
+		MaxKeys:            intEnv("MERCHANT_MAX_API_KEYS", 10),
+		QrTTL:              durationEnv("MERCHANT_QR_TTL", 15*time.Minute),
+		MaxQrTTL:           durationEnv("MERCHANT_QR_MAX_TTL", 24*time.Hour),
+		EncryptionKey:      encryptionKey,
+	}
+}
@@ -0,0 +1,13 @@
+package config
+
+type NotificationConfig struct {
+	PushDriver          string  // log writes push messages to the application log, none drops them
+	LowBalanceThreshold float64 // a main balance falling below this gets a notification
+}
+
+func LoadNotificationConfig() NotificationConfig {
+	return NotificationConfig{
+		PushDriver:          getEnvOrDefault("PUSH_DRIVER", "log"),
+		LowBalanceThreshold: floatEnv("LOW_BALANCE_THRESHOLD", 50000),
+	}
+}
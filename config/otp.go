@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+type OtpConfig struct {
+	TTL               time.Duration // how long a code can be used
+	ResendCooldown    time.Duration // minimum wait before another code goes to the same phone
+	MaxAttempts       int           // wrong codes before the code is burned
+	RequireOnRegister bool          // registration needs a verified phone
+	SMSDriver         string        // log or file
+	SMSFile           string        // where the file driver appends messages
+}
+
+func LoadOtpConfig() OtpConfig {
+	return OtpConfig{
+		TTL:               durationEnv("OTP_TTL", 5*time.Minute),
+		ResendCooldown:    durationEnv("OTP_RESEND_COOLDOWN", time.Minute),
+		MaxAttempts:       intEnv("OTP_MAX_ATTEMPTS", 5),
+		RequireOnRegister: getEnvOrDefault("OTP_REQUIRED_ON_REGISTER", "false") == "true",
+		SMSDriver:         getEnvOrDefault("SMS_DRIVER", "log"),
+		SMSFile:           getEnvOrDefault("SMS_FILE", "sms.log"),
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+type OutboxConfig struct {
+	Enabled       bool          // runs the dispatcher that hands events to the subscribers
+	PollInterval  time.Duration // how often pending events are looked up
+	BatchSize     int
+	MaxAttempts   int           // attempts before an event is marked failed
+	RetryDelay    time.Duration // wait after the first failed attempt, doubled after every further one
+	RetryMaxDelay time.Duration
+}
+
+func LoadOutboxConfig() OutboxConfig {
+	return OutboxConfig{
+		Enabled:       getEnvOrDefault("OUTBOX_ENABLED", "true") == "true",
+		PollInterval:  durationEnv("OUTBOX_POLL_INTERVAL", 2*time.Second),
+		BatchSize:     intEnv("OUTBOX_BATCH_SIZE", 100),
+		MaxAttempts:   intEnv("OUTBOX_MAX_ATTEMPTS", 10),
+		RetryDelay:    durationEnv("OUTBOX_RETRY_DELAY", 10*time.Second),
+		RetryMaxDelay: durationEnv("OUTBOX_RETRY_MAX_DELAY", time.Hour),
+	}
+}
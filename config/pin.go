@@ -0,0 +1,34 @@
+package config
+
+import (
+	"log"
+	"strconv"
+	"time"
+)
+
+type PinConfig struct {
+	RequiredAbove float64       // payments above this amount need the PIN, 0 means every payment
+	MaxAttempts   int           // wrong PINs in a row before the lockout
+	Lockout       time.Duration // how long the PIN stays locked
+}
+
+func LoadPinConfig() PinConfig {
+	return PinConfig{
+		RequiredAbove: floatEnv("PIN_REQUIRED_ABOVE", 50000),
+		MaxAttempts:   intEnv("PIN_MAX_ATTEMPTS", 5),
+		Lockout:       durationEnv("PIN_LOCKOUT", 30*time.Minute),
+	}
+}
+
+func floatEnv(key string, defaultValue float64) float64 {
+	raw := getEnvOrDefault(key, "")
+	if raw == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || f < 0 {
+		log.Printf("invalid %s %q, using %.2f", key, raw, defaultValue)
+		return defaultValue
+	}
+	return f
+}
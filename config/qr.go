@@ -0,0 +1,37 @@
+package config
+
+import "time"
+
+type QrConfig struct {
+	GUI                  string // globally unique identifier in the merchant account template, tells our QRs apart
+	MerchantCategoryCode string // ISO 18245, used when the receiver is not a merchant
+	CurrencyCode         string // ISO 4217 numeric
+	CountryCode          string // ISO 3166-1 alpha 2
+	DefaultCity          string // for receivers without a merchant location
+
+	ImageSize    int           // default edge of a rendered QR image in pixels
+	ImageMinSize int           // smallest edge a client may ask for
+	ImageMaxSize int           // largest edge a client may ask for
+	LogoTimeout  time.Duration // fetching the merchant logo gives up after this
+	LogoMaxBytes int           // larger logos are not drawn
+
+	MaxStaticQrs int // active static QRs per merchant
+}
+
+func LoadQrConfig() QrConfig {
+	return QrConfig{
+		GUI:                  getEnvOrDefault("QR_GUI", "ID.CO.GOCLONE.WWW"),
+		MerchantCategoryCode: getEnvOrDefault("QR_MERCHANT_CATEGORY_CODE", "5999"),
+		CurrencyCode:         getEnvOrDefault("QR_CURRENCY_CODE", "360"),
+		CountryCode:          getEnvOrDefault("QR_COUNTRY_CODE", "ID"),
+		DefaultCity:          getEnvOrDefault("QR_DEFAULT_CITY", "JAKARTA"),
+
+		ImageSize:    intEnv("QR_IMAGE_SIZE", 256),
+		ImageMinSize: intEnv("QR_IMAGE_MIN_SIZE", 128),
+		ImageMaxSize: intEnv("QR_IMAGE_MAX_SIZE", 1024),
+		LogoTimeout:  durationEnv("QR_LOGO_TIMEOUT", 3*time.Second),
+		LogoMaxBytes: intEnv("QR_LOGO_MAX_BYTES", 1<<20),
+
+		MaxStaticQrs: intEnv("QR_MAX_STATIC_PER_MERCHANT", 50),
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Budget is how many requests fit in a window, a zero limit turns the budget off
+type Budget struct {
+	Limit  int
+	Window time.Duration
+}
+
+type RateLimitConfig struct {
+	Login  Budget // per IP, password, phone and 2FA logins, token refresh and the password reset endpoints
+	Otp    Budget // per user or IP, every endpoint that sends a code
+	Verify Budget // per user, every endpoint that checks a code
+	Money  Budget // per user, every endpoint that moves money
+	Lookup Budget // per user, finding other users by phone or email
+
+	MerchantApi Budget // per API key, every merchant API endpoint
+}
+
+type LoginGuardConfig struct {
+	MaxFailures   int           // wrong passwords for one account before it locks
+	IPMaxFailures int           // wrong passwords from one IP, over all accounts, before it locks
+	FailureWindow time.Duration // failures older than this are forgotten
+	Lockout       time.Duration // first lockout, doubled with every failure after the limit
+	MaxLockout    time.Duration // the doubling stops here
+}
+
+func LoadRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Login:  budgetEnv("RATE_LIMIT_LOGIN", Budget{Limit: 10, Window: time.Minute}),
+		Otp:    budgetEnv("RATE_LIMIT_OTP", Budget{Limit: 5, Window: 10 * time.Minute}),
+		Verify: budgetEnv("RATE_LIMIT_VERIFY", Budget{Limit: 10, Window: 10 * time.Minute}),
+		Money:  budgetEnv("RATE_LIMIT_MONEY", Budget{Limit: 30, Window: time.Minute}),
+		Lookup: budgetEnv("RATE_LIMIT_LOOKUP", Budget{Limit: 20, Window: 10 * time.Minute}),
+
+		MerchantApi: budgetEnv("RATE_LIMIT_MERCHANT_API", Budget{Limit: 120, Window: time.Minute}),
+	}
+}
+
+func LoadLoginGuardConfig() LoginGuardConfig {
+	return LoginGuardConfig{
+		MaxFailures:   intEnv("LOGIN_MAX_FAILURES", 5),
+		IPMaxFailures: intEnv("LOGIN_IP_MAX_FAILURES", 20),
+		FailureWindow: durationEnv("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		Lockout:       durationEnv("LOGIN_LOCKOUT", time.Minute),
+		MaxLockout:    durationEnv("LOGIN_MAX_LOCKOUT", time.Hour),
+	}
+}
+
+// budgetEnv reads budgets written as limit/window, e.g. 10/1m
+func budgetEnv(key string, defaultValue Budget) Budget {
+	raw := getEnvOrDefault(key, "")
+	if raw == "" {
+		return defaultValue
+	}
+	limitPart, windowPart, ok := strings.Cut(raw, "/")
+	limit, err := strconv.Atoi(limitPart)
+	if !ok || err != nil || limit < 0 {
+		log.Printf("invalid %s %q, using %d/%s", key, raw, defaultValue.Limit, defaultValue.Window)
+		return defaultValue
+	}
+	window, err := time.ParseDuration(windowPart)
+	if err != nil || window <= 0 {
+		log.Printf("invalid %s %q, using %d/%s", key, raw, defaultValue.Limit, defaultValue.Window)
+		return defaultValue
+	}
+	return Budget{Limit: limit, Window: window}
+}
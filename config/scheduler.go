@@ -0,0 +1,51 @@
+package config
+
+import (
+	"log"
+	"strconv"
+	"time"
+)
+
+type SchedulerConfig struct {
+	Enabled       bool
+	PollInterval  time.Duration // how often due schedules are looked up
+	BatchSize     int
+	RetryAttempts int           // attempts per occurrence when a transfer fails for a reason that may pass, e.g. a low balance
+	RetryDelay    time.Duration // wait between those attempts
+}
+
+func LoadSchedulerConfig() SchedulerConfig {
+	return SchedulerConfig{
+		Enabled:       getEnvOrDefault("SCHEDULER_ENABLED", "true") == "true",
+		PollInterval:  durationEnv("SCHEDULER_POLL_INTERVAL", time.Minute),
+		BatchSize:     intEnv("SCHEDULER_BATCH_SIZE", 50),
+		RetryAttempts: intEnv("SCHEDULER_RETRY_ATTEMPTS", 3),
+		RetryDelay:    durationEnv("SCHEDULER_RETRY_DELAY", time.Hour),
+	}
+}
+
+func durationEnv(key string, defaultValue time.Duration) time.Duration {
+	raw := getEnvOrDefault(key, "")
+	if raw == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		log.Printf("invalid %s %q, using %s", key, raw, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+func intEnv(key string, defaultValue int) int {
+	raw := getEnvOrDefault(key, "")
+	if raw == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		log.Printf("invalid %s %q, using %d", key, raw, defaultValue)
+		return defaultValue
+	}
+	return n
+}
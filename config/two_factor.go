@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+type TwoFactorConfig struct {
+	Issuer        string        // name shown in the authenticator app
+	ChallengeTTL  time.Duration // time between the password and the code at login
+	MaxAttempts   int           // wrong codes before a login challenge is burned
+	RecoveryCodes int           // how many recovery codes are handed out
+}
+
+func LoadTwoFactorConfig() TwoFactorConfig {
+	return TwoFactorConfig{
+		Issuer:        getEnvOrDefault("TOTP_ISSUER", "GoClone"),
+		ChallengeTTL:  durationEnv("TWO_FACTOR_CHALLENGE_TTL", 5*time.Minute),
+		MaxAttempts:   intEnv("TWO_FACTOR_MAX_ATTEMPTS", 5),
+		RecoveryCodes: intEnv("TWO_FACTOR_RECOVERY_CODES", 10),
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"log"
+	"time"
+)
+
+type WebhookConfig struct {
+	Enabled       bool          // runs the delivery worker
+	PollInterval  time.Duration // how often due deliveries are looked up
+	BatchSize     int
+	Timeout       time.Duration // per delivery attempt
+	MaxAttempts   int           // attempts before a delivery is marked failed
+	BackoffBase   time.Duration // wait after the first failure, doubled after every further one
+	BackoffMax    time.Duration
+	MaxEndpoints  int    // endpoints per merchant
+	AllowHTTP     bool   // accept plain http endpoint URLs, for local development
+	EncryptionKey string // seals the endpoint secrets at rest, changing it breaks the signature of every endpoint
+}
+
+// LoadWebhookConfig stops the server when WEBHOOK_SECRET_ENCRYPTION_KEY is missing, like the
+// merchant API keys the secrets must not be sealed under a default
+func LoadWebhookConfig() WebhookConfig {
+	encryptionKey := getEnvOrDefault("WEBHOOK_SECRET_ENCRYPTION_KEY", "")
+	if encryptionKey == "" {
+		log.Fatal("WEBHOOK_SECRET_ENCRYPTION_KEY is required")
+	}
+	return WebhookConfig{
+		Enabled:       getEnvOrDefault("WEBHOOK_WORKER_ENABLED", "true") == "true",
+		PollInterval:  durationEnv("WEBHOOK_POLL_INTERVAL", 10*time.Second),
+		BatchSize:     intEnv("WEBHOOK_BATCH_SIZE", 50),
+		Timeout:       durationEnv("WEBHOOK_TIMEOUT", 10*time.Second),
+		MaxAttempts:   intEnv("WEBHOOK_MAX_ATTEMPTS", 8),
+		BackoffBase:   durationEnv("WEBHOOK_BACKOFF_BASE", 30*time.Second),
+		BackoffMax:    durationEnv("WEBHOOK_BACKOFF_MAX", 6*time.Hour),
+		MaxEndpoints:  intEnv("WEBHOOK_MAX_ENDPOINTS", 5),
+		AllowHTTP:     getEnvOrDefault("WEBHOOK_ALLOW_HTTP", "false") == "true",
+		EncryptionKey: encryptionKey,
+	}
+}
@@ -1,6 +1,11 @@
 package errors
 
-import "net/http"
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
 
 // AppError represents a custom application error
 type AppError struct {
@@ -16,13 +21,17 @@ func (e *AppError) Error() string {
 
 // user-related errors
 var (
-	ErrDatabaseError   = &AppError{"DATABASE_ERROR", "Database operation failed", "internal", http.StatusInternalServerError}
-	ErrUserNotFound    = &AppError{"USER_NOT_FOUND", "User not found", "not_found", http.StatusNotFound}
-	ErrUserExists      = &AppError{"USER_EXISTS", "User already exists", "conflict", http.StatusConflict}
-	ErrInvalidPassword = &AppError{"INVALID_PASSWORD", "Invalid password", "unauthorized", http.StatusUnauthorized}
-	ErrEmailNotFound   = &AppError{"EMAIL_NOT_FOUND", "Email not found", "unauthorized", http.StatusUnauthorized}
-	ErrTokenCreation   = &AppError{"TOKEN_CREATION_FAILED", "Failed to create authentication token", "internal", http.StatusInternalServerError}
-	ErrUserCreation    = &AppError{"USER_CREATION_FAILED", "Failed to create user", "internal", http.StatusInternalServerError}
+	ErrDatabaseError      = &AppError{"DATABASE_ERROR", "Database operation failed", "internal", http.StatusInternalServerError}
+	ErrUserNotFound       = &AppError{"USER_NOT_FOUND", "User not found", "not_found", http.StatusNotFound}
+	ErrUserExists         = &AppError{"USER_EXISTS", "User already exists", "conflict", http.StatusConflict}
+	ErrInvalidPassword    = &AppError{"INVALID_PASSWORD", "Invalid password", "unauthorized", http.StatusUnauthorized}
+	ErrEmailNotFound      = &AppError{"EMAIL_NOT_FOUND", "Email not found", "unauthorized", http.StatusUnauthorized}
+	ErrInvalidCredentials = &AppError{"INVALID_CREDENTIALS", "Invalid email or password", "unauthorized", http.StatusUnauthorized}
+	ErrTokenCreation      = &AppError{"TOKEN_CREATION_FAILED", "Failed to create authentication token", "internal", http.StatusInternalServerError}
+	ErrUserCreation       = &AppError{"USER_CREATION_FAILED", "Failed to create user", "internal", http.StatusInternalServerError}
+	ErrUserFrozen         = &AppError{"USER_FROZEN", "This wallet is frozen, contact support", "forbidden", http.StatusForbidden}
+	ErrUserNotFrozen      = &AppError{"USER_NOT_FROZEN", "User is not frozen", "conflict", http.StatusConflict}
+	ErrUserUpdateFailed   = &AppError{"USER_UPDATE_FAILED", "Failed to update user", "internal", http.StatusInternalServerError}
 )
 
 // driver-related errors
@@ -34,6 +43,8 @@ var (
 	ErrDriverProfileUpdateFailed = &AppError{"DRIVER_PROFILE_UPDATE_FAILED", "Failed to update driver profile", "internal", http.StatusInternalServerError}
 	ErrDriverStatusUpdateFailed  = &AppError{"DRIVER_STATUS_UPDATE_FAILED", "Failed to update driver status", "internal", http.StatusInternalServerError}
 	ErrDriverDeleteFailed        = &AppError{"DRIVER_DELETE_FAILED", "Failed to delete driver ", "internal", http.StatusInternalServerError}
+	ErrDriverSuspended           = &AppError{"DRIVER_SUSPENDED", "Driver is suspended", "forbidden", http.StatusForbidden}
+	ErrDriverNotSuspended        = &AppError{"DRIVER_NOT_SUSPENDED", "Driver is not suspended", "conflict", http.StatusConflict}
 )
 
 // account-related errors
@@ -55,10 +66,22 @@ var (
 
 // QR Code-related errors
 var (
-	ErrQRNotFound     = &AppError{"QR_NOT_FOUND", "QR code not found", "not_found", http.StatusNotFound}
-	ErrQRExpired      = &AppError{"QR_EXPIRED", "QR code has expired", "validation", http.StatusBadRequest}
-	ErrQRAlreadyUsed  = &AppError{"QR_ALREADY_USED", "QR code has already been used", "validation", http.StatusBadRequest}
-	ErrQRCreateFailed = &AppError{"QR_CREATE_FAILED", "Failed to create QR code", "internal", http.StatusInternalServerError}
+	ErrQRNotFound         = &AppError{"QR_NOT_FOUND", "QR code not found", "not_found", http.StatusNotFound}
+	ErrQRExpired          = &AppError{"QR_EXPIRED", "QR code has expired", "validation", http.StatusBadRequest}
+	ErrQRAlreadyUsed      = &AppError{"QR_ALREADY_USED", "QR code has already been used", "validation", http.StatusBadRequest}
+	ErrQRCreateFailed     = &AppError{"QR_CREATE_FAILED", "Failed to create QR code", "internal", http.StatusInternalServerError}
+	ErrInvalidQRPayload   = &AppError{"INVALID_QR_PAYLOAD", "QR payload is malformed", "validation", http.StatusBadRequest}
+	ErrQRChecksumMismatch = &AppError{"QR_CHECKSUM_MISMATCH", "QR payload checksum does not match, scan again", "validation", http.StatusBadRequest}
+	ErrQRUnsupported      = &AppError{"QR_UNSUPPORTED", "QR code belongs to another payment provider", "validation", http.StatusBadRequest}
+	ErrQRPayloadMismatch  = &AppError{"QR_PAYLOAD_MISMATCH", "QR payload does not match the stored QR code", "validation", http.StatusBadRequest}
+	ErrQRImageSize        = &AppError{"QR_IMAGE_SIZE", "QR image size is out of range", "validation", http.StatusBadRequest}
+	ErrQRRenderFailed     = &AppError{"QR_RENDER_FAILED", "Failed to render QR code", "internal", http.StatusInternalServerError}
+	ErrQRNotStatic        = &AppError{"QR_NOT_STATIC", "Standees are only printed for static QR codes", "validation", http.StatusBadRequest}
+	ErrQRStatic           = &AppError{"QR_STATIC", "Static QR codes are paid many times, list their payments instead", "validation", http.StatusBadRequest}
+	ErrQRDisabled         = &AppError{"QR_DISABLED", "QR code has been disabled", "validation", http.StatusBadRequest}
+	ErrQRAmountRequired   = &AppError{"QR_AMOUNT_REQUIRED", "Enter the amount to pay for a static QR code", "validation", http.StatusBadRequest}
+	ErrQRAmountFixed      = &AppError{"QR_AMOUNT_FIXED", "The amount of this QR code is fixed", "validation", http.StatusBadRequest}
+	ErrStaticQRLimit      = &AppError{"STATIC_QR_LIMIT", "Too many active static QR codes, disable one first", "conflict", http.StatusConflict}
 )
 
 // order-related errors
@@ -69,6 +92,13 @@ var (
 	ErrOrderDeleteFailed       = &AppError{"ORDER_DELETE_FAILED", "Failed to delete order", "internal", http.StatusInternalServerError}
 )
 
+// ride-related errors
+var (
+	ErrRideNotFound           = &AppError{"RIDE_NOT_FOUND", "Ride not found", "not_found", http.StatusNotFound}
+	ErrRideStatusTransition   = &AppError{"RIDE_STATUS_TRANSITION", "The ride can't move to this status", "validation", http.StatusBadRequest}
+	ErrRideStatusUpdateFailed = &AppError{"RIDE_STATUS_UPDATE_FAILED", "Failed to update ride status", "internal", http.StatusInternalServerError}
+)
+
 // menu-related errors
 var (
 	ErrMenuNotFound     = &AppError{"MENU_NOT_FOUND", "Menu not found", "not_found", http.StatusNotFound}
@@ -85,6 +115,95 @@ var (
 	ErrMerchantExists       = &AppError{"MERCHANT_EXISTS", "Merchant profile already exists", "conflict", http.StatusConflict}
 	ErrMerchantUpdateFailed = &AppError{"MERCHANT_UPDATE_FAILED", "Failed to update merchant profile", "internal", http.StatusInternalServerError}
 	ErrMerchantDeleteFailed = &AppError{"MERCHANT_DELETE_FAILED", "Failed to delete merchant ", "internal", http.StatusInternalServerError}
+	ErrMerchantLogoInvalid  = &AppError{"MERCHANT_LOGO_INVALID", "Merchant logo URL must be a public PNG, JPEG or GIF image", "validation", http.StatusBadRequest}
+)
+
+// contact-related errors
+var (
+	ErrContactNotFound     = &AppError{"CONTACT_NOT_FOUND", "Contact not found", "not_found", http.StatusNotFound}
+	ErrContactExists       = &AppError{"CONTACT_EXISTS", "Contact already exists", "conflict", http.StatusConflict}
+	ErrContactSelf         = &AppError{"CONTACT_SELF", "Cannot add yourself as a contact", "validation", http.StatusBadRequest}
+	ErrContactCreateFailed = &AppError{"CONTACT_CREATE_FAILED", "Failed to create contact", "internal", http.StatusInternalServerError}
+	ErrContactUpdateFailed = &AppError{"CONTACT_UPDATE_FAILED", "Failed to update contact", "internal", http.StatusInternalServerError}
+	ErrContactDeleteFailed = &AppError{"CONTACT_DELETE_FAILED", "Failed to delete contact", "internal", http.StatusInternalServerError}
+)
+
+// budget-related errors
+var (
+	ErrBudgetNotFound     = &AppError{"BUDGET_NOT_FOUND", "Budget not found", "not_found", http.StatusNotFound}
+	ErrBudgetUpdateFailed = &AppError{"BUDGET_UPDATE_FAILED", "Failed to save budget", "internal", http.StatusInternalServerError}
+	ErrBudgetDeleteFailed = &AppError{"BUDGET_DELETE_FAILED", "Failed to delete budget", "internal", http.StatusInternalServerError}
+)
+
+// payment request and split bill errors
+var (
+	ErrPaymentRequestNotFound     = &AppError{"PAYMENT_REQUEST_NOT_FOUND", "Payment request not found", "not_found", http.StatusNotFound}
+	ErrPaymentRequestNotPending   = &AppError{"PAYMENT_REQUEST_NOT_PENDING", "Payment request is no longer pending", "conflict", http.StatusConflict}
+	ErrPaymentRequestCreateFailed = &AppError{"PAYMENT_REQUEST_CREATE_FAILED", "Failed to create payment request", "internal", http.StatusInternalServerError}
+	ErrPaymentRequestUpdateFailed = &AppError{"PAYMENT_REQUEST_UPDATE_FAILED", "Failed to update payment request", "internal", http.StatusInternalServerError}
+	ErrSplitBillNotFound          = &AppError{"SPLIT_BILL_NOT_FOUND", "Split bill not found", "not_found", http.StatusNotFound}
+	ErrSplitBillNotOpen           = &AppError{"SPLIT_BILL_NOT_OPEN", "Split bill is no longer open", "conflict", http.StatusConflict}
+	ErrSplitBillCreateFailed      = &AppError{"SPLIT_BILL_CREATE_FAILED", "Failed to create split bill", "internal", http.StatusInternalServerError}
+	ErrSplitSharesMismatch        = &AppError{"SPLIT_SHARES_MISMATCH", "Shares do not add up to the bill total", "validation", http.StatusBadRequest}
+	ErrOrderNotSplittable         = &AppError{"ORDER_NOT_SPLITTABLE", "Only your own completed orders can be split", "validation", http.StatusBadRequest}
+	ErrOrderAlreadySplit          = &AppError{"ORDER_ALREADY_SPLIT", "This order has already been split", "conflict", http.StatusConflict}
+	ErrSplitShareTooSmall         = &AppError{"SPLIT_SHARE_TOO_SMALL", "Every share has to be at least 0.01", "validation", http.StatusBadRequest}
+)
+
+// scheduled transfer errors
+var (
+	ErrScheduleNotFound     = &AppError{"SCHEDULE_NOT_FOUND", "Scheduled transfer not found", "not_found", http.StatusNotFound}
+	ErrScheduleNotActive    = &AppError{"SCHEDULE_NOT_ACTIVE", "Scheduled transfer is not active", "conflict", http.StatusConflict}
+	ErrScheduleNotPaused    = &AppError{"SCHEDULE_NOT_PAUSED", "Scheduled transfer is not paused", "conflict", http.StatusConflict}
+	ErrScheduleFinished     = &AppError{"SCHEDULE_FINISHED", "Scheduled transfer has no runs left", "validation", http.StatusBadRequest}
+	ErrScheduleCreateFailed = &AppError{"SCHEDULE_CREATE_FAILED", "Failed to create scheduled transfer", "internal", http.StatusInternalServerError}
+	ErrScheduleUpdateFailed = &AppError{"SCHEDULE_UPDATE_FAILED", "Failed to update scheduled transfer", "internal", http.StatusInternalServerError}
+)
+
+// bill payment errors
+var (
+	ErrBillerNotFound       = &AppError{"BILLER_NOT_FOUND", "Biller not found", "not_found", http.StatusNotFound}
+	ErrBillerUnavailable    = &AppError{"BILLER_UNAVAILABLE", "Biller is not available right now", "unavailable", http.StatusBadGateway}
+	ErrBillCustomerNotFound = &AppError{"BILL_CUSTOMER_NOT_FOUND", "Customer number not found at the biller", "not_found", http.StatusNotFound}
+	ErrBillAlreadyPaid      = &AppError{"BILL_ALREADY_PAID", "There is no outstanding bill for this customer", "conflict", http.StatusConflict}
+	ErrBillAmountOutOfRange = &AppError{"BILL_AMOUNT_OUT_OF_RANGE", "Amount is outside what this biller accepts", "validation", http.StatusBadRequest}
+	ErrBillInquiryNotFound  = &AppError{"BILL_INQUIRY_NOT_FOUND", "Bill inquiry not found", "not_found", http.StatusNotFound}
+	ErrBillInquiryExpired   = &AppError{"BILL_INQUIRY_EXPIRED", "Bill inquiry expired, inquire again", "conflict", http.StatusConflict}
+	ErrBillInquiryNotOpen   = &AppError{"BILL_INQUIRY_NOT_OPEN", "Bill inquiry is already being paid or paid", "conflict", http.StatusConflict}
+	ErrBillPaymentNotFound  = &AppError{"BILL_PAYMENT_NOT_FOUND", "Bill payment not found", "not_found", http.StatusNotFound}
+	ErrBillPaymentFailed    = &AppError{"BILL_PAYMENT_FAILED", "Biller rejected the payment, your balance was returned", "unavailable", http.StatusBadGateway}
+)
+
+// savings pocket errors
+var (
+	ErrPocketNotFound         = &AppError{"POCKET_NOT_FOUND", "Pocket not found", "not_found", http.StatusNotFound}
+	ErrPocketLocked           = &AppError{"POCKET_LOCKED", "Pocket is locked, money can only move to your own accounts once the lock ends", "forbidden", http.StatusForbidden}
+	ErrPocketLockShortened    = &AppError{"POCKET_LOCK_SHORTENED", "A running lock can only be extended", "validation", http.StatusBadRequest}
+	ErrPocketCreateFailed     = &AppError{"POCKET_CREATE_FAILED", "Failed to create pocket", "internal", http.StatusInternalServerError}
+	ErrPocketUpdateFailed     = &AppError{"POCKET_UPDATE_FAILED", "Failed to update pocket", "internal", http.StatusInternalServerError}
+	ErrNotInternalTransfer    = &AppError{"NOT_INTERNAL_TRANSFER", "Both accounts must belong to you", "forbidden", http.StatusForbidden}
+	ErrAutoSaveRuleNotFound   = &AppError{"AUTO_SAVE_RULE_NOT_FOUND", "Auto-save rule not found", "not_found", http.StatusNotFound}
+	ErrAutoSaveRoundUpExists  = &AppError{"AUTO_SAVE_ROUND_UP_EXISTS", "Payments are already rounded up into another pocket", "conflict", http.StatusConflict}
+	ErrAutoSaveRuleSaveFailed = &AppError{"AUTO_SAVE_RULE_SAVE_FAILED", "Failed to save auto-save rule", "internal", http.StatusInternalServerError}
+)
+
+// audit errors
+var (
+	ErrAuditWriteFailed = &AppError{"AUDIT_WRITE_FAILED", "Failed to write audit log", "internal", http.StatusInternalServerError}
+)
+
+// notification-related errors
+var (
+	ErrNotificationCreateFailed = &AppError{"NOTIFICATION_CREATE_FAILED", "Failed to create notification", "internal", http.StatusInternalServerError}
+	ErrNotificationNotFound     = &AppError{"NOTIFICATION_NOT_FOUND", "Notification not found", "not_found", http.StatusNotFound}
+	ErrNotificationUpdateFailed = &AppError{"NOTIFICATION_UPDATE_FAILED", "Failed to update notification", "internal", http.StatusInternalServerError}
+	ErrDeviceTokenNotFound      = &AppError{"DEVICE_TOKEN_NOT_FOUND", "Device not found", "not_found", http.StatusNotFound}
+	ErrDeviceTokenSaveFailed    = &AppError{"DEVICE_TOKEN_SAVE_FAILED", "Failed to save device", "internal", http.StatusInternalServerError}
+)
+
+// domain event errors
+var (
+	ErrEventPublishFailed = &AppError{"EVENT_PUBLISH_FAILED", "Failed to record the change", "internal", http.StatusInternalServerError}
 )
 
 // authorization errors
@@ -94,6 +213,87 @@ var (
 	ErrInvalidToken = &AppError{"INVALID_TOKEN", "Invalid authentication token", "unauthorized", http.StatusUnauthorized}
 )
 
+// session and refresh token errors
+var (
+	ErrSessionRevoked      = &AppError{"SESSION_REVOKED", "Session has ended, log in again", "unauthorized", http.StatusUnauthorized}
+	ErrInvalidRefreshToken = &AppError{"INVALID_REFRESH_TOKEN", "Refresh token is invalid or expired", "unauthorized", http.StatusUnauthorized}
+	ErrRefreshTokenReused  = &AppError{"REFRESH_TOKEN_REUSED", "Refresh token was already used, the session has been ended", "unauthorized", http.StatusUnauthorized}
+	ErrSessionCreateFailed = &AppError{"SESSION_CREATE_FAILED", "Failed to start session", "internal", http.StatusInternalServerError}
+	ErrSessionRevokeFailed = &AppError{"SESSION_REVOKE_FAILED", "Failed to end session", "internal", http.StatusInternalServerError}
+	ErrSessionNotFound     = &AppError{"SESSION_NOT_FOUND", "Session not found", "not_found", http.StatusNotFound}
+)
+
+// transaction PIN errors
+var (
+	ErrPinNotSet       = &AppError{"PIN_NOT_SET", "Set a transaction PIN before making this payment", "forbidden", http.StatusForbidden}
+	ErrPinRequired     = &AppError{"PIN_REQUIRED", "Transaction PIN is required for this payment", "unauthorized", http.StatusUnauthorized}
+	ErrPinLocked       = &AppError{"PIN_LOCKED", "Too many wrong PINs, try again later", "locked", http.StatusLocked}
+	ErrPinAlreadySet   = &AppError{"PIN_ALREADY_SET", "Transaction PIN is already set, change it instead", "conflict", http.StatusConflict}
+	ErrPinUpdateFailed = &AppError{"PIN_UPDATE_FAILED", "Failed to save transaction PIN", "internal", http.StatusInternalServerError}
+)
+
+// phone OTP errors
+var (
+	ErrOtpRequired          = &AppError{"OTP_REQUIRED", "A code sent to your phone is required", "unauthorized", http.StatusUnauthorized}
+	ErrOtpExpired           = &AppError{"OTP_EXPIRED", "Code expired or already used, request a new one", "unauthorized", http.StatusUnauthorized}
+	ErrOtpTooManyAttempts   = &AppError{"OTP_TOO_MANY_ATTEMPTS", "Too many wrong codes, request a new one", "unauthorized", http.StatusUnauthorized}
+	ErrOtpCooldown          = &AppError{"OTP_COOLDOWN", "A code was just sent, wait before asking again", "rate_limit", http.StatusTooManyRequests}
+	ErrOtpSendFailed        = &AppError{"OTP_SEND_FAILED", "Failed to send the code", "external", http.StatusBadGateway}
+	ErrOtpCreateFailed      = &AppError{"OTP_CREATE_FAILED", "Failed to create the code", "internal", http.StatusInternalServerError}
+	ErrPhoneNotVerified     = &AppError{"PHONE_NOT_VERIFIED", "Verify your phone number first", "forbidden", http.StatusForbidden}
+	ErrPhoneAlreadyVerified = &AppError{"PHONE_ALREADY_VERIFIED", "Phone number is already verified", "conflict", http.StatusConflict}
+	ErrPhoneTaken           = &AppError{"PHONE_TAKEN", "Phone number is already verified by another user", "conflict", http.StatusConflict}
+)
+
+// email verification and password reset errors
+var (
+	ErrEmailAlreadyVerified   = &AppError{"EMAIL_ALREADY_VERIFIED", "Email is already verified", "conflict", http.StatusConflict}
+	ErrEmailNotVerified       = &AppError{"EMAIL_NOT_VERIFIED", "Verify your email before moving money", "forbidden", http.StatusForbidden}
+	ErrInvalidEmailToken      = &AppError{"INVALID_EMAIL_TOKEN", "Link expired or already used, request a new one", "unauthorized", http.StatusUnauthorized}
+	ErrEmailCooldown          = &AppError{"EMAIL_COOLDOWN", "An email was just sent, wait before asking again", "rate_limit", http.StatusTooManyRequests}
+	ErrEmailSendFailed        = &AppError{"EMAIL_SEND_FAILED", "Failed to send the email", "external", http.StatusBadGateway}
+	ErrEmailTokenCreateFailed = &AppError{"EMAIL_TOKEN_CREATE_FAILED", "Failed to create the link", "internal", http.StatusInternalServerError}
+)
+
+// two-factor authentication errors
+var (
+	ErrTwoFactorAlreadyEnabled = &AppError{"TWO_FACTOR_ALREADY_ENABLED", "Two-factor authentication is already enabled", "conflict", http.StatusConflict}
+	ErrTwoFactorNotEnabled     = &AppError{"TWO_FACTOR_NOT_ENABLED", "Two-factor authentication is not enabled", "conflict", http.StatusConflict}
+	ErrTwoFactorNotEnrolled    = &AppError{"TWO_FACTOR_NOT_ENROLLED", "Start the enrolment before enabling two-factor authentication", "conflict", http.StatusConflict}
+	ErrInvalidTwoFactorCode    = &AppError{"INVALID_TWO_FACTOR_CODE", "Invalid authentication code", "unauthorized", http.StatusUnauthorized}
+	ErrInvalidLoginChallenge   = &AppError{"INVALID_LOGIN_CHALLENGE", "Login challenge expired or already used, log in again", "unauthorized", http.StatusUnauthorized}
+	ErrTwoFactorUpdateFailed   = &AppError{"TWO_FACTOR_UPDATE_FAILED", "Failed to save two-factor settings", "internal", http.StatusInternalServerError}
+)
+
+// merchant API errors
+var (
+	ErrApiKeyNotFound        = &AppError{"API_KEY_NOT_FOUND", "API key not found", "not_found", http.StatusNotFound}
+	ErrApiKeyLimit           = &AppError{"API_KEY_LIMIT", "Too many active API keys, revoke one first", "conflict", http.StatusConflict}
+	ErrApiKeyCreateFailed    = &AppError{"API_KEY_CREATE_FAILED", "Failed to create API key", "internal", http.StatusInternalServerError}
+	ErrApiKeyUpdateFailed    = &AppError{"API_KEY_UPDATE_FAILED", "Failed to update API key", "internal", http.StatusInternalServerError}
+	ErrInvalidApiKey         = &AppError{"INVALID_API_KEY", "Unknown or revoked API key", "unauthorized", http.StatusUnauthorized}
+	ErrInvalidSignature      = &AppError{"INVALID_SIGNATURE", "Request signature does not match", "unauthorized", http.StatusUnauthorized}
+	ErrSignatureExpired      = &AppError{"SIGNATURE_EXPIRED", "Request timestamp is too far from the server time", "unauthorized", http.StatusUnauthorized}
+	ErrSignatureReplayed     = &AppError{"SIGNATURE_REPLAYED", "This signed request was already received", "unauthorized", http.StatusUnauthorized}
+	ErrApiScopeMissing       = &AppError{"API_SCOPE_MISSING", "API key is not allowed to do this", "forbidden", http.StatusForbidden}
+	ErrRefundNotAllowed      = &AppError{"REFUND_NOT_ALLOWED", "Only completed payments to this merchant can be refunded", "conflict", http.StatusConflict}
+	ErrRefundExceedsPayment  = &AppError{"REFUND_EXCEEDS_PAYMENT", "Refunds can not add up to more than the payment", "validation", http.StatusBadRequest}
+	ErrRefundReferenceExists = &AppError{"REFUND_REFERENCE_EXISTS", "A refund with this reference already exists", "conflict", http.StatusConflict}
+	ErrRefundFailed          = &AppError{"REFUND_FAILED", "Failed to refund the payment", "internal", http.StatusInternalServerError}
+)
+
+// webhook errors
+var (
+	ErrWebhookNotFound          = &AppError{"WEBHOOK_NOT_FOUND", "Webhook endpoint not found", "not_found", http.StatusNotFound}
+	ErrWebhookLimit             = &AppError{"WEBHOOK_LIMIT", "Too many webhook endpoints, delete one first", "conflict", http.StatusConflict}
+	ErrWebhookInsecureURL       = &AppError{"WEBHOOK_INSECURE_URL", "Webhook URLs must use https", "validation", http.StatusBadRequest}
+	ErrWebhookCreateFailed      = &AppError{"WEBHOOK_CREATE_FAILED", "Failed to create webhook endpoint", "internal", http.StatusInternalServerError}
+	ErrWebhookUpdateFailed      = &AppError{"WEBHOOK_UPDATE_FAILED", "Failed to update webhook endpoint", "internal", http.StatusInternalServerError}
+	ErrWebhookDeliveryNotFound  = &AppError{"WEBHOOK_DELIVERY_NOT_FOUND", "Webhook delivery not found", "not_found", http.StatusNotFound}
+	ErrWebhookRedeliveryPending = &AppError{"WEBHOOK_REDELIVERY_PENDING", "This delivery is still being retried", "conflict", http.StatusConflict}
+	ErrWebhookEnqueueFailed     = &AppError{"WEBHOOK_ENQUEUE_FAILED", "Failed to queue the webhook", "internal", http.StatusInternalServerError}
+)
+
 // Validation errors
 var (
 	ErrValidationFailed = &AppError{"VALIDATION_FAILED", "Validation failed", "validation", http.StatusBadRequest}
@@ -125,3 +325,43 @@ func NewInternalError(message string) *AppError {
 		HTTPStatus: http.StatusInternalServerError,
 	}
 }
+
+// NewOtpInvalidError tells how many tries are left before the code is burned
+func NewOtpInvalidError(attemptsLeft int) *AppError {
+	return &AppError{
+		Code:       "OTP_INVALID",
+		Message:    fmt.Sprintf("Wrong code, %d attempts left", attemptsLeft),
+		Type:       "unauthorized",
+		HTTPStatus: http.StatusUnauthorized,
+	}
+}
+
+// NewPinInvalidError tells how many tries are left before the PIN locks
+func NewPinInvalidError(attemptsLeft int) *AppError {
+	return &AppError{
+		Code:       "PIN_INVALID",
+		Message:    fmt.Sprintf("Wrong transaction PIN, %d attempts left", attemptsLeft),
+		Type:       "unauthorized",
+		HTTPStatus: http.StatusUnauthorized,
+	}
+}
+
+// NewLoginLockedError tells when the next login attempt is allowed
+func NewLoginLockedError(retryAfter time.Duration) *AppError {
+	return &AppError{
+		Code:       "LOGIN_LOCKED",
+		Message:    fmt.Sprintf("Too many failed logins, try again in %d seconds", int(math.Ceil(retryAfter.Seconds()))),
+		Type:       "rate_limit",
+		HTTPStatus: http.StatusTooManyRequests,
+	}
+}
+
+// NewRateLimitedError tells when the budget of the route is refilled
+func NewRateLimitedError(retryAfter time.Duration) *AppError {
+	return &AppError{
+		Code:       "RATE_LIMITED",
+		Message:    fmt.Sprintf("Too many requests, try again in %d seconds", int(math.Ceil(retryAfter.Seconds()))),
+		Type:       "rate_limit",
+		HTTPStatus: http.StatusTooManyRequests,
+	}
+}
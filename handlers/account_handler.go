@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"errors"
+	"fmt"
 	"gopay-clone/models"
 	"gopay-clone/services"
 
@@ -9,6 +9,7 @@ import (
 	"gopay-clone/validator"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -16,6 +17,7 @@ import (
 type AccountHandler struct {
 	accountService     *services.AccountService
 	transactionService *services.TransactionService
+	statementService   *services.StatementService
 }
 
 /*
@@ -26,8 +28,21 @@ type AccountHandler struct {
 	return split error if it might comes from the service
 */
 
-func NewAccountHandler(accountService *services.AccountService, transactionService *services.TransactionService) *AccountHandler {
-	return &AccountHandler{accountService: accountService, transactionService: transactionService}
+var transactionPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+		"amount":     {Column: "amount", Kind: utils.SortNumber},
+	},
+	Filters: map[string]string{
+		"type":         "type",
+		"category":     "category",
+		"status":       "status",
+		"service_type": "service_type",
+	},
+}
+
+func NewAccountHandler(accountService *services.AccountService, transactionService *services.TransactionService, statementService *services.StatementService) *AccountHandler {
+	return &AccountHandler{accountService: accountService, transactionService: transactionService, statementService: statementService}
 }
 
 // CreateAccount godoc
@@ -47,6 +62,10 @@ func (h *AccountHandler) CreateAccount(c echo.Context) error {
 		return err
 	}
 
+	if err := ensureOwner(c, req.UserId); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
 	account := &models.Account{
 		Name:    req.Name,
 		Balance: req.Balance,
@@ -109,9 +128,8 @@ func (h *AccountHandler) UpdateAccount(c echo.Context) error {
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
-	loggedInUserId := utils.CLaimJwt(c)
-	if int(account.UserId) != int(loggedInUserId) {
-		return utils.ForbiddenResponse(c, errors.New("unauthorized access"))
+	if err := ensureOwner(c, account.UserId); err != nil {
+		return utils.SplitErrorResponse(c, err)
 	}
 	var req validator.UpdateAccountRequest
 	if err := utils.BindAndValidate(c, &req, validator.ValidateUpdateAccount); err != nil {
@@ -147,21 +165,31 @@ func (h *AccountHandler) GetAccountDetail(c echo.Context) error {
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
-	loggedInUserId := utils.CLaimJwt(c)
-	if int(account.UserId) != int(loggedInUserId) {
-		return utils.ForbiddenResponse(c, errors.New("unauthorized access"))
+	if err := ensureOwner(c, account.UserId); err != nil {
+		return utils.SplitErrorResponse(c, err)
 	}
 	return utils.SuccessResponse(c, http.StatusOK, "Account detail fetched successfully", account)
 }
 
 // GetTransactionByAccounts godoc
 // @Summary Get latest transactions from account
-// @Description Get all latest. transaction from single account
+// @Description Get sent and received transactions of a single account, each with its direction and counterparty
 // @Param account_id path int true "Account ID"
+// @Param direction query string false "in or out, both when empty"
+// @Param type query string false "Comma separated transaction types"
+// @Param category query string false "Comma separated transaction categories"
+// @Param service_type query string false "Comma separated service types"
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Param sort_by query string false "created_at or amount"
+// @Param sort_dir query string false "asc or desc"
+// @Param from query string false "RFC3339 or YYYY-MM-DD"
+// @Param to query string false "RFC3339 or YYYY-MM-DD, inclusive"
+// @Param status query string false "Comma separated transaction statuses"
 // @Tags Account
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {array} models.Account
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.TransactionHistoryItem}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
 // @Router /accounts/:account_id/transactions [get]
@@ -171,9 +199,115 @@ func (h *AccountHandler) GetTransactionByAccounts(c echo.Context) error {
 		return utils.ValidationErrorResponse(c, err)
 	}
 
-	transactions, err := h.transactionService.GetTransactionsByAccount(uint(accountId))
+	if err := ensureAccountOwner(c, h.accountService, uint(accountId)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	var query validator.TransactionHistoryQuery
+	if err := utils.BindAndValidate(c, &query, validator.ValidateTransactionHistoryQuery); err != nil {
+		return err
+	}
+	page, err := utils.ParsePageParams(c, transactionPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	transactions, meta, err := h.transactionService.GetTransactionsByAccount(uint(accountId), query.Direction, page)
 	if err != nil {
-		return utils.NotFoundResponse(c, "user id")
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Transactions for account fetched successfully", transactions, meta)
+}
+
+// GetTransactionSummary godoc
+// @Summary Get money in/out totals of an account
+// @Description Totals of completed transactions grouped per day, week or month, accepts the same filters as the history
+// @Param account_id path int true "Account ID"
+// @Param period query string false "day, week or month (default)"
+// @Param from query string false "RFC3339 or YYYY-MM-DD"
+// @Param to query string false "RFC3339 or YYYY-MM-DD, inclusive"
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APISuccessResponse{data=models.TransactionSummary}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /accounts/:account_id/transactions/summary [get]
+func (h *AccountHandler) GetTransactionSummary(c echo.Context) error {
+	accountId, err := strconv.Atoi(c.Param("account_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	if err := ensureAccountOwner(c, h.accountService, uint(accountId)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	var query validator.TransactionHistoryQuery
+	if err := utils.BindAndValidate(c, &query, validator.ValidateTransactionHistoryQuery); err != nil {
+		return err
+	}
+	page, err := utils.ParsePageParams(c, transactionPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	summary, err := h.transactionService.GetTransactionSummary(uint(accountId), query.Period, page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Transaction summary fetched successfully", summary)
+}
+
+// GetStatement godoc
+// @Summary Download an account statement
+// @Description Opening balance, every movement with running balance and closing balance for a month or date range
+// @Param account_id path int true "Account ID"
+// @Param month query string false "YYYY-MM, alternative to from/to"
+// @Param from query string false "YYYY-MM-DD"
+// @Param to query string false "YYYY-MM-DD, inclusive"
+// @Param format query string false "json (default), csv or pdf"
+// @Tags Account
+// @Produce json
+// @Produce text/csv
+// @Produce application/pdf
+// @Security BearerAuth
+// @Success 200 {object} utils.APISuccessResponse{data=models.Statement}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /accounts/:account_id/statement [get]
+func (h *AccountHandler) GetStatement(c echo.Context) error {
+	accountId, err := strconv.Atoi(c.Param("account_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	if err := ensureAccountOwner(c, h.accountService, uint(accountId)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	var query validator.StatementQuery
+	if err := utils.BindAndValidate(c, &query, validator.ValidateStatementQuery); err != nil {
+		return err
+	}
+
+	from, to := query.Period(time.Now().UTC())
+	statement, err := h.statementService.GenerateStatement(uint(accountId), from, to)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
+	filename := fmt.Sprintf("statement-%d-%s-%s", accountId, from.Format("20060102"), to.Format("20060102"))
+	switch query.Format {
+	case "csv":
+		body, err := utils.StatementCSV(statement)
+		if err != nil {
+			return utils.InternalErrorResponse(c, err)
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`.csv"`)
+		return c.Blob(http.StatusOK, "text/csv", body)
+	case "pdf":
+		body, err := utils.StatementPDF(statement)
+		if err != nil {
+			return utils.InternalErrorResponse(c, err)
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`.pdf"`)
+		return c.Blob(http.StatusOK, "application/pdf", body)
 	}
-	return utils.SuccessResponse(c, http.StatusOK, "Transactions for account fetched successfully", transactions)
+	return utils.SuccessResponse(c, http.StatusOK, "Statement generated successfully", statement)
 }
@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"gopay-clone/middleware"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AdminHandler struct {
+	driverService      *services.DriverService
+	userService        *services.UserService
+	orderService       *services.OrderService
+	transactionService *services.TransactionService
+	auditService       *services.AuditService
+}
+
+var adminDriverPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+		"rating":     {Column: "rating", Kind: utils.SortNumber},
+	},
+	Filters: map[string]string{"verification": "verification", "status": "status", "vehicle_type": "vehicle_type"},
+}
+
+var auditPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+	},
+	Filters: map[string]string{"actor_id": "actor_id", "target_type": "target_type", "target_id": "target_id"},
+}
+
+func NewAdminHandler(
+	driverService *services.DriverService,
+	userService *services.UserService,
+	orderService *services.OrderService,
+	transactionService *services.TransactionService,
+	auditService *services.AuditService,
+) *AdminHandler {
+	return &AdminHandler{
+		driverService:      driverService,
+		userService:        userService,
+		orderService:       orderService,
+		transactionService: transactionService,
+		auditService:       auditService,
+	}
+}
+
+// GetDrivers godoc
+// @Summary List drivers for review
+// @Description Every driver with the license documents, filter on verification=pending to get the review queue
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param verification query string false "Comma separated: pending, verified, rejected"
+// @Param status query string false "Comma separated driver statuses"
+// @Param vehicle_type query string false "Comma separated vehicle types"
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.DriverProfile}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /admin/drivers [get]
+func (h *AdminHandler) GetDrivers(c echo.Context) error {
+	page, err := utils.ParsePageParams(c, adminDriverPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	drivers, meta, err := h.driverService.GetDriversForReview(page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Drivers fetched successfully", drivers, meta)
+}
+
+// GetDriver godoc
+// @Summary Get a driver for review
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param driver_id path int true "Driver ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.DriverProfile}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /admin/drivers/{driver_id} [get]
+func (h *AdminHandler) GetDriver(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("driver_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	driver, err := h.driverService.GetDriverByID(uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Driver fetched successfully", driver)
+}
+
+// VerifyDriver godoc
+// @Summary Approve a driver's documents
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param driver_id path int true "Driver ID"
+// @Param action body validator.AdminActionRequest false "Optional note"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /admin/drivers/{driver_id}/verify [post]
+func (h *AdminHandler) VerifyDriver(c echo.Context) error {
+	id, req, err := h.bindAction(c, "driver_id", validator.ValidateAdminAction)
+	if err != nil {
+		return err
+	}
+	if err := h.driverService.VerifyDriver(id, req.Reason); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Driver verified successfully", nil)
+}
+
+// RejectDriver godoc
+// @Summary Reject a driver's documents
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param driver_id path int true "Driver ID"
+// @Param action body validator.AdminActionRequest true "Reason, shown to the driver"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /admin/drivers/{driver_id}/reject [post]
+func (h *AdminHandler) RejectDriver(c echo.Context) error {
+	id, req, err := h.bindAction(c, "driver_id", validator.ValidateAdminReason)
+	if err != nil {
+		return err
+	}
+	if err := h.driverService.RejectDriver(id, req.Reason); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Driver rejected successfully", nil)
+}
+
+// SuspendDriver godoc
+// @Summary Suspend a driver
+// @Description The driver goes offline and cannot come back online until reinstated
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param driver_id path int true "Driver ID"
+// @Param action body validator.AdminActionRequest true "Reason"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /admin/drivers/{driver_id}/suspend [post]
+func (h *AdminHandler) SuspendDriver(c echo.Context) error {
+	id, req, err := h.bindAction(c, "driver_id", validator.ValidateAdminReason)
+	if err != nil {
+		return err
+	}
+	if err := h.driverService.SuspendDriver(id, req.Reason); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Driver suspended successfully", nil)
+}
+
+// ReinstateDriver godoc
+// @Summary Lift a driver's suspension
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param driver_id path int true "Driver ID"
+// @Param action body validator.AdminActionRequest false "Optional note"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /admin/drivers/{driver_id}/reinstate [post]
+func (h *AdminHandler) ReinstateDriver(c echo.Context) error {
+	id, _, err := h.bindAction(c, "driver_id", validator.ValidateAdminAction)
+	if err != nil {
+		return err
+	}
+	if err := h.driverService.ReinstateDriver(id); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Driver reinstated successfully", nil)
+}
+
+// FreezeUser godoc
+// @Summary Freeze a user's wallet
+// @Description No money moves in or out of the user's accounts while frozen
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param action body validator.AdminActionRequest true "Reason"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /admin/users/{user_id}/freeze [post]
+func (h *AdminHandler) FreezeUser(c echo.Context) error {
+	id, req, err := h.bindAction(c, "user_id", validator.ValidateAdminReason)
+	if err != nil {
+		return err
+	}
+	if err := h.userService.FreezeUser(id, req.Reason); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "User frozen successfully", nil)
+}
+
+// UnfreezeUser godoc
+// @Summary Unfreeze a user's wallet
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "User ID"
+// @Param action body validator.AdminActionRequest false "Optional note"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /admin/users/{user_id}/unfreeze [post]
+func (h *AdminHandler) UnfreezeUser(c echo.Context) error {
+	id, _, err := h.bindAction(c, "user_id", validator.ValidateAdminAction)
+	if err != nil {
+		return err
+	}
+	if err := h.userService.UnfreezeUser(id); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "User unfrozen successfully", nil)
+}
+
+// GetOrders godoc
+// @Summary List orders of every user
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Comma separated order statuses"
+// @Param merchant_id query string false "Comma separated merchant ids"
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.Order}
+// @Router /admin/orders [get]
+func (h *AdminHandler) GetOrders(c echo.Context) error {
+	page, err := utils.ParsePageParams(c, orderPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	orders, meta, err := h.orderService.GetOrders(page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Orders fetched successfully", orders, meta)
+}
+
+// GetOrder godoc
+// @Summary Get any order
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param order_id path int true "Order ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.Order}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /admin/orders/{order_id} [get]
+func (h *AdminHandler) GetOrder(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("order_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	order, err := h.orderService.GetOrderByID(uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Order fetched successfully", order)
+}
+
+// GetTransactions godoc
+// @Summary List transactions of every user
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param type query string false "Comma separated transaction types"
+// @Param status query string false "Comma separated transaction statuses"
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.Transaction}
+// @Router /admin/transactions [get]
+func (h *AdminHandler) GetTransactions(c echo.Context) error {
+	page, err := utils.ParsePageParams(c, transactionPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	transactions, meta, err := h.transactionService.GetTransactions(page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Transactions fetched successfully", transactions, meta)
+}
+
+// GetTransaction godoc
+// @Summary Get any transaction
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param transaction_id path int true "Transaction ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.Transaction}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /admin/transactions/{transaction_id} [get]
+func (h *AdminHandler) GetTransaction(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("transaction_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	transaction, err := h.transactionService.GetTransactionById(uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Transaction fetched successfully", transaction)
+}
+
+// GetAuditLogs godoc
+// @Summary Read the admin audit log
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param actor_id query string false "Comma separated admin user ids"
+// @Param target_type query string false "e.g. drivers, users, orders"
+// @Param target_id query string false "Comma separated target ids"
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.AuditLog}
+// @Router /admin/audit-logs [get]
+func (h *AdminHandler) GetAuditLogs(c echo.Context) error {
+	page, err := utils.ParsePageParams(c, auditPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	logs, meta, err := h.auditService.GetLogs(page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Audit logs fetched successfully", logs, meta)
+}
+
+// bindAction reads the target id and the reason of an admin action, the reason goes on the audit entry
+func (h *AdminHandler) bindAction(c echo.Context, param string, validate func(*validator.AdminActionRequest) error) (uint, *validator.AdminActionRequest, error) {
+	id, err := strconv.Atoi(c.Param(param))
+	if err != nil {
+		// written here like BindAndValidate does, the error only stops the handler
+		utils.ValidationErrorResponse(c, err)
+		return 0, nil, err
+	}
+	var req validator.AdminActionRequest
+	if err := utils.BindAndValidate(c, &req, validate); err != nil {
+		return 0, nil, err
+	}
+	middleware.SetAuditReason(c, req.Reason)
+	return uint(id), &req, nil
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"gopay-clone/models"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AuthHandler struct {
+	authService  *services.AuthService
+	emailService *services.EmailService
+}
+
+func NewAuthHandler(authService *services.AuthService, emailService *services.EmailService) *AuthHandler {
+	return &AuthHandler{authService: authService, emailService: emailService}
+}
+
+// RefreshToken godoc
+// @Summary Exchange a refresh token for a new token pair
+// @Description The refresh token is single use, presenting a spent one ends its whole session
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param refresh body validator.RefreshTokenRequest true "Refresh token from login or the last refresh"
+// @Success 200 {object} utils.APISuccessResponse{data=models.TokenPair}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Router /public/auth/refresh [post]
+func (h *AuthHandler) RefreshToken(c echo.Context) error {
+	var req validator.RefreshTokenRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateRefreshToken); err != nil {
+		return err
+	}
+	tokens, err := h.authService.Refresh(req.RefreshToken, c.RealIP())
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	c.Response().Header().Set("Authorization", "Bearer "+tokens.Token)
+	return utils.SuccessResponse(c, http.StatusOK, "Token refreshed successfully", tokens)
+}
+
+// Logout godoc
+// @Summary Log out of this session
+// @Description Revokes the session of the bearer token, its access and refresh tokens stop working
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c echo.Context) error {
+	if err := h.authService.Logout(uint(utils.CLaimJwt(c)), utils.ClaimSessionID(c)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Logged out successfully", nil)
+}
+
+// LogoutAll godoc
+// @Summary Log out of every device
+// @Description Revokes all sessions of the user including this one
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Router /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c echo.Context) error {
+	if err := h.authService.LogoutAll(uint(utils.CLaimJwt(c))); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Logged out of all devices successfully", nil)
+}
+
+// GetSessions godoc
+// @Summary List the devices the user is logged in on
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APISuccessResponse{data=[]models.Session}
+// @Router /auth/sessions [get]
+func (h *AuthHandler) GetSessions(c echo.Context) error {
+	sessions, err := h.authService.GetSessions(uint(utils.CLaimJwt(c)), utils.ClaimSessionID(c))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Sessions fetched successfully", sessions)
+}
+
+// RevokeSession godoc
+// @Summary Log a device out remotely
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Param session_id path int true "Session ID"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /auth/sessions/{session_id} [delete]
+func (h *AuthHandler) RevokeSession(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("session_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	if err := h.authService.RevokeSession(uint(utils.CLaimJwt(c)), uint(id)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Session ended successfully", nil)
+}
+
+// SendEmailVerification godoc
+// @Summary Send the email verification link again
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 429 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /auth/email/verification [post]
+func (h *AuthHandler) SendEmailVerification(c echo.Context) error {
+	if err := h.emailService.SendVerification(c.Request().Context(), uint(utils.CLaimJwt(c))); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Verification email sent", nil)
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email with the token from the verification link
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param token body validator.VerifyEmailRequest true "Token from the link"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Router /public/auth/email/verify [post]
+func (h *AuthHandler) VerifyEmail(c echo.Context) error {
+	var req validator.VerifyEmailRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateVerifyEmail); err != nil {
+		return err
+	}
+	if err := h.emailService.VerifyEmail(req.Token); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Email verified successfully", nil)
+}
+
+// ForgotPassword godoc
+// @Summary Mail a password reset link
+// @Description Answers the same whether the email is registered or not
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param email body validator.ForgotPasswordRequest true "Email of the account"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /public/auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c echo.Context) error {
+	var req validator.ForgotPasswordRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateForgotPassword); err != nil {
+		return err
+	}
+	if err := h.emailService.RequestPasswordReset(c.Request().Context(), req.Email); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "If the email is registered a reset link is on its way", nil)
+}
+
+// ResetPassword godoc
+// @Summary Set a new password with the token from the reset link
+// @Description Ends every session of the user, log in again with the new password
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param reset body validator.ResetPasswordRequest true "Token from the link and the new password"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Router /public/auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c echo.Context) error {
+	var req validator.ResetPasswordRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateResetPassword); err != nil {
+		return err
+	}
+	if err := h.emailService.ResetPassword(req.Token, req.NewPassword); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Password reset successfully", nil)
+}
+
+// Devices send back the token the last login handed them and a readable name, a login
+// without a token is a new device
+const (
+	DeviceTokenHeader = "X-Device-Token"
+	DeviceNameHeader  = "X-Device-Name"
+)
+
+func deviceInfo(c echo.Context) models.DeviceInfo {
+	userAgent := truncate(c.Request().UserAgent(), 255)
+	device := models.DeviceInfo{
+		Token:     truncate(strings.TrimSpace(c.Request().Header.Get(DeviceTokenHeader)), 128),
+		Name:      truncate(strings.TrimSpace(c.Request().Header.Get(DeviceNameHeader)), 100),
+		UserAgent: userAgent,
+		IP:        c.RealIP(),
+	}
+	if device.Name == "" {
+		device.Name = truncate(userAgent, 100)
+	}
+	if device.Name == "" {
+		device.Name = "Unknown device"
+	}
+	return device
+}
+
+// startSession logs the user in on the calling device, a device the user has not
+// used before gets a mail so a stolen password does not go unnoticed
+func startSession(c echo.Context, authService *services.AuthService, emailService *services.EmailService, user *models.User) error {
+	device := deviceInfo(c)
+	known, err := authService.KnownDevice(user.ID, device.Token)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	tokens, err := authService.StartSession(user, device)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	if !known {
+		if err := emailService.NotifyNewDevice(c.Request().Context(), user, device); err != nil {
+			c.Logger().Warn(err)
+		}
+	}
+
+	// Set the Authorization header with Bearer token
+	c.Response().Header().Set("Authorization", "Bearer "+tokens.Token)
+
+	return utils.SuccessResponse(c, http.StatusOK, "Login successful", tokens)
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
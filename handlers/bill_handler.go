@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+var billPaymentPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+		"total":      {Column: "total", Kind: utils.SortNumber},
+	},
+	Filters: map[string]string{"status": "status", "biller_id": "biller_id"},
+}
+
+type BillHandler struct {
+	billService   *services.BillService
+	budgetService *services.BudgetService
+	pinService    *services.PinService
+	emailService  *services.EmailService
+}
+
+func NewBillHandler(billService *services.BillService, budgetService *services.BudgetService, pinService *services.PinService, emailService *services.EmailService) *BillHandler {
+	return &BillHandler{billService: billService, budgetService: budgetService, pinService: pinService, emailService: emailService}
+}
+
+// GetBillers godoc
+// @Summary Biller catalog
+// @Tags Bill
+// @Produce json
+// @Security BearerAuth
+// @Param category query string false "electricity, water, internet or phone_credit"
+// @Success 200 {object} utils.APISuccessResponse{data=[]models.Biller}
+// @Router /bills/billers [get]
+func (h *BillHandler) GetBillers(c echo.Context) error {
+	var req validator.BillerQuery
+	if err := utils.BindAndValidate(c, &req, validator.ValidateBillerQuery); err != nil {
+		return err
+	}
+	billers, err := h.billService.GetBillers(req.Category)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Billers fetched successfully", billers)
+}
+
+// InquireBill godoc
+// @Summary Check a bill
+// @Description Ask the biller what a customer number owes. Prepaid billers take the amount to buy. The inquiry is valid for 15 minutes.
+// @Tags Bill
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param inquiry body validator.BillInquiryRequest true "Biller and customer number"
+// @Success 201 {object} utils.APISuccessResponse{data=models.BillInquiry}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /bills/inquiries [post]
+func (h *BillHandler) InquireBill(c echo.Context) error {
+	var req validator.BillInquiryRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateBillInquiry); err != nil {
+		return err
+	}
+	inquiry, err := h.billService.Inquire(c.Request().Context(), uint(utils.CLaimJwt(c)), req.BillerCode, req.CustomerNumber, req.Amount)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Bill fetched successfully", inquiry)
+}
+
+// PayBill godoc
+// @Summary Pay a bill
+// @Description Pays an open inquiry. The receipt carries the biller's receipt number and, for electricity tokens, the token.
+// @Tags Bill
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payment body validator.BillPaymentRequest true "Inquiry to pay"
+// @Success 201 {object} utils.APISuccessResponse{data=models.BillPayment}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 502 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Param X-Transaction-PIN header string false "Transaction PIN, required above the configured amount"
+// @Router /bills/payments [post]
+func (h *BillHandler) PayBill(c echo.Context) error {
+	var req validator.BillPaymentRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateBillPayment); err != nil {
+		return err
+	}
+	inquiry, err := h.billService.GetInquiry(uint(utils.CLaimJwt(c)), req.InquiryID)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	if err := authorizePayment(c, h.pinService, h.emailService, inquiry.Total); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
+	payment, err := h.billService.Pay(c.Request().Context(), uint(utils.CLaimJwt(c)), req.InquiryID, req.SenderAccountID)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	if payment.Transaction != nil {
+		if err := h.budgetService.NotifyAfterPayment(payment.Transaction); err != nil {
+			c.Logger().Warn(err)
+		}
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Bill paid successfully", payment)
+}
+
+// GetBillPayments godoc
+// @Summary Bill payment history
+// @Tags Bill
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, success or failed"
+// @Param biller_id query int false "Only one biller"
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.BillPayment}
+// @Router /bills/payments [get]
+func (h *BillHandler) GetBillPayments(c echo.Context) error {
+	page, err := utils.ParsePageParams(c, billPaymentPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	payments, meta, err := h.billService.GetPayments(uint(utils.CLaimJwt(c)), page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Bill payments fetched successfully", payments, meta)
+}
+
+// GetBillPayment godoc
+// @Summary Bill payment receipt
+// @Tags Bill
+// @Produce json
+// @Security BearerAuth
+// @Param payment_id path int true "Bill payment ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.BillPayment}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /bills/payments/{payment_id} [get]
+func (h *BillHandler) GetBillPayment(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("payment_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	payment, err := h.billService.GetPayment(uint(utils.CLaimJwt(c)), uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Bill payment fetched successfully", payment)
+}
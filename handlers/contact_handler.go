@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"errors"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+var contactPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+		"nickname":   {Column: "nickname", Kind: utils.SortString},
+	},
+	Filters: map[string]string{"is_favourite": "is_favourite"},
+}
+
+type ContactHandler struct {
+	contactService     *services.ContactService
+	accountService     *services.AccountService
+	transactionService *services.TransactionService
+	budgetService      *services.BudgetService
+	pinService         *services.PinService
+	emailService       *services.EmailService
+}
+
+func NewContactHandler(
+	contactService *services.ContactService,
+	accountService *services.AccountService,
+	transactionService *services.TransactionService,
+	budgetService *services.BudgetService,
+	pinService *services.PinService,
+	emailService *services.EmailService,
+) *ContactHandler {
+	return &ContactHandler{
+		contactService:     contactService,
+		accountService:     accountService,
+		transactionService: transactionService,
+		budgetService:      budgetService,
+		pinService:         pinService,
+		emailService:       emailService,
+	}
+}
+
+// GetContacts godoc
+// @Summary List contacts
+// @Tags Contact
+// @Produce json
+// @Security BearerAuth
+// @Param is_favourite query bool false "Only favourites"
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.Contact}
+// @Router /contacts [get]
+func (h *ContactHandler) GetContacts(c echo.Context) error {
+	page, err := utils.ParsePageParams(c, contactPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	contacts, meta, err := h.contactService.GetContacts(uint(utils.CLaimJwt(c)), page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Contacts fetched successfully", contacts, meta)
+}
+
+// AddContact godoc
+// @Summary Add a contact
+// @Description Add a registered user as a contact by user id, phone or email
+// @Tags Contact
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param contact body validator.AddContactRequest true "Who to add"
+// @Success 201 {object} utils.APISuccessResponse{data=models.Contact}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /contacts [post]
+func (h *ContactHandler) AddContact(c echo.Context) error {
+	var req validator.AddContactRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateAddContact); err != nil {
+		return err
+	}
+	ownerID := uint(utils.CLaimJwt(c))
+
+	var targetID uint
+	if req.TargetID != nil {
+		targetID = *req.TargetID
+	} else {
+		found, err := h.contactService.LookupUser(ownerID, req.Phone, req.Email)
+		if err != nil {
+			return utils.SplitErrorResponse(c, err)
+		}
+		targetID = found.ID
+	}
+
+	contact := &models.Contact{
+		OwnerID:  ownerID,
+		TargetID: targetID,
+		Nickname: strings.TrimSpace(req.Nickname),
+	}
+	if err := h.contactService.AddContact(contact); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Contact added successfully", contact)
+}
+
+// UpdateContact godoc
+// @Summary Rename or favourite a contact
+// @Tags Contact
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param contact_id path int true "Contact ID"
+// @Param contact body validator.UpdateContactRequest true "Changes"
+// @Success 200 {object} utils.APISuccessResponse{data=models.Contact}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /contacts/{contact_id} [put]
+func (h *ContactHandler) UpdateContact(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("contact_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	var req validator.UpdateContactRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateUpdateContact); err != nil {
+		return err
+	}
+	ownerID := uint(utils.CLaimJwt(c))
+
+	updates := map[string]any{}
+	if req.Nickname != nil {
+		updates["nickname"] = strings.TrimSpace(*req.Nickname)
+	}
+	if req.IsFavourite != nil {
+		updates["is_favourite"] = *req.IsFavourite
+	}
+	if err := h.contactService.UpdateContact(ownerID, uint(id), updates); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
+	contact, err := h.contactService.GetContact(ownerID, uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Contact updated successfully", contact)
+}
+
+// DeleteContact godoc
+// @Summary Remove a contact
+// @Tags Contact
+// @Produce json
+// @Security BearerAuth
+// @Param contact_id path int true "Contact ID"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /contacts/{contact_id} [delete]
+func (h *ContactHandler) DeleteContact(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("contact_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	if err := h.contactService.DeleteContact(uint(utils.CLaimJwt(c)), uint(id)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Contact deleted successfully", nil)
+}
+
+// LookupUser godoc
+// @Summary Find a registered user by phone or email
+// @Tags Contact
+// @Produce json
+// @Security BearerAuth
+// @Param phone query string false "10 digit phone number"
+// @Param email query string false "Email address"
+// @Success 200 {object} utils.APISuccessResponse{data=models.UserLookup}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /contacts/lookup [get]
+func (h *ContactHandler) LookupUser(c echo.Context) error {
+	var req validator.LookupUserQuery
+	if err := utils.BindAndValidate(c, &req, validator.ValidateLookupUser); err != nil {
+		return err
+	}
+	found, err := h.contactService.LookupUser(uint(utils.CLaimJwt(c)), req.Phone, req.Email)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "User found", found)
+}
+
+// GetRecentCounterparties godoc
+// @Summary Recent counterparties
+// @Description People the user recently sent money to or received money from
+// @Tags Contact
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "How many, default 10, max 50"
+// @Success 200 {object} utils.APISuccessResponse{data=[]models.RecentCounterparty}
+// @Router /contacts/recent [get]
+func (h *ContactHandler) GetRecentCounterparties(c echo.Context) error {
+	limit := 10
+	if raw := c.QueryParam("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			return utils.ValidationErrorResponse(c, errors.New("limit must be a positive number"))
+		}
+		limit = min(parsed, 50)
+	}
+	recent, err := h.contactService.GetRecentCounterparties(uint(utils.CLaimJwt(c)), limit)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Recent counterparties fetched successfully", recent)
+}
+
+// TransferToContact godoc
+// @Summary Send money to a contact
+// @Description Transfer to the contact's main balance account without knowing its account id
+// @Tags Contact
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param contact_id path int true "Contact ID"
+// @Param transfer body validator.ContactTransferRequest true "Transfer"
+// @Success 201 {object} utils.APISuccessResponse{data=models.Transaction}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Param X-Transaction-PIN header string false "Transaction PIN, required above the configured amount"
+// @Router /contacts/{contact_id}/transfer [post]
+func (h *ContactHandler) TransferToContact(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("contact_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	var req validator.ContactTransferRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateContactTransfer); err != nil {
+		return err
+	}
+	ownerID := uint(utils.CLaimJwt(c))
+
+	contact, err := h.contactService.GetContact(ownerID, uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
+	var sender *models.Account
+	if req.SenderAccountID != nil {
+		sender, err = h.accountService.GetAccount(*req.SenderAccountID)
+		if err == nil && sender.UserId != ownerID {
+			err = apperrors.ErrForbidden
+		}
+	} else {
+		sender, err = h.accountService.GetMainBalanceAccount(ownerID)
+	}
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
+	receiver, err := h.accountService.GetMainBalanceAccount(contact.TargetID)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
+	description := req.Description
+	if description == "" {
+		description = "Transfer to " + contactDisplayName(contact)
+	}
+	transaction := &models.Transaction{
+		Amount:            req.Amount,
+		SenderAccountID:   sender.ID,
+		ReceiverAccountID: receiver.ID,
+		Type:              models.Transfer,
+		Category:          models.TransferCat,
+		Status:            models.TransactionCompleted,
+		ServiceType:       models.ServiceNone,
+		Description:       description,
+	}
+	if err := authorizePayment(c, h.pinService, h.emailService, transaction.Amount); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
+	if err := h.transactionService.CreateTransaction(transaction); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	if err := h.budgetService.NotifyAfterPayment(transaction); err != nil {
+		c.Logger().Warn(err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusCreated, "Transfer sent successfully", transaction)
+}
+
+func contactDisplayName(contact *models.Contact) string {
+	if contact.Nickname != "" {
+		return contact.Nickname
+	}
+	return contact.Target.Name
+}
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	apperrors "gopay-clone/errors"
 	"gopay-clone/models"
 	"gopay-clone/services"
 	"gopay-clone/utils"
 	"gopay-clone/validator"
 	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 )
@@ -13,10 +15,19 @@ import (
 type DriverHandler struct {
 	driverService *services.DriverService
 	userService   *services.UserService
+	rideService   *services.RideService
 }
 
-func NewDriverHandler(driverService *services.DriverService, userService *services.UserService) *DriverHandler {
-	return &DriverHandler{driverService: driverService, userService: userService}
+var driverPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+		"rating":     {Column: "rating", Kind: utils.SortNumber},
+	},
+	Filters: map[string]string{"status": "status", "vehicle_type": "vehicle_type"},
+}
+
+func NewDriverHandler(driverService *services.DriverService, userService *services.UserService, rideService *services.RideService) *DriverHandler {
+	return &DriverHandler{driverService: driverService, userService: userService, rideService: rideService}
 }
 
 // CreateDriver godoc
@@ -97,16 +108,26 @@ func (h *DriverHandler) GetDriverByID(c echo.Context) error {
 // @Description Retrieve all registered drivers
 // @Tags Driver
 // @Produce json
-// @Success 200 {object} utils.APISuccessResponse{data=[]models.DriverProfile}
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Param sort_by query string false "created_at or rating"
+// @Param sort_dir query string false "asc or desc"
+// @Param status query string false "Comma separated driver statuses"
+// @Param vehicle_type query string false "Comma separated vehicle types"
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.DriverProfile}
 // @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
 // @Router /public/drivers [get]
 func (h *DriverHandler) GetAllDrivers(c echo.Context) error {
-	drivers, err := h.driverService.GetAllDrivers()
+	page, err := utils.ParsePageParams(c, driverPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	drivers, meta, err := h.driverService.GetAllDrivers(page)
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
 
-	return utils.SuccessResponse(c, http.StatusOK, "Drivers fetched successfully", drivers)
+	return utils.PaginatedResponse(c, http.StatusOK, "Drivers fetched successfully", drivers, meta)
 }
 
 // GetAvailableDrivers godoc
@@ -114,28 +135,29 @@ func (h *DriverHandler) GetAllDrivers(c echo.Context) error {
 // @Description Retrieve all available drivers
 // @Tags Driver
 // @Produce json
-// @Success 200 {object} utils.APISuccessResponse{data=[]models.DriverProfile}
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Param vehicle_type query string false "Comma separated vehicle types"
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.DriverProfile}
 //
 //	@Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
 //
 // @Router /drivers/available [get]
 func (h *DriverHandler) GetAvailableDrivers(c echo.Context) error {
-	vehicleType := c.QueryParam("vehicle_type")
-
-	var drivers []models.DriverProfile
-	var err error
-
-	if vehicleType != "" {
-		drivers, err = h.driverService.GetDriversByVehicleType(models.VehicleType(vehicleType))
-	} else {
-		drivers, err = h.driverService.GetAvailableDrivers()
+	page, err := utils.ParsePageParams(c, utils.PageOptions{
+		Sorts:   driverPageOptions.Sorts,
+		Filters: map[string]string{"vehicle_type": "vehicle_type"},
+	})
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
 	}
 
+	drivers, meta, err := h.driverService.GetAvailableDriversPage(page)
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
 
-	return utils.SuccessResponse(c, http.StatusOK, "Available drivers fetched successfully", drivers)
+	return utils.PaginatedResponse(c, http.StatusOK, "Available drivers fetched successfully", drivers, meta)
 }
 
 // UpdateDriver godoc
@@ -211,6 +233,10 @@ func (h *DriverHandler) UpdateDriverStatus(c echo.Context) error {
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
+	// only an admin lifts a suspension
+	if driver.Status == models.Suspended {
+		return utils.SplitErrorResponse(c, apperrors.ErrDriverSuspended)
+	}
 
 	if err := h.driverService.UpdateDriverStatus(uint(driver.ID), req.Status); err != nil {
 		return utils.SplitErrorResponse(c, err)
@@ -219,6 +245,39 @@ func (h *DriverHandler) UpdateDriverStatus(c echo.Context) error {
 	return utils.SuccessResponse(c, http.StatusOK, "Driver status updated successfully", nil)
 }
 
+// UpdateRideStatus godoc
+// @Summary Move a ride of the driver along
+// @Description pickup, ongoing, completed or cancelled, a completed ride is announced as ride.completed
+// @Tags Driver
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param ride_id path int true "Ride ID"
+// @Param status body validator.UpdateRideStatusRequest true "New ride status"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /drivers/rides/{ride_id}/status [put]
+func (h *DriverHandler) UpdateRideStatus(c echo.Context) error {
+	rideID, err := strconv.Atoi(c.Param("ride_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	var req validator.UpdateRideStatusRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateUpdateRideStatus); err != nil {
+		return err
+	}
+	driver, err := h.driverService.GetDriverByUserID(uint(utils.CLaimJwt(c)))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
+	if err := h.rideService.UpdateRideStatus(uint(rideID), driver.ID, req.Status); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Ride status updated successfully", nil)
+}
+
 // UpdateDriverLocation godoc
 // @Summary Update driver location by ID
 // @Description Update location of a driver
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"gopay-clone/models"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InsightHandler struct {
+	insightService *services.InsightService
+	budgetService  *services.BudgetService
+}
+
+func NewInsightHandler(insightService *services.InsightService, budgetService *services.BudgetService) *InsightHandler {
+	return &InsightHandler{insightService: insightService, budgetService: budgetService}
+}
+
+// GetSpending godoc
+// @Summary Spending insights
+// @Description Aggregate what the logged in user paid to others by category, merchant or month
+// @Tags Insights
+// @Produce json
+// @Security BearerAuth
+// @Param group_by query string false "category (default), merchant or month"
+// @Param from query string false "YYYY-MM-DD"
+// @Param to query string false "YYYY-MM-DD, inclusive"
+// @Success 200 {object} utils.APISuccessResponse{data=models.SpendingReport}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /insights/spending [get]
+func (h *InsightHandler) GetSpending(c echo.Context) error {
+	var req validator.SpendingQuery
+	if err := utils.BindAndValidate(c, &req, validator.ValidateSpendingQuery); err != nil {
+		return err
+	}
+	from, to := req.Period(time.Now().UTC())
+	if to.Before(from) {
+		return utils.ValidationErrorResponse(c, errors.New("to must be after from"))
+	}
+
+	report, err := h.insightService.GetSpending(uint(utils.CLaimJwt(c)), req.GroupBy, from, to)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Spending fetched successfully", report)
+}
+
+// GetBudgets godoc
+// @Summary List budgets
+// @Description Monthly budgets of the logged in user with what has been spent this month
+// @Tags Insights
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APISuccessResponse{data=[]models.BudgetStatus}
+// @Router /budgets [get]
+func (h *InsightHandler) GetBudgets(c echo.Context) error {
+	budgets, err := h.budgetService.GetBudgets(uint(utils.CLaimJwt(c)))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Budgets fetched successfully", budgets)
+}
+
+// SetBudget godoc
+// @Summary Set a category budget
+// @Description Create or replace the monthly limit of one spending category
+// @Tags Insights
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param budget body validator.SetBudgetRequest true "Budget"
+// @Success 200 {object} utils.APISuccessResponse{data=models.Budget}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /budgets [put]
+func (h *InsightHandler) SetBudget(c echo.Context) error {
+	var req validator.SetBudgetRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateSetBudget); err != nil {
+		return err
+	}
+
+	budget := &models.Budget{
+		UserID:        uint(utils.CLaimJwt(c)),
+		Category:      req.Category,
+		MonthlyLimit:  req.MonthlyLimit,
+		WarnAtPercent: 80,
+	}
+	if req.WarnAtPercent != nil {
+		budget.WarnAtPercent = *req.WarnAtPercent
+	}
+
+	if err := h.budgetService.SetBudget(budget); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Budget saved successfully", budget)
+}
+
+// DeleteBudget godoc
+// @Summary Remove a category budget
+// @Tags Insights
+// @Produce json
+// @Security BearerAuth
+// @Param category path string true "Transaction category"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /budgets/{category} [delete]
+func (h *InsightHandler) DeleteBudget(c echo.Context) error {
+	category := models.TransactionCategory(c.Param("category"))
+	if err := h.budgetService.DeleteBudget(uint(utils.CLaimJwt(c)), category); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Budget deleted successfully", nil)
+}
+
+// CheckBudget godoc
+// @Summary Check a payment against the budget
+// @Description Tells the app whether paying an amount in a category would go over the budget, before paying
+// @Tags Insights
+// @Produce json
+// @Security BearerAuth
+// @Param category query string true "Transaction category"
+// @Param amount query number true "Amount about to be paid"
+// @Success 200 {object} utils.APISuccessResponse{data=models.BudgetCheck}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /budgets/check [get]
+func (h *InsightHandler) CheckBudget(c echo.Context) error {
+	var req validator.BudgetCheckQuery
+	if err := utils.BindAndValidate(c, &req, validator.ValidateBudgetCheck); err != nil {
+		return err
+	}
+	check, err := h.budgetService.CheckPayment(uint(utils.CLaimJwt(c)), req.Category, req.Amount)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Budget checked successfully", check)
+}
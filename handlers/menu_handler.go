@@ -18,6 +18,19 @@ type MenuHandler struct {
 	merchantService *services.MerchantService
 }
 
+var menuPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"category":   {Column: "category", Kind: utils.SortString, Then: "name"},
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+		"name":       {Column: "name", Kind: utils.SortString},
+		"price":      {Column: "price", Kind: utils.SortNumber},
+		"total_sold": {Column: "total_sold", Kind: utils.SortNumber},
+	},
+	DefaultSort: "category", // the menu reads by section, names A-Z within each
+	DefaultAsc:  true,
+	Filters:     map[string]string{"category": "category", "is_available": "is_available"},
+}
+
 func NewMenuHandler(menuService *services.MenuItemService, merchantService *services.MerchantService) *MenuHandler {
 	return &MenuHandler{menuService: menuService, merchantService: merchantService}
 }
@@ -27,14 +40,11 @@ func (h *MenuHandler) CreateMenu(c echo.Context) error {
 	if err := utils.BindAndValidate(c, &req, validator.ValidateCreateMenu); err != nil {
 		return err
 	}
-	user_id, user_role := utils.GetIDAndRoleFromJWT(c)
-	m, err := h.merchantService.GetMerchantByUserID(uint(user_id))
+	// only merchants reach this, the route requires merchant:manage
+	m, err := h.merchantService.GetMerchantByUserID(uint(utils.CLaimJwt(c)))
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
-	if user_role != "merchant" {
-		return utils.ValidationErrorResponse(c, errors.New("only merchant can create menu"))
-	}
 	var category models.MenuCategory
 	if req.Category != nil {
 		category = models.MenuCategory(*req.Category)
@@ -61,11 +71,15 @@ func (h *MenuHandler) GetAllMenus(c echo.Context) error {
 	if err != nil {
 		return utils.ValidationErrorResponse(c, err)
 	}
-	menus, err := h.menuService.GetAllMenusFromMerchant(uint(merchant_id))
+	page, err := utils.ParsePageParams(c, menuPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	menus, meta, err := h.menuService.GetAllMenusFromMerchant(uint(merchant_id), page)
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
-	return utils.SuccessResponse(c, http.StatusOK, "All Menus fetched successfully", menus)
+	return utils.PaginatedResponse(c, http.StatusOK, "All Menus fetched successfully", menus, meta)
 }
 
 func (h *MenuHandler) GetMenuByID(c echo.Context) error {
@@ -98,9 +112,8 @@ func (h *MenuHandler) UpdateMenuItem(c echo.Context) error {
 		return utils.SplitErrorResponse(c, err)
 	}
 
-	loggedInUserId := utils.CLaimJwt(c)
-	if merchant.UserId != uint(loggedInUserId) {
-		return utils.ValidationErrorResponse(c, errors.New("unauthorized: merchant can only update their own menu items"))
+	if err := ensureOwner(c, merchant.UserId); err != nil {
+		return utils.SplitErrorResponse(c, err)
 	}
 
 	// verify menu item belongs to merchant
@@ -160,9 +173,8 @@ func (h *MenuHandler) DeleteMenuItem(c echo.Context) error {
 		return utils.SplitErrorResponse(c, err)
 	}
 
-	loggedInUserId := utils.CLaimJwt(c)
-	if merchant.UserId != uint(loggedInUserId) {
-		return utils.ValidationErrorResponse(c, errors.New("unauthorized: merchant can only delete their own menu items"))
+	if err := ensureOwner(c, merchant.UserId); err != nil {
+		return utils.SplitErrorResponse(c, err)
 	}
 
 	// verify menu item belongs to merchant
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"gopay-clone/middleware"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MerchantApiHandler serves merchant backends, they authenticate with a signed API key
+type MerchantApiHandler struct {
+	paymentService *services.MerchantPaymentService
+}
+
+func NewMerchantApiHandler(paymentService *services.MerchantPaymentService) *MerchantApiHandler {
+	return &MerchantApiHandler{paymentService: paymentService}
+}
+
+// CreateQR godoc
+// @Summary Create a dynamic QR for an exact amount
+// @Description Needs the qr:write scope, the QR pays into the merchant owner's main balance
+// @Tags Merchant API
+// @Accept json
+// @Produce json
+// @Param X-Merchant-Key header string true "API key ID"
+// @Param X-Merchant-Timestamp header string true "Unix timestamp"
+// @Param X-Merchant-Signature header string true "HMAC-SHA256 of the request"
+// @Param qr body validator.CreateDynamicQRRequest true "Amount and reference"
+// @Success 201 {object} utils.APISuccessResponse{data=models.QrCode}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /merchant-api/qr [post]
+func (h *MerchantApiHandler) CreateQR(c echo.Context) error {
+	var req validator.CreateDynamicQRRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateCreateDynamicQR); err != nil {
+		return err
+	}
+	ttl := time.Duration(req.ExpiresInSeconds) * time.Second
+	qr, err := h.paymentService.CreateDynamicQR(middleware.MerchantKey(c).MerchantID, req.Amount, strings.TrimSpace(req.Reference), ttl)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "QR created successfully", qr)
+}
+
+// GetPayment godoc
+// @Summary Payment status of a dynamic QR
+// @Description Needs the payments:read scope
+// @Tags Merchant API
+// @Produce json
+// @Param X-Merchant-Key header string true "API key ID"
+// @Param X-Merchant-Timestamp header string true "Unix timestamp"
+// @Param X-Merchant-Signature header string true "HMAC-SHA256 of the request"
+// @Param qr_id path int true "QR ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.MerchantPayment}
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /merchant-api/qr/{qr_id} [get]
+func (h *MerchantApiHandler) GetPayment(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("qr_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	payment, err := h.paymentService.GetPayment(middleware.MerchantKey(c).MerchantID, uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Payment fetched successfully", payment)
+}
+
+// CreateRefund godoc
+// @Summary Refund a payment
+// @Description Needs the refunds:write scope, without an amount what is left of the payment is refunded
+// @Tags Merchant API
+// @Accept json
+// @Produce json
+// @Param X-Merchant-Key header string true "API key ID"
+// @Param X-Merchant-Timestamp header string true "Unix timestamp"
+// @Param X-Merchant-Signature header string true "HMAC-SHA256 of the request"
+// @Param refund body validator.RefundRequest true "Payment and amount"
+// @Success 201 {object} utils.APISuccessResponse{data=models.MerchantRefund}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /merchant-api/refunds [post]
+func (h *MerchantApiHandler) CreateRefund(c echo.Context) error {
+	var req validator.RefundRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateRefund); err != nil {
+		return err
+	}
+	refund, err := h.paymentService.Refund(middleware.MerchantKey(c).MerchantID, req.TransactionID, req.Amount, strings.TrimSpace(req.Reason), strings.TrimSpace(req.Reference))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Refund sent successfully", refund)
+}
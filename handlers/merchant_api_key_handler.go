@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type MerchantApiKeyHandler struct {
+	keyService      *services.MerchantApiKeyService
+	merchantService *services.MerchantService
+}
+
+func NewMerchantApiKeyHandler(keyService *services.MerchantApiKeyService, merchantService *services.MerchantService) *MerchantApiKeyHandler {
+	return &MerchantApiKeyHandler{keyService: keyService, merchantService: merchantService}
+}
+
+// GetKeys godoc
+// @Summary List the merchant API keys
+// @Tags Merchant API Keys
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Success 200 {object} utils.APISuccessResponse{data=[]models.MerchantApiKey}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /merchants/{merchant_id}/api-keys [get]
+func (h *MerchantApiKeyHandler) GetKeys(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	keys, err := h.keyService.GetKeys(merchantID)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "API keys fetched successfully", keys)
+}
+
+// CreateKey godoc
+// @Summary Create a merchant API key
+// @Description The secret is only returned here, requests are signed with its SHA-256
+// @Tags Merchant API Keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param key body validator.CreateApiKeyRequest true "Name and scopes"
+// @Success 201 {object} utils.APISuccessResponse{data=models.MerchantApiCredentials}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /merchants/{merchant_id}/api-keys [post]
+func (h *MerchantApiKeyHandler) CreateKey(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	var req validator.CreateApiKeyRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateCreateApiKey); err != nil {
+		return err
+	}
+	credentials, err := h.keyService.CreateKey(merchantID, strings.TrimSpace(req.Name), req.Scopes)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "API key created, store the secret now", credentials)
+}
+
+// RotateKey godoc
+// @Summary Rotate the secret of a merchant API key
+// @Description The previous secret keeps working for the rotation grace period
+// @Tags Merchant API Keys
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param key_id path int true "API key ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.MerchantApiCredentials}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /merchants/{merchant_id}/api-keys/{key_id}/rotate [post]
+func (h *MerchantApiKeyHandler) RotateKey(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	id, err := strconv.Atoi(c.Param("key_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	credentials, err := h.keyService.RotateKey(merchantID, uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "API key rotated, store the new secret now", credentials)
+}
+
+// RevokeKey godoc
+// @Summary Revoke a merchant API key
+// @Tags Merchant API Keys
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param key_id path int true "API key ID"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /merchants/{merchant_id}/api-keys/{key_id} [delete]
+func (h *MerchantApiKeyHandler) RevokeKey(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	id, err := strconv.Atoi(c.Param("key_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	if err := h.keyService.RevokeKey(merchantID, uint(id)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "API key revoked successfully", nil)
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"errors"
 	"gopay-clone/models"
 	"gopay-clone/services"
 	"gopay-clone/utils"
@@ -17,6 +16,15 @@ type MerchantHandler struct {
 	merchantService *services.MerchantService
 }
 
+var merchantPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at":    {Column: "created_at", Kind: utils.SortTime},
+		"merchant_name": {Column: "merchant_name", Kind: utils.SortString},
+		"rating":        {Column: "rating", Kind: utils.SortNumber},
+	},
+	Filters: map[string]string{"category": "category", "location": "location"},
+}
+
 func NewMerchantHandler(userService *services.UserService, merchantService *services.MerchantService) *MerchantHandler {
 	return &MerchantHandler{userService: userService, merchantService: merchantService}
 }
@@ -68,11 +76,15 @@ func (h *MerchantHandler) CreateMerchant(c echo.Context) error {
 }
 
 func (h *MerchantHandler) GetAllMerchants(c echo.Context) error {
-	merchants, err := h.merchantService.GetAllMerchants()
+	page, err := utils.ParsePageParams(c, merchantPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	merchants, meta, err := h.merchantService.GetAllMerchants(page)
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
-	return utils.SuccessResponse(c, http.StatusOK, "Merchants fetched successfully", merchants)
+	return utils.PaginatedResponse(c, http.StatusOK, "Merchants fetched successfully", merchants, meta)
 }
 
 func (h *MerchantHandler) GetMerchantByID(c echo.Context) error {
@@ -97,10 +109,8 @@ func (h *MerchantHandler) UpdateMerchantByID(c echo.Context) error {
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
-	loggedInUserId := utils.CLaimJwt(c)
-
-	if merchant.UserId != uint(loggedInUserId) {
-		return utils.ValidationErrorResponse(c, errors.New("unauthorized: merchant can only update their own profile"))
+	if err := ensureOwner(c, merchant.UserId); err != nil {
+		return utils.SplitErrorResponse(c, err)
 	}
 	var req validator.UpdateMerchantRequest
 	if err := utils.BindAndValidate(c, &req, validator.ValidateUpdateMerchant); err != nil {
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"fmt"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+var qrPaymentPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+		"amount":     {Column: "amount", Kind: utils.SortNumber},
+	},
+	Filters: map[string]string{"type": "type", "status": "status"},
+}
+
+type MerchantQRHandler struct {
+	qrService       *services.QRService
+	merchantService *services.MerchantService
+}
+
+func NewMerchantQRHandler(qrService *services.QRService, merchantService *services.MerchantService) *MerchantQRHandler {
+	return &MerchantQRHandler{qrService: qrService, merchantService: merchantService}
+}
+
+// CreateStaticQR godoc
+// @Summary Create a static QR for the counter
+// @Description A reusable QR without an amount, the payer enters it when paying and every payment is a transaction of its own
+// @Tags Merchant QR
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param qr body validator.CreateStaticQRRequest false "Label"
+// @Success 201 {object} utils.APISuccessResponse{data=models.QrCodeSummary}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /merchants/{merchant_id}/qr [post]
+func (h *MerchantQRHandler) CreateStaticQR(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	var req validator.CreateStaticQRRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateCreateStaticQR); err != nil {
+		return err
+	}
+	qr, err := h.qrService.CreateStaticQR(merchantID, req.Label)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Static QR created successfully", qr)
+}
+
+// GetStaticQRs godoc
+// @Summary List the static QRs of a merchant with their totals
+// @Tags Merchant QR
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Success 200 {object} utils.APISuccessResponse{data=[]models.QrCodeSummary}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /merchants/{merchant_id}/qr [get]
+func (h *MerchantQRHandler) GetStaticQRs(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	qrs, err := h.qrService.GetStaticQRs(merchantID)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Static QRs fetched successfully", qrs)
+}
+
+// GetQR godoc
+// @Summary A QR of the merchant with its payment totals
+// @Tags Merchant QR
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param qr_id path int true "QR ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.QrCodeSummary}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /merchants/{merchant_id}/qr/{qr_id} [get]
+func (h *MerchantQRHandler) GetQR(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	id, err := strconv.Atoi(c.Param("qr_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	qr, err := h.qrService.GetMerchantQR(merchantID, uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "QR fetched successfully", qr)
+}
+
+// GetQRPayments godoc
+// @Summary Payment history of a QR of the merchant
+// @Description Every payment made with the QR and the refunds of those payments
+// @Tags Merchant QR
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param qr_id path int true "QR ID"
+// @Param type query string false "payment or refund"
+// @Param status query string false "Transaction status"
+// @Success 200 {object} utils.APISuccessResponse{data=[]models.Transaction}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /merchants/{merchant_id}/qr/{qr_id}/payments [get]
+func (h *MerchantQRHandler) GetQRPayments(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	id, err := strconv.Atoi(c.Param("qr_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	page, err := utils.ParsePageParams(c, qrPaymentPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	payments, meta, err := h.qrService.GetQRPayments(merchantID, uint(id), page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "QR payments fetched successfully", payments, meta)
+}
+
+// DisableStaticQR godoc
+// @Summary Disable a static QR
+// @Description A disabled QR can't be paid anymore, its payment history stays
+// @Tags Merchant QR
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param qr_id path int true "QR ID"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /merchants/{merchant_id}/qr/{qr_id} [delete]
+func (h *MerchantQRHandler) DisableStaticQR(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	id, err := strconv.Atoi(c.Param("qr_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	if err := h.qrService.DisableStaticQR(merchantID, uint(id)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Static QR disabled successfully", nil)
+}
+
+// GetStandee godoc
+// @Summary Download a printable standee of a static QR
+// @Description A5 PDF with the merchant name, location and the QR with the merchant logo
+// @Tags Merchant QR
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param qr_id path int true "QR ID"
+// @Success 200 {file} file
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /merchants/{merchant_id}/qr/{qr_id}/standee [get]
+func (h *MerchantQRHandler) GetStandee(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	id, err := strconv.Atoi(c.Param("qr_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	body, err := h.qrService.Standee(merchantID, uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="qr-standee-%d.pdf"`, id))
+	return c.Blob(http.StatusOK, "application/pdf", body)
+}
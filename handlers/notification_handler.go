@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+var notificationPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+	},
+	Filters: map[string]string{"type": "type"},
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// GetNotifications godoc
+// @Summary List notifications
+// @Description Notifications of the logged in user, newest first
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "Only unread notifications"
+// @Param type query string false "Notification type"
+// @Success 200 {object} utils.APISuccessResponse{data=[]models.Notification}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /notifications [get]
+func (h *NotificationHandler) GetNotifications(c echo.Context) error {
+	page, err := utils.ParsePageParams(c, notificationPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	unreadOnly := c.QueryParam("unread") == "true"
+	notifications, meta, err := h.notificationService.GetNotifications(uint(utils.CLaimJwt(c)), unreadOnly, page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Notifications fetched successfully", notifications, meta)
+}
+
+// GetUnreadCount godoc
+// @Summary Number of unread notifications
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APISuccessResponse{data=models.UnreadCount}
+// @Router /notifications/unread-count [get]
+func (h *NotificationHandler) GetUnreadCount(c echo.Context) error {
+	count, err := h.notificationService.UnreadCount(uint(utils.CLaimJwt(c)))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Unread count fetched successfully", count)
+}
+
+// MarkRead godoc
+// @Summary Mark a notification read
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param notification_id path int true "Notification ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.Notification}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /notifications/{notification_id}/read [post]
+func (h *NotificationHandler) MarkRead(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("notification_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	notification, err := h.notificationService.MarkRead(uint(utils.CLaimJwt(c)), uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Notification marked read", notification)
+}
+
+// MarkAllRead godoc
+// @Summary Mark every notification read
+// @Description Returns how many notifications were unread
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APISuccessResponse{data=models.UnreadCount}
+// @Router /notifications/read-all [post]
+func (h *NotificationHandler) MarkAllRead(c echo.Context) error {
+	count, err := h.notificationService.MarkAllRead(uint(utils.CLaimJwt(c)))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Notifications marked read", count)
+}
+
+// GetDevices godoc
+// @Summary List devices registered for push
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APISuccessResponse{data=[]models.DeviceToken}
+// @Router /notifications/devices [get]
+func (h *NotificationHandler) GetDevices(c echo.Context) error {
+	devices, err := h.notificationService.GetDevices(uint(utils.CLaimJwt(c)))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Devices fetched successfully", devices)
+}
+
+// RegisterDevice godoc
+// @Summary Register a device token for push notifications
+// @Description Registering a token again refreshes it, a token used by another account moves over
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param device body validator.RegisterDeviceRequest true "Push token and platform"
+// @Success 200 {object} utils.APISuccessResponse{data=models.DeviceToken}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /notifications/devices [post]
+func (h *NotificationHandler) RegisterDevice(c echo.Context) error {
+	var req validator.RegisterDeviceRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateRegisterDevice); err != nil {
+		return err
+	}
+	device, err := h.notificationService.RegisterDevice(uint(utils.CLaimJwt(c)), req.Token, req.Platform)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Device registered successfully", device)
+}
+
+// RemoveDevice godoc
+// @Summary Stop push notifications to a device
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param device_id path int true "Device ID"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /notifications/devices/{device_id} [delete]
+func (h *NotificationHandler) RemoveDevice(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("device_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	if err := h.notificationService.RemoveDevice(uint(utils.CLaimJwt(c)), uint(id)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Device removed successfully", nil)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"gopay-clone/middleware"
 	"gopay-clone/models"
 	"gopay-clone/services"
 	"gopay-clone/utils"
@@ -16,13 +17,15 @@ import (
 )
 
 type OrderHandler struct {
-	orderService       *services.OrderService
-	merchantService    *services.MerchantService
-	userService        *services.UserService
-	menuService        *services.MenuItemService
-	accountService     *services.AccountService
-	transactionService *services.TransactionService
-	driverService      *services.DriverService
+	orderService    *services.OrderService
+	merchantService *services.MerchantService
+	userService     *services.UserService
+	menuService     *services.MenuItemService
+	accountService  *services.AccountService
+	driverService   *services.DriverService
+	budgetService   *services.BudgetService
+	pinService      *services.PinService
+	emailService    *services.EmailService
 }
 
 func NewOrderHandler(
@@ -30,17 +33,21 @@ func NewOrderHandler(
 	merchantService *services.MerchantService, userService *services.UserService,
 	menuService *services.MenuItemService,
 	accountService *services.AccountService,
-	transactionService *services.TransactionService,
 	driverService *services.DriverService,
+	budgetService *services.BudgetService,
+	pinService *services.PinService,
+	emailService *services.EmailService,
 ) *OrderHandler {
 	return &OrderHandler{
-		orderService:       orderService,
-		merchantService:    merchantService,
-		userService:        userService,
-		menuService:        menuService,
-		accountService:     accountService,
-		transactionService: transactionService,
-		driverService:      driverService,
+		orderService:    orderService,
+		merchantService: merchantService,
+		userService:     userService,
+		menuService:     menuService,
+		accountService:  accountService,
+		driverService:   driverService,
+		budgetService:   budgetService,
+		pinService:      pinService,
+		emailService:    emailService,
 	}
 }
 
@@ -93,6 +100,9 @@ func (h *OrderHandler) CreateOrder(c echo.Context) error {
 	if userAccount.Balance < totalAmount {
 		return utils.ValidationErrorResponse(c, errors.New("insufficient balance"))
 	}
+	if err := authorizePayment(c, h.pinService, h.emailService, totalAmount); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
 
 	// create the order
 	order := &models.Order{
@@ -113,14 +123,6 @@ func (h *OrderHandler) CreateOrder(c echo.Context) error {
 	fmt.Print(selectedDriver.UserId)
 	order.DriverID = &selectedDriver.ID
 
-	if err := h.driverService.UpdateDriverStatus(selectedDriver.ID, "sending"); err != nil {
-		return utils.SplitErrorResponse(c, err)
-	}
-
-	if err := h.orderService.CreateOrder(order, orderItems); err != nil {
-		return utils.SplitErrorResponse(c, err)
-	}
-
 	transaction := &models.Transaction{
 		Amount:            totalAmount,
 		SenderAccountID:   userAccount.ID,
@@ -129,13 +131,15 @@ func (h *OrderHandler) CreateOrder(c echo.Context) error {
 		Type:              "payment",
 		Status:            "pending",
 		ServiceType:       "food",
-		ServiceID:         &order.ID,
 	}
 
-	if err := h.transactionService.CreateTransaction(transaction); err != nil {
-		h.orderService.DeleteOrder(order.ID)
+	// the driver is sent and the payment taken together with the order
+	if err := h.orderService.CreateOrder(order, orderItems, transaction); err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
+	if err := h.budgetService.NotifyAfterPayment(transaction); err != nil {
+		c.Logger().Warn(err)
+	}
 	createdOrder, err := h.orderService.GetOrderByID(uint(order.ID))
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
@@ -164,18 +168,11 @@ func (h *OrderHandler) UpdateOrderStatus(c echo.Context) error {
 		return utils.SplitErrorResponse(c, err)
 	}
 
-	// do the actual update
+	// do the actual update, the payment is settled by the order.status_changed subscriber
 	if err := h.orderService.UpdateOrderStatus(uint(orderId), string(req.Status)); err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
 
-	// update the transaction as well if the status is completed
-	if req.Status == models.OrderCompleted {
-		if err := h.transactionService.UpdateTransactionWhenFoodOrderCompleted(order.ID); err != nil {
-			return utils.SplitErrorResponse(c, err)
-		}
-	}
-
 	return utils.SuccessResponse(c, http.StatusOK, "Order status updated successfully", nil)
 }
 
@@ -189,10 +186,27 @@ func (h *OrderHandler) GetOrderByID(c echo.Context) error {
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
+	if err := ensureOwnerOr(c, middleware.PermReadAny, h.orderParties(order)...); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
 
 	return utils.SuccessResponse(c, http.StatusOK, "Order detail fetched successfully", order)
 }
 
+// orderParties are the users who may see an order: the customer, the merchant and the driver
+func (h *OrderHandler) orderParties(order *models.Order) []uint {
+	parties := []uint{order.UserID}
+	if merchant, err := h.merchantService.GetMerchantByID(order.MerchantID); err == nil {
+		parties = append(parties, merchant.UserId)
+	}
+	if order.DriverID != nil {
+		if driver, err := h.driverService.GetDriverByID(*order.DriverID); err == nil {
+			parties = append(parties, driver.UserId)
+		}
+	}
+	return parties
+}
+
 func (h *OrderHandler) validateStatusUpdate(order *models.Order, userID uint, newStatus string) error {
 	currentStatus := string(order.Status)
 
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"gopay-clone/models"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type OtpHandler struct {
+	otpService *services.OtpService
+}
+
+func NewOtpHandler(otpService *services.OtpService) *OtpHandler {
+	return &OtpHandler{otpService: otpService}
+}
+
+// RequestOtp godoc
+// @Summary Send a code to a phone before signing up or logging in
+// @Description Purpose register gives the otp_code for registration, login the one for POST /public/users/login/phone
+// @Tags OTP
+// @Accept json
+// @Produce json
+// @Param otp body validator.RequestOtpRequest true "Phone and purpose"
+// @Success 201 {object} utils.APISuccessResponse{data=models.OtpChallenge}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 429 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /public/otp [post]
+func (h *OtpHandler) RequestOtp(c echo.Context) error {
+	var req validator.RequestOtpRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateRequestOtp); err != nil {
+		return err
+	}
+	challenge, err := h.otpService.Send(c.Request().Context(), req.Phone, req.Purpose)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Code sent", challenge)
+}
+
+// SendPhoneVerification godoc
+// @Summary Send a code to verify the phone of the logged in user
+// @Tags OTP
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} utils.APISuccessResponse{data=models.OtpChallenge}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 429 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /phone/otp [post]
+func (h *OtpHandler) SendPhoneVerification(c echo.Context) error {
+	challenge, err := h.otpService.SendToUser(c.Request().Context(), uint(utils.CLaimJwt(c)), models.OtpVerifyPhone)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Code sent", challenge)
+}
+
+// VerifyPhone godoc
+// @Summary Verify the phone of the logged in user
+// @Tags OTP
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param otp body validator.VerifyPhoneRequest true "Code from POST /phone/otp"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /phone/verify [post]
+func (h *OtpHandler) VerifyPhone(c echo.Context) error {
+	var req validator.VerifyPhoneRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateVerifyPhone); err != nil {
+		return err
+	}
+	if err := h.otpService.VerifyPhone(uint(utils.CLaimJwt(c)), req.OtpCode); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Phone verified successfully", nil)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	apperrors "gopay-clone/errors"
+	"gopay-clone/middleware"
+	"gopay-clone/models"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ensureOwner is the shared check for anything that stores the id of the user it belongs to,
+// with several ids any of them may be the caller (both sides of a transaction)
+func ensureOwner(c echo.Context, ownerIDs ...uint) error {
+	userID := uint(utils.CLaimJwt(c))
+	for _, ownerID := range ownerIDs {
+		if ownerID == userID {
+			return nil
+		}
+	}
+	return apperrors.ErrForbidden
+}
+
+// ensureOwnerOr also lets roles with the permission through, e.g. admins reading someone else's data
+func ensureOwnerOr(c echo.Context, permission middleware.Permission, ownerIDs ...uint) error {
+	_, role := utils.GetIDAndRoleFromJWT(c)
+	if middleware.HasPermission(models.UserType(role), permission) {
+		return nil
+	}
+	return ensureOwner(c, ownerIDs...)
+}
+
+// ensureAccountOwner is for account ids coming from the request, money may only leave the caller's own accounts
+func ensureAccountOwner(c echo.Context, accountService *services.AccountService, accountID uint) error {
+	account, err := accountService.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+	return ensureOwner(c, account.UserId)
+}
+
+// ensureMerchantOwner is for routes under /merchants/:merchant_id that only the merchant's owner may use,
+// it returns the merchant id
+func ensureMerchantOwner(c echo.Context, merchantService *services.MerchantService) (uint, error) {
+	id, err := strconv.Atoi(c.Param("merchant_id"))
+	if err != nil {
+		return 0, apperrors.ErrInvalidInput
+	}
+	merchant, err := merchantService.GetMerchantByID(uint(id))
+	if err != nil {
+		return 0, err
+	}
+	if err := ensureOwner(c, merchant.UserId); err != nil {
+		return 0, err
+	}
+	return merchant.ID, nil
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"gopay-clone/models"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+var paymentRequestPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+		"amount":     {Column: "amount", Kind: utils.SortNumber},
+	},
+	Filters: map[string]string{"status": "status", "split_bill_id": "split_bill_id"},
+}
+
+var splitBillPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at":   {Column: "created_at", Kind: utils.SortTime},
+		"total_amount": {Column: "total_amount", Kind: utils.SortNumber},
+	},
+	Filters: map[string]string{"status": "status"},
+}
+
+type PaymentRequestHandler struct {
+	paymentRequestService *services.PaymentRequestService
+	budgetService         *services.BudgetService
+	pinService            *services.PinService
+	emailService          *services.EmailService
+}
+
+func NewPaymentRequestHandler(paymentRequestService *services.PaymentRequestService, budgetService *services.BudgetService, pinService *services.PinService, emailService *services.EmailService) *PaymentRequestHandler {
+	return &PaymentRequestHandler{paymentRequestService: paymentRequestService, budgetService: budgetService, pinService: pinService, emailService: emailService}
+}
+
+// CreatePaymentRequest godoc
+// @Summary Request money from contacts
+// @Description Ask one or more contacts for the same amount, each contact gets its own request
+// @Tags PaymentRequest
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body validator.CreatePaymentRequestRequest true "Who to ask and how much"
+// @Success 201 {object} utils.APISuccessResponse{data=[]models.PaymentRequest}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /payment-requests [post]
+func (h *PaymentRequestHandler) CreatePaymentRequest(c echo.Context) error {
+	var req validator.CreatePaymentRequestRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateCreatePaymentRequest); err != nil {
+		return err
+	}
+	requests, err := h.paymentRequestService.CreateRequests(uint(utils.CLaimJwt(c)), req.ContactIDs, req.Amount, strings.TrimSpace(req.Note))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Payment requests sent successfully", requests)
+}
+
+// GetIncomingRequests godoc
+// @Summary Requests to pay
+// @Description Payment requests other users sent to the logged in user
+// @Tags PaymentRequest
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, paid, declined or cancelled, comma separated"
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.PaymentRequest}
+// @Router /payment-requests/incoming [get]
+func (h *PaymentRequestHandler) GetIncomingRequests(c echo.Context) error {
+	page, err := utils.ParsePageParams(c, paymentRequestPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	requests, meta, err := h.paymentRequestService.GetIncoming(uint(utils.CLaimJwt(c)), page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Payment requests fetched successfully", requests, meta)
+}
+
+// GetOutgoingRequests godoc
+// @Summary Sent requests
+// @Description Payment requests the logged in user sent, including split bill shares
+// @Tags PaymentRequest
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, paid, declined or cancelled, comma separated"
+// @Param split_bill_id query int false "Only requests of one split bill"
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.PaymentRequest}
+// @Router /payment-requests/outgoing [get]
+func (h *PaymentRequestHandler) GetOutgoingRequests(c echo.Context) error {
+	page, err := utils.ParsePageParams(c, paymentRequestPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	requests, meta, err := h.paymentRequestService.GetOutgoing(uint(utils.CLaimJwt(c)), page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Payment requests fetched successfully", requests, meta)
+}
+
+// GetPaymentRequest godoc
+// @Summary Get a payment request
+// @Tags PaymentRequest
+// @Produce json
+// @Security BearerAuth
+// @Param request_id path int true "Payment request ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.PaymentRequest}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /payment-requests/{request_id} [get]
+func (h *PaymentRequestHandler) GetPaymentRequest(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("request_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	request, err := h.paymentRequestService.GetRequest(uint(utils.CLaimJwt(c)), uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Payment request fetched successfully", request)
+}
+
+// PayRequest godoc
+// @Summary Pay a payment request
+// @Description Transfers the requested amount to the requester's main balance
+// @Tags PaymentRequest
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request_id path int true "Payment request ID"
+// @Param pay body validator.PayRequestRequest false "Account to pay from"
+// @Success 201 {object} utils.APISuccessResponse{data=models.Transaction}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Param X-Transaction-PIN header string false "Transaction PIN, required above the configured amount"
+// @Router /payment-requests/{request_id}/pay [post]
+func (h *PaymentRequestHandler) PayRequest(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("request_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	var req validator.PayRequestRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidatePayRequest); err != nil {
+		return err
+	}
+	request, err := h.paymentRequestService.GetRequest(uint(utils.CLaimJwt(c)), uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	if err := authorizePayment(c, h.pinService, h.emailService, request.Amount); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
+	transaction, err := h.paymentRequestService.PayRequest(uint(utils.CLaimJwt(c)), uint(id), req.SenderAccountID)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	if err := h.budgetService.NotifyAfterPayment(transaction); err != nil {
+		c.Logger().Warn(err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Payment request paid successfully", transaction)
+}
+
+// DeclineRequest godoc
+// @Summary Decline a payment request
+// @Tags PaymentRequest
+// @Produce json
+// @Security BearerAuth
+// @Param request_id path int true "Payment request ID"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /payment-requests/{request_id}/decline [post]
+func (h *PaymentRequestHandler) DeclineRequest(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("request_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	if err := h.paymentRequestService.DeclineRequest(uint(utils.CLaimJwt(c)), uint(id)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Payment request declined", nil)
+}
+
+// CancelRequest godoc
+// @Summary Cancel a sent payment request
+// @Tags PaymentRequest
+// @Produce json
+// @Security BearerAuth
+// @Param request_id path int true "Payment request ID"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /payment-requests/{request_id}/cancel [post]
+func (h *PaymentRequestHandler) CancelRequest(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("request_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	if err := h.paymentRequestService.CancelRequest(uint(utils.CLaimJwt(c)), uint(id)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Payment request cancelled", nil)
+}
+
+// CreateSplitBill godoc
+// @Summary Split a bill
+// @Description Divide a total, or one of your completed orders, evenly or by custom shares and request each share from a contact
+// @Tags SplitBill
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param bill body validator.CreateSplitBillRequest true "Bill and participants"
+// @Success 201 {object} utils.APISuccessResponse{data=models.SplitBill}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /split-bills [post]
+func (h *PaymentRequestHandler) CreateSplitBill(c echo.Context) error {
+	var req validator.CreateSplitBillRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateCreateSplitBill); err != nil {
+		return err
+	}
+
+	bill := &models.SplitBill{
+		OwnerID:     uint(utils.CLaimJwt(c)),
+		Title:       strings.TrimSpace(req.Title),
+		TotalAmount: req.TotalAmount,
+		Mode:        models.SplitMode(req.Mode),
+		OrderID:     req.OrderID,
+	}
+	shares := make([]models.SplitShare, 0, len(req.Participants))
+	for _, p := range req.Participants {
+		shares = append(shares, models.SplitShare{ContactID: p.ContactID, Amount: p.Amount})
+	}
+	if err := h.paymentRequestService.CreateSplitBill(bill, shares, req.OwnerPays()); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Split bill created successfully", bill)
+}
+
+// GetSplitBills godoc
+// @Summary List split bills
+// @Description Split bills the logged in user created, with who has paid
+// @Tags SplitBill
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "open, settled or cancelled"
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.SplitBill}
+// @Router /split-bills [get]
+func (h *PaymentRequestHandler) GetSplitBills(c echo.Context) error {
+	page, err := utils.ParsePageParams(c, splitBillPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	bills, meta, err := h.paymentRequestService.GetSplitBills(uint(utils.CLaimJwt(c)), page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Split bills fetched successfully", bills, meta)
+}
+
+// GetSplitBill godoc
+// @Summary Get a split bill
+// @Description Visible to the owner and to every participant
+// @Tags SplitBill
+// @Produce json
+// @Security BearerAuth
+// @Param split_bill_id path int true "Split bill ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.SplitBill}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /split-bills/{split_bill_id} [get]
+func (h *PaymentRequestHandler) GetSplitBill(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("split_bill_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	bill, err := h.paymentRequestService.GetSplitBill(uint(utils.CLaimJwt(c)), uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Split bill fetched successfully", bill)
+}
+
+// CancelSplitBill godoc
+// @Summary Cancel a split bill
+// @Description Cancels the shares nobody paid yet, paid shares are kept
+// @Tags SplitBill
+// @Produce json
+// @Security BearerAuth
+// @Param split_bill_id path int true "Split bill ID"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /split-bills/{split_bill_id}/cancel [post]
+func (h *PaymentRequestHandler) CancelSplitBill(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("split_bill_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	if err := h.paymentRequestService.CancelSplitBill(uint(utils.CLaimJwt(c)), uint(id)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Split bill cancelled", nil)
+}
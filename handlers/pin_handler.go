@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"gopay-clone/models"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PinHeader carries the transaction PIN on every endpoint that moves money
+const PinHeader = "X-Transaction-PIN"
+
+type PinHandler struct {
+	pinService *services.PinService
+	otpService *services.OtpService
+}
+
+func NewPinHandler(pinService *services.PinService, otpService *services.OtpService) *PinHandler {
+	return &PinHandler{pinService: pinService, otpService: otpService}
+}
+
+// GetPinStatus godoc
+// @Summary Transaction PIN status
+// @Description Whether a PIN is set, from which amount it is asked and whether it is locked
+// @Tags PIN
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APISuccessResponse{data=models.PinStatus}
+// @Router /pin [get]
+func (h *PinHandler) GetPinStatus(c echo.Context) error {
+	status, err := h.pinService.GetStatus(uint(utils.CLaimJwt(c)))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "PIN status fetched successfully", status)
+}
+
+// SetPin godoc
+// @Summary Set the transaction PIN
+// @Description First time only, confirmed with the account password
+// @Tags PIN
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param pin body validator.SetPinRequest true "Password and new PIN"
+// @Success 201 {object} utils.APISuccessResponse
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /pin [post]
+func (h *PinHandler) SetPin(c echo.Context) error {
+	var req validator.SetPinRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateSetPin); err != nil {
+		return err
+	}
+	if err := h.pinService.SetPin(uint(utils.CLaimJwt(c)), req.Password, req.Pin); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "PIN set successfully", nil)
+}
+
+// ChangePin godoc
+// @Summary Change the transaction PIN
+// @Description Wrong current PINs count towards the lockout. Users with a verified phone also send a code from POST /pin/otp
+// @Tags PIN
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param pin body validator.ChangePinRequest true "Current and new PIN"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Failure 423 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /pin [put]
+func (h *PinHandler) ChangePin(c echo.Context) error {
+	var req validator.ChangePinRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateChangePin); err != nil {
+		return err
+	}
+	userID := uint(utils.CLaimJwt(c))
+	if err := h.otpService.StepUp(userID, models.OtpPin, req.OtpCode); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	if err := h.pinService.ChangePin(userID, req.CurrentPin, req.NewPin); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "PIN changed successfully", nil)
+}
+
+// SendPinOtp godoc
+// @Summary Send a code for changing or resetting the PIN
+// @Description Only works once the phone is verified
+// @Tags PIN
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} utils.APISuccessResponse{data=models.OtpChallenge}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 429 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /pin/otp [post]
+func (h *PinHandler) SendPinOtp(c echo.Context) error {
+	challenge, err := h.otpService.SendToUser(c.Request().Context(), uint(utils.CLaimJwt(c)), models.OtpPin)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Code sent", challenge)
+}
+
+// ResetPin godoc
+// @Summary Reset a forgotten transaction PIN
+// @Description The code from POST /pin/otp replaces the current PIN, this also lifts a lockout
+// @Tags PIN
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param pin body validator.ResetPinRequest true "Code and new PIN"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /pin/reset [post]
+func (h *PinHandler) ResetPin(c echo.Context) error {
+	var req validator.ResetPinRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateResetPin); err != nil {
+		return err
+	}
+	userID := uint(utils.CLaimJwt(c))
+	if err := h.otpService.VerifyUser(userID, models.OtpPin, req.OtpCode); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	if err := h.pinService.ResetPin(userID, req.NewPin); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "PIN reset successfully", nil)
+}
+
+// authorizePayment checks that the logged in user may pay at all and the PIN header for a payment of amount
+func authorizePayment(c echo.Context, pinService *services.PinService, emailService *services.EmailService, amount float64) error {
+	userID := uint(utils.CLaimJwt(c))
+	if err := emailService.RequireVerified(userID); err != nil {
+		return err
+	}
+	return pinService.Authorize(userID, amount, c.Request().Header.Get(PinHeader))
+}
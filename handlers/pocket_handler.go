@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"gopay-clone/models"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PocketHandler struct {
+	pocketService  *services.PocketService
+	accountService *services.AccountService
+	pinService     *services.PinService
+	emailService   *services.EmailService
+}
+
+func NewPocketHandler(pocketService *services.PocketService, accountService *services.AccountService, pinService *services.PinService, emailService *services.EmailService) *PocketHandler {
+	return &PocketHandler{pocketService: pocketService, accountService: accountService, pinService: pinService, emailService: emailService}
+}
+
+// CreatePocket godoc
+// @Summary Create a savings pocket
+// @Description A pocket is an extra account with an optional target amount and date. Locked pockets only pay out to your own accounts, and not before locked_until.
+// @Tags Pocket
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param pocket body validator.CreatePocketRequest true "Pocket"
+// @Success 201 {object} utils.APISuccessResponse{data=models.PocketStatus}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /pockets [post]
+func (h *PocketHandler) CreatePocket(c echo.Context) error {
+	var req validator.CreatePocketRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateCreatePocket); err != nil {
+		return err
+	}
+	userID := uint(utils.CLaimJwt(c))
+
+	pocket := &models.Account{
+		Name:         strings.TrimSpace(req.Name),
+		UserId:       userID,
+		TargetAmount: req.TargetAmount,
+		TargetDate:   req.TargetDate,
+		IsLocked:     req.IsLocked || req.LockedUntil != nil,
+		LockedUntil:  req.LockedUntil,
+	}
+	if err := h.pocketService.CreatePocket(pocket); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	status, err := h.pocketService.GetPocket(userID, pocket.ID)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Pocket created successfully", status)
+}
+
+// GetPockets godoc
+// @Summary List savings pockets
+// @Description Pockets of the logged in user with progress towards their goals
+// @Tags Pocket
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APISuccessResponse{data=[]models.PocketStatus}
+// @Router /pockets [get]
+func (h *PocketHandler) GetPockets(c echo.Context) error {
+	pockets, err := h.pocketService.GetPockets(uint(utils.CLaimJwt(c)))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Pockets fetched successfully", pockets)
+}
+
+// GetPocket godoc
+// @Summary Get a savings pocket
+// @Tags Pocket
+// @Produce json
+// @Security BearerAuth
+// @Param pocket_id path int true "Pocket account ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.PocketStatus}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /pockets/{pocket_id} [get]
+func (h *PocketHandler) GetPocket(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("pocket_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	pocket, err := h.pocketService.GetPocket(uint(utils.CLaimJwt(c)), uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Pocket fetched successfully", pocket)
+}
+
+// UpdatePocket godoc
+// @Summary Update a savings pocket
+// @Description Rename, change the goal or lock. A running lock can be extended but not lifted.
+// @Tags Pocket
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param pocket_id path int true "Pocket account ID"
+// @Param pocket body validator.UpdatePocketRequest true "Changes"
+// @Success 200 {object} utils.APISuccessResponse{data=models.PocketStatus}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /pockets/{pocket_id} [put]
+func (h *PocketHandler) UpdatePocket(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("pocket_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	var req validator.UpdatePocketRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateUpdatePocket); err != nil {
+		return err
+	}
+
+	updates := map[string]any{}
+	if req.Name != nil {
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.TargetAmount != nil {
+		updates["target_amount"] = *req.TargetAmount
+	}
+	if req.TargetDate != nil {
+		updates["target_date"] = req.TargetDate
+	}
+	if req.IsLocked != nil {
+		updates["is_locked"] = *req.IsLocked
+	}
+	if req.LockedUntil != nil {
+		updates["locked_until"] = req.LockedUntil
+		updates["is_locked"] = true
+	}
+
+	pocket, err := h.pocketService.UpdatePocket(uint(utils.CLaimJwt(c)), uint(id), updates)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Pocket updated successfully", pocket)
+}
+
+// InternalTransfer godoc
+// @Summary Move money between your own accounts
+// @Description Top up or withdraw from a pocket. Internal moves are not spending and skip budgets and round-ups.
+// @Tags Pocket
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param transfer body validator.InternalTransferRequest true "Accounts and amount"
+// @Success 201 {object} utils.APISuccessResponse{data=models.Transaction}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Param X-Transaction-PIN header string false "Transaction PIN, required above the configured amount"
+// @Router /pockets/transfer [post]
+func (h *PocketHandler) InternalTransfer(c echo.Context) error {
+	var req validator.InternalTransferRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateInternalTransfer); err != nil {
+		return err
+	}
+	if err := authorizePayment(c, h.pinService, h.emailService, req.Amount); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	transaction, err := h.pocketService.InternalTransfer(uint(utils.CLaimJwt(c)), req.FromAccountID, req.ToAccountID, req.Amount, strings.TrimSpace(req.Description))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Money moved successfully", transaction)
+}
+
+// GetAutoSaveRules godoc
+// @Summary Auto-save rules of a pocket
+// @Tags Pocket
+// @Produce json
+// @Security BearerAuth
+// @Param pocket_id path int true "Pocket account ID"
+// @Success 200 {object} utils.APISuccessResponse{data=[]models.AutoSaveRule}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /pockets/{pocket_id}/auto-save [get]
+func (h *PocketHandler) GetAutoSaveRules(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("pocket_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	rules, err := h.pocketService.GetAutoSaveRules(uint(utils.CLaimJwt(c)), uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Auto-save rules fetched successfully", rules)
+}
+
+// AddAutoSaveRule godoc
+// @Summary Add an auto-save rule
+// @Description round_up saves the difference to the next multiple of round_to on every payment, scheduled saves a fixed amount daily, weekly or monthly
+// @Tags Pocket
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param pocket_id path int true "Pocket account ID"
+// @Param rule body validator.AutoSaveRuleRequest true "Rule"
+// @Success 201 {object} utils.APISuccessResponse{data=models.AutoSaveRule}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /pockets/{pocket_id}/auto-save [post]
+func (h *PocketHandler) AddAutoSaveRule(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("pocket_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	var req validator.AutoSaveRuleRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateAutoSaveRule); err != nil {
+		return err
+	}
+	userID := uint(utils.CLaimJwt(c))
+
+	rule := &models.AutoSaveRule{UserID: userID, PocketID: uint(id), RoundTo: req.RoundTo}
+	if req.SourceAccountID != nil {
+		rule.SourceAccountID = *req.SourceAccountID
+	} else {
+		source, err := h.accountService.GetMainBalanceAccount(userID)
+		if err != nil {
+			return utils.SplitErrorResponse(c, err)
+		}
+		rule.SourceAccountID = source.ID
+	}
+
+	if req.Type == models.AutoSaveRoundUp {
+		err = h.pocketService.AddRoundUpRule(rule)
+	} else {
+		err = h.pocketService.AddScheduledRule(rule, &models.ScheduledTransfer{
+			Amount:    req.Amount,
+			Frequency: req.Frequency,
+			Interval:  req.Interval,
+			StartAt:   *req.StartAt,
+			EndAt:     req.EndAt,
+			Timezone:  req.Timezone,
+		})
+	}
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Auto-save rule added successfully", rule)
+}
+
+// DeleteAutoSaveRule godoc
+// @Summary Stop an auto-save rule
+// @Tags Pocket
+// @Produce json
+// @Security BearerAuth
+// @Param pocket_id path int true "Pocket account ID"
+// @Param rule_id path int true "Rule ID"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /pockets/{pocket_id}/auto-save/{rule_id} [delete]
+func (h *PocketHandler) DeleteAutoSaveRule(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("pocket_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	ruleID, err := strconv.Atoi(c.Param("rule_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	if err := h.pocketService.DeleteAutoSaveRule(uint(utils.CLaimJwt(c)), uint(id), uint(ruleID)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Auto-save rule stopped", nil)
+}
@@ -13,11 +13,15 @@ import (
 )
 
 type QRHandler struct {
-	qrService *services.QRService
+	qrService      *services.QRService
+	budgetService  *services.BudgetService
+	pinService     *services.PinService
+	emailService   *services.EmailService
+	accountService *services.AccountService
 }
 
-func NewQRHandler(qrService *services.QRService) *QRHandler {
-	return &QRHandler{qrService: qrService}
+func NewQRHandler(qrService *services.QRService, budgetService *services.BudgetService, pinService *services.PinService, emailService *services.EmailService, accountService *services.AccountService) *QRHandler {
+	return &QRHandler{qrService: qrService, budgetService: budgetService, pinService: pinService, emailService: emailService, accountService: accountService}
 }
 
 func (h *QRHandler) CreateQR(c echo.Context) error {
@@ -26,11 +30,17 @@ func (h *QRHandler) CreateQR(c echo.Context) error {
 		return err
 	}
 
+	if err := ensureAccountOwner(c, h.accountService, uint(req.ReceiverAccountID)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
+	expiresAt := time.Now().Add(time.Minute)
 	qr := &models.QrCode{
+		Type:              models.QrDynamic,
 		ReceiverAccountID: uint(req.ReceiverAccountID),
 		Amount:            req.Amount,
 		URL:               req.URL,
-		ExpiresAt:         time.Now().Add(time.Minute),
+		ExpiresAt:         &expiresAt,
 	}
 
 	if err := h.qrService.CreateQR(qr); err != nil {
@@ -40,6 +50,77 @@ func (h *QRHandler) CreateQR(c echo.Context) error {
 	return utils.SuccessResponse(c, http.StatusCreated, "QR created successfully", qr)
 }
 
+// ParseQR godoc
+// @Summary Decode a scanned QR payload
+// @Description Checks the EMVCo payload and its CRC and resolves the QR and the receiving account, pay it with PUT /qr/{qr_id}
+// @Tags QR
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payload body validator.ParseQRRequest true "Scanned payload"
+// @Success 200 {object} utils.APISuccessResponse{data=models.QrPayloadInfo}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /qr/parse [post]
+func (h *QRHandler) ParseQR(c echo.Context) error {
+	var req validator.ParseQRRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateParseQR); err != nil {
+		return err
+	}
+	info, err := h.qrService.ParsePayload(req.Payload)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "QR payload parsed successfully", info)
+}
+
+// GetQRImage godoc
+// @Summary Render a QR code as an image
+// @Description PNG or SVG of the QR payload, only for the owner of the receiving account. With logo=true the merchant logo is drawn in the middle and the level is raised to H.
+// @Tags QR
+// @Produce image/png
+// @Produce image/svg+xml
+// @Security BearerAuth
+// @Param qr_id path int true "QR ID"
+// @Param format query string false "png (default) or svg"
+// @Param size query int false "Edge in pixels"
+// @Param level query string false "Error correction L, M (default), Q or H"
+// @Param logo query bool false "Draw the merchant logo"
+// @Success 200 {file} file
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /qr/{qr_id}/image [get]
+func (h *QRHandler) GetQRImage(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("qr_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	var query validator.QRImageQuery
+	if err := utils.BindAndValidate(c, &query, validator.ValidateQRImageQuery); err != nil {
+		return err
+	}
+
+	qr, err := h.qrService.GetQRById(uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	if err := ensureAccountOwner(c, h.accountService, qr.ReceiverAccountID); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
+	body, contentType, err := h.qrService.RenderImage(qr, models.QrImageOptions{
+		Format: query.Format,
+		Size:   query.Size,
+		Level:  query.Level,
+		Logo:   query.Logo,
+	})
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return c.Blob(http.StatusOK, contentType, body)
+}
+
 func (h *QRHandler) ScanQr(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("qr_id"))
 	if err != nil {
@@ -50,13 +131,29 @@ func (h *QRHandler) ScanQr(c echo.Context) error {
 		return err
 	}
 
+	if err := ensureAccountOwner(c, h.accountService, uint(req.SenderAccountID)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
 	foundQr, err := h.qrService.GetQRById(uint(id))
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
 
-	if err := h.qrService.ScanQR(foundQr, uint(req.SenderAccountID)); err != nil {
+	amount, err := h.qrService.PaymentAmount(foundQr, req.Amount)
+	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
+	if err := authorizePayment(c, h.pinService, h.emailService, amount); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
+	transaction, err := h.qrService.ScanQR(foundQr, uint(req.SenderAccountID), req.Amount)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	if err := h.budgetService.NotifyAfterPayment(transaction); err != nil {
+		c.Logger().Warn(err)
+	}
 	return utils.SuccessResponse(c, http.StatusOK, "QR scanned successfully", foundQr)
 }
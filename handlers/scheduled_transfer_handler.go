@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"gopay-clone/models"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+var schedulePageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at":  {Column: "created_at", Kind: utils.SortTime},
+		"next_run_at": {Column: "next_run_at", Kind: utils.SortTime},
+		"amount":      {Column: "amount", Kind: utils.SortNumber},
+	},
+	Filters: map[string]string{"status": "status", "frequency": "frequency"},
+}
+
+var scheduleRunPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+	},
+	Filters: map[string]string{"status": "status"},
+}
+
+type ScheduledTransferHandler struct {
+	scheduleService *services.ScheduledTransferService
+	accountService  *services.AccountService
+	contactService  *services.ContactService
+	pinService      *services.PinService
+	emailService    *services.EmailService
+}
+
+func NewScheduledTransferHandler(
+	scheduleService *services.ScheduledTransferService,
+	accountService *services.AccountService,
+	contactService *services.ContactService,
+	pinService *services.PinService,
+	emailService *services.EmailService,
+) *ScheduledTransferHandler {
+	return &ScheduledTransferHandler{
+		scheduleService: scheduleService,
+		accountService:  accountService,
+		contactService:  contactService,
+		pinService:      pinService,
+		emailService:    emailService,
+	}
+}
+
+// CreateSchedule godoc
+// @Summary Schedule a transfer
+// @Description One off transfer on start_at, or a recurring one every interval days, weeks or months
+// @Tags ScheduledTransfer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param schedule body validator.CreateScheduleRequest true "Schedule"
+// @Success 201 {object} utils.APISuccessResponse{data=models.ScheduledTransfer}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Param X-Transaction-PIN header string false "Transaction PIN, required above the configured amount"
+// @Router /scheduled-transfers [post]
+func (h *ScheduledTransferHandler) CreateSchedule(c echo.Context) error {
+	var req validator.CreateScheduleRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateCreateSchedule); err != nil {
+		return err
+	}
+	// the PIN is asked once when the schedule is set up, not on every run
+	if err := authorizePayment(c, h.pinService, h.emailService, req.Amount); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	userID := uint(utils.CLaimJwt(c))
+
+	schedule := &models.ScheduledTransfer{
+		UserID:      userID,
+		Amount:      req.Amount,
+		Description: strings.TrimSpace(req.Description),
+		Frequency:   req.Frequency,
+		Interval:    req.Interval,
+		StartAt:     req.StartAt,
+		EndAt:       req.EndAt,
+		MaxRuns:     req.MaxRuns,
+		Timezone:    req.Timezone,
+	}
+	if req.Category != nil {
+		schedule.Category = *req.Category
+	}
+
+	if req.SenderAccountID != nil {
+		schedule.SenderAccountID = *req.SenderAccountID
+	} else {
+		sender, err := h.accountService.GetMainBalanceAccount(userID)
+		if err != nil {
+			return utils.SplitErrorResponse(c, err)
+		}
+		schedule.SenderAccountID = sender.ID
+	}
+
+	if req.ContactID != nil {
+		contact, err := h.contactService.GetContact(userID, *req.ContactID)
+		if err != nil {
+			return utils.SplitErrorResponse(c, err)
+		}
+		receiver, err := h.accountService.GetMainBalanceAccount(contact.TargetID)
+		if err != nil {
+			return utils.SplitErrorResponse(c, err)
+		}
+		schedule.ReceiverAccountID = receiver.ID
+	} else {
+		schedule.ReceiverAccountID = *req.ReceiverAccountID
+	}
+
+	if err := h.scheduleService.CreateSchedule(schedule); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Transfer scheduled successfully", schedule)
+}
+
+// GetSchedules godoc
+// @Summary List scheduled transfers
+// @Tags ScheduledTransfer
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "active, paused, completed, cancelled or failed"
+// @Param frequency query string false "once, daily, weekly or monthly"
+// @Param sort_by query string false "created_at (default), next_run_at or amount"
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.ScheduledTransfer}
+// @Router /scheduled-transfers [get]
+func (h *ScheduledTransferHandler) GetSchedules(c echo.Context) error {
+	page, err := utils.ParsePageParams(c, schedulePageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	schedules, meta, err := h.scheduleService.GetSchedules(uint(utils.CLaimJwt(c)), page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Scheduled transfers fetched successfully", schedules, meta)
+}
+
+// GetSchedule godoc
+// @Summary Get a scheduled transfer
+// @Tags ScheduledTransfer
+// @Produce json
+// @Security BearerAuth
+// @Param schedule_id path int true "Schedule ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.ScheduledTransfer}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /scheduled-transfers/{schedule_id} [get]
+func (h *ScheduledTransferHandler) GetSchedule(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("schedule_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	schedule, err := h.scheduleService.GetSchedule(uint(utils.CLaimJwt(c)), uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Scheduled transfer fetched successfully", schedule)
+}
+
+// GetScheduleRuns godoc
+// @Summary Run history of a scheduled transfer
+// @Description Every attempt with its outcome, retries on low balance included
+// @Tags ScheduledTransfer
+// @Produce json
+// @Security BearerAuth
+// @Param schedule_id path int true "Schedule ID"
+// @Param status query string false "success, retrying or failed"
+// @Param limit query int false "Page size, max 100"
+// @Param cursor query string false "Cursor from the previous page meta"
+// @Success 200 {object} utils.APIPaginatedResponse{data=[]models.ScheduledTransferRun}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /scheduled-transfers/{schedule_id}/runs [get]
+func (h *ScheduledTransferHandler) GetScheduleRuns(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("schedule_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	page, err := utils.ParsePageParams(c, scheduleRunPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	runs, meta, err := h.scheduleService.GetRuns(uint(utils.CLaimJwt(c)), uint(id), page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Runs fetched successfully", runs, meta)
+}
+
+// PauseSchedule godoc
+// @Summary Pause a scheduled transfer
+// @Tags ScheduledTransfer
+// @Produce json
+// @Security BearerAuth
+// @Param schedule_id path int true "Schedule ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.ScheduledTransfer}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /scheduled-transfers/{schedule_id}/pause [post]
+func (h *ScheduledTransferHandler) PauseSchedule(c echo.Context) error {
+	return h.changeStatus(c, h.scheduleService.PauseSchedule, "Scheduled transfer paused")
+}
+
+// ResumeSchedule godoc
+// @Summary Resume a paused scheduled transfer
+// @Description Occurrences missed while paused are skipped
+// @Tags ScheduledTransfer
+// @Produce json
+// @Security BearerAuth
+// @Param schedule_id path int true "Schedule ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.ScheduledTransfer}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /scheduled-transfers/{schedule_id}/resume [post]
+func (h *ScheduledTransferHandler) ResumeSchedule(c echo.Context) error {
+	return h.changeStatus(c, h.scheduleService.ResumeSchedule, "Scheduled transfer resumed")
+}
+
+// CancelSchedule godoc
+// @Summary Cancel a scheduled transfer
+// @Tags ScheduledTransfer
+// @Produce json
+// @Security BearerAuth
+// @Param schedule_id path int true "Schedule ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.ScheduledTransfer}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /scheduled-transfers/{schedule_id} [delete]
+func (h *ScheduledTransferHandler) CancelSchedule(c echo.Context) error {
+	return h.changeStatus(c, h.scheduleService.CancelSchedule, "Scheduled transfer cancelled")
+}
+
+func (h *ScheduledTransferHandler) changeStatus(c echo.Context, change func(userID, id uint) (*models.ScheduledTransfer, error), message string) error {
+	id, err := strconv.Atoi(c.Param("schedule_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	schedule, err := change(uint(utils.CLaimJwt(c)), uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, message, schedule)
+}
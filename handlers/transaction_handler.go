@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"gopay-clone/middleware"
 	"gopay-clone/models"
 	"gopay-clone/services"
 	"gopay-clone/utils"
@@ -13,10 +14,14 @@ import (
 
 type TransactionHandler struct {
 	transactionService *services.TransactionService
+	budgetService      *services.BudgetService
+	pinService         *services.PinService
+	emailService       *services.EmailService
+	accountService     *services.AccountService
 }
 
-func NewTransactionHandler(transactionService *services.TransactionService) *TransactionHandler {
-	return &TransactionHandler{transactionService: transactionService}
+func NewTransactionHandler(transactionService *services.TransactionService, budgetService *services.BudgetService, pinService *services.PinService, emailService *services.EmailService, accountService *services.AccountService) *TransactionHandler {
+	return &TransactionHandler{transactionService: transactionService, budgetService: budgetService, pinService: pinService, emailService: emailService, accountService: accountService}
 }
 
 func (h *TransactionHandler) CreateTransaction(c echo.Context) error {
@@ -29,8 +34,8 @@ func (h *TransactionHandler) CreateTransaction(c echo.Context) error {
 		Amount:            req.Amount,
 		SenderAccountID:   req.SenderAccountID,
 		ReceiverAccountID: req.ReceiverAccountID,
-		QrCodeID:          req.QrCodeID,
 		ServiceID:         req.ServiceID,
+		Status:            models.TransactionCompleted, // the money moves right away, clients can't file it as anything else
 	}
 
 	if req.Type != nil {
@@ -39,9 +44,6 @@ func (h *TransactionHandler) CreateTransaction(c echo.Context) error {
 	if req.Category != nil {
 		transaction.Category = models.TransactionCategory(*req.Category)
 	}
-	if req.Status != nil {
-		transaction.Status = models.TransactionStatus(*req.Status)
-	}
 	if req.ServiceType != nil {
 		transaction.ServiceType = models.ServiceType(*req.ServiceType)
 	}
@@ -49,9 +51,20 @@ func (h *TransactionHandler) CreateTransaction(c echo.Context) error {
 		transaction.Description = *req.Description
 	}
 
+	if err := ensureAccountOwner(c, h.accountService, transaction.SenderAccountID); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	if err := authorizePayment(c, h.pinService, h.emailService, transaction.Amount); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+
 	if err := h.transactionService.CreateTransaction(transaction); err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
+	// the transfer already went through, a failed budget check should not fail the request
+	if err := h.budgetService.NotifyAfterPayment(transaction); err != nil {
+		c.Logger().Warn(err)
+	}
 
 	return utils.SuccessResponse(c, http.StatusCreated, "Transaction created successfully", transaction)
 }
@@ -66,6 +79,9 @@ func (h *TransactionHandler) GetTransactionDetail(c echo.Context) error {
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
+	if err := ensureOwnerOr(c, middleware.PermReadAny, transaction.SenderAccount.UserId, transaction.ReceiverAccount.UserId); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
 	return utils.SuccessResponse(c, http.StatusOK, "Transaction fetched successfully", transaction)
 }
 
@@ -74,9 +90,11 @@ func (h *TransactionHandler) UpdateTransactionDetail(c echo.Context) error {
 	if err != nil {
 		return utils.ValidationErrorResponse(c, err)
 	}
-	loggedInUserId := utils.CLaimJwt(c)
-
-	if loggedInUserId == 0 {
+	transaction, err := h.transactionService.GetTransactionById(uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	if err := ensureOwner(c, transaction.SenderAccount.UserId, transaction.ReceiverAccount.UserId); err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
 	var req validator.UpdateTransactionRequest
@@ -85,12 +103,6 @@ func (h *TransactionHandler) UpdateTransactionDetail(c echo.Context) error {
 	}
 	updates := make(map[string]any)
 
-	if req.Status != nil {
-		updates["status"] = *req.Status
-	}
-	if req.QrCodeID != nil {
-		updates["qr_code_id"] = *req.QrCodeID
-	}
 	if req.Category != nil {
 		updates["category"] = *req.Category
 	}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService *services.TwoFactorService
+	authService      *services.AuthService
+	emailService     *services.EmailService
+}
+
+func NewTwoFactorHandler(twoFactorService *services.TwoFactorService, authService *services.AuthService, emailService *services.EmailService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: twoFactorService, authService: authService, emailService: emailService}
+}
+
+// GetStatus godoc
+// @Summary Two-factor authentication status
+// @Tags Two-Factor
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APISuccessResponse{data=models.TwoFactorStatus}
+// @Router /auth/2fa [get]
+func (h *TwoFactorHandler) GetStatus(c echo.Context) error {
+	status, err := h.twoFactorService.Status(uint(utils.CLaimJwt(c)))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Two-factor status fetched successfully", status)
+}
+
+// Enrol godoc
+// @Summary Start two-factor enrolment
+// @Description Returns the secret and an otpauth:// URI for the authenticator app, 2FA is off until POST /auth/2fa/enable
+// @Tags Two-Factor
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} utils.APISuccessResponse{data=models.TwoFactorEnrolment}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /auth/2fa/enrol [post]
+func (h *TwoFactorHandler) Enrol(c echo.Context) error {
+	enrolment, err := h.twoFactorService.Enrol(uint(utils.CLaimJwt(c)))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Scan the code with your authenticator app", enrolment)
+}
+
+// Enable godoc
+// @Summary Turn on two-factor authentication
+// @Description Confirms the enrolment with a code from the app, the recovery codes are only shown here
+// @Tags Two-Factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body validator.TwoFactorCodeRequest true "Code from the app"
+// @Success 200 {object} utils.APISuccessResponse{data=models.RecoveryCodes}
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /auth/2fa/enable [post]
+func (h *TwoFactorHandler) Enable(c echo.Context) error {
+	var req validator.TwoFactorCodeRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateTwoFactorCode); err != nil {
+		return err
+	}
+	codes, err := h.twoFactorService.Enable(uint(utils.CLaimJwt(c)), req.Code)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication enabled", codes)
+}
+
+// Disable godoc
+// @Summary Turn off two-factor authentication
+// @Tags Two-Factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param disable body validator.DisableTwoFactorRequest true "Password and a code from the app or a recovery code"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /auth/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(c echo.Context) error {
+	var req validator.DisableTwoFactorRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateDisableTwoFactor); err != nil {
+		return err
+	}
+	if err := h.twoFactorService.Disable(uint(utils.CLaimJwt(c)), req.Password, req.Code); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace the recovery codes
+// @Description The old codes stop working, used or not
+// @Tags Two-Factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body validator.TwoFactorCodeRequest true "Code from the app or a recovery code"
+// @Success 200 {object} utils.APISuccessResponse{data=models.RecoveryCodes}
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /auth/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	var req validator.TwoFactorCodeRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateTwoFactorCode); err != nil {
+		return err
+	}
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(uint(utils.CLaimJwt(c)), req.Code)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Recovery codes replaced", codes)
+}
+
+// CompleteLogin godoc
+// @Summary Finish a login that needs a two-factor code
+// @Description Takes the challenge token returned by login and a code from the app or a recovery code
+// @Tags Two-Factor
+// @Accept json
+// @Produce json
+// @Param login body validator.CompleteLoginRequest true "Challenge token and code"
+// @Success 200 {object} utils.APISuccessResponse{data=models.TokenPair}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 401 {object} utils.APIErrorResponse{error=utils.ErrorAuth}
+// @Router /public/auth/2fa [post]
+func (h *TwoFactorHandler) CompleteLogin(c echo.Context) error {
+	var req validator.CompleteLoginRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateCompleteLogin); err != nil {
+		return err
+	}
+	user, err := h.twoFactorService.CompleteChallenge(req.ChallengeToken, req.Code)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return startSession(c, h.authService, h.emailService, user)
+}
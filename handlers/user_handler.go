@@ -1,25 +1,56 @@
 package handlers
 
 import (
-	"errors"
+	apperrors "gopay-clone/errors"
 	"gopay-clone/models"
 	"gopay-clone/services"
 	"gopay-clone/utils"
 	"gopay-clone/validator"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
 type UserHandler struct {
-	userService    *services.UserService
-	accountService *services.AccountService
-	orderService   *services.OrderService
+	userService      *services.UserService
+	accountService   *services.AccountService
+	orderService     *services.OrderService
+	authService      *services.AuthService
+	otpService       *services.OtpService
+	emailService     *services.EmailService
+	twoFactorService *services.TwoFactorService
+	loginGuard       *services.LoginGuard
 }
 
-func NewUserHandler(userService *services.UserService, accountService *services.AccountService, orderService *services.OrderService) *UserHandler {
-	return &UserHandler{userService: userService, accountService: accountService, orderService: orderService}
+var userPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+		"name":       {Column: "name", Kind: utils.SortString},
+	},
+	Filters: map[string]string{"user_type": "type"},
+}
+
+var accountPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+		"balance":    {Column: "balance", Kind: utils.SortNumber},
+	},
+	DefaultAsc: true,
+	Filters:    map[string]string{"account_type": "account_type"},
+}
+
+var orderPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at":   {Column: "created_at", Kind: utils.SortTime},
+		"total_amount": {Column: "total_amount", Kind: utils.SortNumber},
+	},
+	Filters: map[string]string{"status": "status", "merchant_id": "merchant_id"},
+}
+
+func NewUserHandler(userService *services.UserService, accountService *services.AccountService, orderService *services.OrderService, authService *services.AuthService, otpService *services.OtpService, emailService *services.EmailService, twoFactorService *services.TwoFactorService, loginGuard *services.LoginGuard) *UserHandler {
+	return &UserHandler{userService: userService, accountService: accountService, orderService: orderService, authService: authService, otpService: otpService, emailService: emailService, twoFactorService: twoFactorService, loginGuard: loginGuard}
 }
 
 func (h *UserHandler) CreateUser(c echo.Context) error {
@@ -41,9 +72,23 @@ func (h *UserHandler) CreateUser(c echo.Context) error {
 		Type:              models.UserType(req.Type),
 	}
 
+	if req.OtpCode != "" {
+		if err := h.otpService.Verify(req.Phone, models.OtpRegister, req.OtpCode); err != nil {
+			return utils.SplitErrorResponse(c, err)
+		}
+		now := time.Now()
+		user.PhoneVerifiedAt = &now
+	} else if h.otpService.RequiredOnRegister() {
+		return utils.SplitErrorResponse(c, apperrors.ErrOtpRequired)
+	}
+
 	if err := h.userService.CreateUser(user); err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
+	// the user exists either way, the link can be sent again from POST /auth/email/verification
+	if err := h.emailService.SendVerification(c.Request().Context(), user.ID); err != nil {
+		c.Logger().Warn(err)
+	}
 
 	return utils.SuccessResponse(c, http.StatusCreated, "User created successfully", user)
 }
@@ -59,15 +104,74 @@ func (h *UserHandler) Login(c echo.Context) error {
 		Password: req.Password,
 	}
 
-	token, err := h.userService.Login(user)
+	if err := h.loginGuard.Check(req.Email, c.RealIP()); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	foundUser, err := h.userService.Login(user)
 	if err != nil {
+		if err == apperrors.ErrInvalidCredentials {
+			h.loginGuard.Fail(req.Email, c.RealIP())
+		}
 		return utils.SplitErrorResponse(c, err)
 	}
+	h.loginGuard.Succeed(req.Email)
+	return h.finishLogin(c, foundUser, true, req.OtpCode)
+}
 
-	// Set the Authorization header with Bearer token
-	c.Response().Header().Set("Authorization", "Bearer "+token)
+// LoginByPhone starts a session with a login code instead of the password
+func (h *UserHandler) LoginByPhone(c echo.Context) error {
+	var req validator.PhoneLoginRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidatePhoneLogin); err != nil {
+		return err
+	}
+	if err := h.otpService.Verify(req.Phone, models.OtpLogin, req.OtpCode); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	user, err := h.userService.GetUserByVerifiedPhone(req.Phone)
+	if err != nil {
+		// a code only goes out to verified phones, so this is a code guessed for an unknown number
+		return utils.SplitErrorResponse(c, apperrors.ErrOtpExpired)
+	}
+	// the code already came from the phone, a new device check would ask for the same again
+	return h.finishLogin(c, user, false, "")
+}
 
-	return utils.SuccessResponse(c, http.StatusOK, "Login successful", map[string]string{"token": token})
+// finishLogin starts the session, or answers 202 with a challenge for POST /public/auth/2fa
+// when the user has two-factor authentication on. Without 2FA a password login from a new
+// device can be stepped up with a code to the verified phone, the client logs in again with it.
+func (h *UserHandler) finishLogin(c echo.Context, user *models.User, stepUp bool, otpCode string) error {
+	enabled, err := h.twoFactorService.Enabled(user.ID)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	if enabled {
+		challenge, err := h.twoFactorService.StartChallenge(user.ID)
+		if err != nil {
+			return utils.SplitErrorResponse(c, err)
+		}
+		return utils.SuccessResponse(c, http.StatusAccepted, "Two-factor code required", challenge)
+	}
+
+	if stepUp && h.authService.NewDeviceOtp() && user.PhoneVerifiedAt != nil {
+		known, err := h.authService.KnownDevice(user.ID, deviceInfo(c).Token)
+		if err != nil {
+			return utils.SplitErrorResponse(c, err)
+		}
+		if !known {
+			if otpCode == "" {
+				challenge, err := h.otpService.Send(c.Request().Context(), user.Phone, models.OtpNewDevice)
+				if err != nil {
+					return utils.SplitErrorResponse(c, err)
+				}
+				return utils.SuccessResponse(c, http.StatusAccepted, "New device, log in again with the code sent to your phone", challenge)
+			}
+			if err := h.otpService.Verify(user.Phone, models.OtpNewDevice, otpCode); err != nil {
+				return utils.SplitErrorResponse(c, err)
+			}
+		}
+	}
+
+	return startSession(c, h.authService, h.emailService, user)
 }
 
 func (h *UserHandler) GetUserById(c echo.Context) error {
@@ -75,11 +179,6 @@ func (h *UserHandler) GetUserById(c echo.Context) error {
 	if err != nil {
 		return utils.ValidationErrorResponse(c, err)
 	}
-	loggedInUserId := utils.CLaimJwt(c)
-	if id != int(loggedInUserId) {
-		return utils.ForbiddenResponse(c, errors.New("unauthorized access"))
-	}
-
 	user, err := h.userService.GetUserById(uint(id))
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
@@ -88,11 +187,15 @@ func (h *UserHandler) GetUserById(c echo.Context) error {
 }
 
 func (h *UserHandler) GetAllUsers(c echo.Context) error {
-	users, err := h.userService.GetUsers()
+	page, err := utils.ParsePageParams(c, userPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	users, meta, err := h.userService.GetUsers(page)
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
-	return utils.SuccessResponse(c, http.StatusOK, "Users fetched successfully", users)
+	return utils.PaginatedResponse(c, http.StatusOK, "Users fetched successfully", users, meta)
 }
 
 func (h *UserHandler) UpdateUser(c echo.Context) error {
@@ -104,11 +207,6 @@ func (h *UserHandler) UpdateUser(c echo.Context) error {
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
-	loggedInUserId := utils.CLaimJwt(c)
-
-	if id != int(loggedInUserId) {
-		return utils.ForbiddenResponse(c, errors.New("unauthorized access"))
-	}
 	var req validator.UpdateUserRequest
 	if err := utils.BindAndValidate(c, &req, validator.ValidateUpdateUser); err != nil {
 		return err
@@ -118,6 +216,10 @@ func (h *UserHandler) UpdateUser(c echo.Context) error {
 		user.Name = *req.Name
 	}
 	if req.Phone != nil {
+		// a new number has to be verified again
+		if *req.Phone != user.Phone {
+			user.PhoneVerifiedAt = nil
+		}
 		user.Phone = *req.Phone
 	}
 	if req.Password != nil {
@@ -153,24 +255,26 @@ func (h *UserHandler) GetAccountsByUser(c echo.Context) error {
 	if err != nil {
 		return utils.ValidationErrorResponse(c, err)
 	}
-	loggedInUserId := utils.CLaimJwt(c)
-
-	if userId != int(loggedInUserId) {
-		return utils.ForbiddenResponse(c, errors.New("unauthorized access"))
+	page, err := utils.ParsePageParams(c, accountPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
 	}
-
-	accounts, err := h.accountService.GetAccountsByUser(uint(userId))
+	accounts, meta, err := h.accountService.GetAccountsByUser(uint(userId), page)
 	if err != nil {
 		return utils.SplitErrorResponse(c, err)
 	}
-	return utils.SuccessResponse(c, http.StatusOK, "Accounts for user fetched successfully", accounts)
+	return utils.PaginatedResponse(c, http.StatusOK, "Accounts for user fetched successfully", accounts, meta)
 }
 
 func (h *UserHandler) GetAllOrdersByUser(c echo.Context) error {
 	loggedInUserId := utils.CLaimJwt(c)
-	orders, err := h.orderService.GetAllOrdersByUser(uint(loggedInUserId))
+	page, err := utils.ParsePageParams(c, orderPageOptions)
 	if err != nil {
 		return utils.ValidationErrorResponse(c, err)
 	}
-	return utils.SuccessResponse(c, http.StatusOK, "Orders fetched successfully", orders)
+	orders, meta, err := h.orderService.GetAllOrdersByUser(uint(loggedInUserId), page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Orders fetched successfully", orders, meta)
 }
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"gopay-clone/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookHandler struct {
+	webhookService  *services.WebhookService
+	merchantService *services.MerchantService
+}
+
+var webhookDeliveryPageOptions = utils.PageOptions{
+	Sorts: map[string]utils.SortField{
+		"created_at": {Column: "created_at", Kind: utils.SortTime},
+	},
+	Filters: map[string]string{"status": "status", "event": "event", "endpoint_id": "endpoint_id", "event_id": "event_id"},
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService, merchantService *services.MerchantService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService, merchantService: merchantService}
+}
+
+// GetEndpoints godoc
+// @Summary List the webhook endpoints of a merchant
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Success 200 {object} utils.APISuccessResponse{data=[]models.WebhookEndpoint}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /merchants/{merchant_id}/webhooks [get]
+func (h *WebhookHandler) GetEndpoints(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	endpoints, err := h.webhookService.GetEndpoints(merchantID)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Webhook endpoints fetched successfully", endpoints)
+}
+
+// CreateEndpoint godoc
+// @Summary Register a webhook endpoint
+// @Description The secret is only returned here, deliveries are signed with its SHA-256
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param webhook body validator.CreateWebhookRequest true "URL and events"
+// @Success 201 {object} utils.APISuccessResponse{data=models.WebhookEndpointCredentials}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /merchants/{merchant_id}/webhooks [post]
+func (h *WebhookHandler) CreateEndpoint(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	var req validator.CreateWebhookRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateCreateWebhook); err != nil {
+		return err
+	}
+	credentials, err := h.webhookService.CreateEndpoint(merchantID, req.URL, req.Events)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusCreated, "Webhook endpoint created, store the secret now", credentials)
+}
+
+// UpdateEndpoint godoc
+// @Summary Change the URL or events of a webhook endpoint, or disable it
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param webhook_id path int true "Webhook endpoint ID"
+// @Param webhook body validator.UpdateWebhookRequest true "Fields to change"
+// @Success 200 {object} utils.APISuccessResponse{data=models.WebhookEndpoint}
+// @Failure 400 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /merchants/{merchant_id}/webhooks/{webhook_id} [put]
+func (h *WebhookHandler) UpdateEndpoint(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	id, err := strconv.Atoi(c.Param("webhook_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	var req validator.UpdateWebhookRequest
+	if err := utils.BindAndValidate(c, &req, validator.ValidateUpdateWebhook); err != nil {
+		return err
+	}
+	endpoint, err := h.webhookService.UpdateEndpoint(merchantID, uint(id), req.URL, req.Events, req.Enabled)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Webhook endpoint updated successfully", endpoint)
+}
+
+// DeleteEndpoint godoc
+// @Summary Delete a webhook endpoint
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param webhook_id path int true "Webhook endpoint ID"
+// @Success 200 {object} utils.APISuccessResponse
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /merchants/{merchant_id}/webhooks/{webhook_id} [delete]
+func (h *WebhookHandler) DeleteEndpoint(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	id, err := strconv.Atoi(c.Param("webhook_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	if err := h.webhookService.DeleteEndpoint(merchantID, uint(id)); err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Webhook endpoint deleted successfully", nil)
+}
+
+// GetDeliveries godoc
+// @Summary Webhook delivery log of a merchant
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param status query string false "pending, delivered or failed"
+// @Param event query string false "Event name"
+// @Param endpoint_id query int false "Webhook endpoint ID"
+// @Param event_id query string false "Event ID"
+// @Success 200 {object} utils.APISuccessResponse{data=[]models.WebhookDelivery}
+// @Failure 403 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /merchants/{merchant_id}/webhooks/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	page, err := utils.ParsePageParams(c, webhookDeliveryPageOptions)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	deliveries, meta, err := h.webhookService.GetDeliveries(merchantID, page)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.PaginatedResponse(c, http.StatusOK, "Webhook deliveries fetched successfully", deliveries, meta)
+}
+
+// GetDelivery godoc
+// @Summary A webhook delivery with every attempt
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 200 {object} utils.APISuccessResponse{data=models.WebhookDelivery}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Router /merchants/{merchant_id}/webhooks/deliveries/{delivery_id} [get]
+func (h *WebhookHandler) GetDelivery(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	id, err := strconv.Atoi(c.Param("delivery_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	delivery, err := h.webhookService.GetDelivery(merchantID, uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusOK, "Webhook delivery fetched successfully", delivery)
+}
+
+// Redeliver godoc
+// @Summary Send a delivered or failed webhook again
+// @Description Queues a new delivery of the same event with the same event ID
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param merchant_id path int true "Merchant ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 202 {object} utils.APISuccessResponse{data=models.WebhookDelivery}
+// @Failure 404 {object} utils.APIErrorResponse{error=utils.ErrorNotFound}
+// @Failure 409 {object} utils.APIErrorResponse{error=utils.ErrorDetail}
+// @Router /merchants/{merchant_id}/webhooks/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c echo.Context) error {
+	merchantID, err := ensureMerchantOwner(c, h.merchantService)
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	id, err := strconv.Atoi(c.Param("delivery_id"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+	delivery, err := h.webhookService.Redeliver(merchantID, uint(id))
+	if err != nil {
+		return utils.SplitErrorResponse(c, err)
+	}
+	return utils.SuccessResponse(c, http.StatusAccepted, "Webhook queued for redelivery", delivery)
+}
@@ -1,37 +1,66 @@
 package main
 
 import (
+	"context"
 	"gopay-clone/config"
 	_ "gopay-clone/docs"
+	"gopay-clone/middleware"
 	"gopay-clone/migrations"
 	"gopay-clone/routes"
+	"gopay-clone/services"
 	"net/http"
 	"os"
 
 	echoSwagger "github.com/swaggo/echo-swagger"
 
 	"github.com/joho/godotenv"
-	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 )
 
-func setupRoutes(e *echo.Echo, db *config.Database, secret string) {
+func setupRoutes(e *echo.Echo, db *config.Database) {
 	api := e.Group("/api/v1")
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
-	jwtMiddleware := echojwt.WithConfig(echojwt.Config{
-		SigningKey:  []byte(secret),
-		ContextKey:  "user", // This stores the *jwt.Token in context
-		TokenLookup: "header:Authorization:Bearer ",
-	})
+	authConfig := config.LoadAuthConfig()
+	// every request made with an admin token lands in the audit log
+	jwtMiddleware := middleware.Chain(
+		middleware.JWT(authConfig, services.NewAuthService(db, authConfig)),
+		middleware.AuditAdmin(services.NewAuditService(db)),
+	)
+	// one store holds the rate limits and the login lockouts, swap it for a shared one when running several instances
+	limiter := middleware.NewRateLimiter(services.NewMemoryLimiterStore(), config.LoadRateLimitConfig())
+
 	// Register routes
-	routes.RegisterUserRoutes(api, db, jwtMiddleware)
+	routes.RegisterAuthRoutes(api, db, jwtMiddleware, limiter)
+	routes.RegisterUserRoutes(api, db, jwtMiddleware, limiter)
+	routes.RegisterOtpRoutes(api, db, jwtMiddleware, limiter)
 	routes.RegisterMerchantRoutes(api, db, jwtMiddleware)
+	routes.RegisterMerchantApiRoutes(api, db, limiter)
 	routes.RegisterAccountRoutes(api, db, jwtMiddleware)
-	routes.RegisterTransactionRoutes(api, db, jwtMiddleware)
-	routes.RegisterQRRoutes(api, db, jwtMiddleware)
-	routes.RegisterOrderRoutes(api, db, jwtMiddleware)
+	routes.RegisterTransactionRoutes(api, db, jwtMiddleware, limiter)
+	routes.RegisterQRRoutes(api, db, jwtMiddleware, limiter)
+	routes.RegisterOrderRoutes(api, db, jwtMiddleware, limiter)
 	routes.RegisterDriverRoutes(api, db, jwtMiddleware)
+	routes.RegisterInsightRoutes(api, db, jwtMiddleware)
+	routes.RegisterNotificationRoutes(api, db, jwtMiddleware)
+	routes.RegisterContactRoutes(api, db, jwtMiddleware, limiter)
+	routes.RegisterPaymentRequestRoutes(api, db, jwtMiddleware, limiter)
+	routes.RegisterScheduledTransferRoutes(api, db, jwtMiddleware, limiter)
+	routes.RegisterBillRoutes(api, db, jwtMiddleware, limiter)
+	routes.RegisterPocketRoutes(api, db, jwtMiddleware, limiter)
+	routes.RegisterPinRoutes(api, db, jwtMiddleware, limiter)
+	routes.RegisterAdminRoutes(api, db, jwtMiddleware)
+}
+
+// setupEvents subscribes everything that reacts to domain events, the services themselves
+// only write events to the outbox
+func setupEvents(db *config.Database, push services.PushProvider) *services.EventBus {
+	notificationConfig := config.LoadNotificationConfig()
+	bus := services.NewEventBus()
+	services.NewTransactionService(db).Subscribe(bus)
+	services.NewWebhookService(db, config.LoadWebhookConfig()).Subscribe(bus)
+	services.NewNotificationFanout(db, services.NewNotificationService(db, push), notificationConfig).Subscribe(bus)
+	return bus
 }
 
 // @title GoClone API
@@ -45,7 +74,6 @@ func setupRoutes(e *echo.Echo, db *config.Database, secret string) {
 
 func main() {
 	_ = godotenv.Load()
-	secret := os.Getenv("JWT_SECRET")
 
 	// database
 	db := config.InitDatabase()
@@ -55,6 +83,12 @@ func main() {
 
 	// echo
 	e := echo.New()
+	// rate limits and lockouts key on the client IP, forwarded headers are only believed behind a proxy
+	if os.Getenv("TRUST_PROXY_HEADERS") == "true" {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
 
 	// Health check
 	e.GET("/health", func(c echo.Context) error {
@@ -64,7 +98,22 @@ func main() {
 		})
 	})
 
-	setupRoutes(e, db, secret)
+	setupRoutes(e, db)
+
+	// background jobs
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	push := services.NewPushProvider(config.LoadNotificationConfig())
+	if schedulerConfig := config.LoadSchedulerConfig(); schedulerConfig.Enabled {
+		go services.NewTransferScheduler(db, schedulerConfig, push).Start(ctx)
+	}
+	if webhookConfig := config.LoadWebhookConfig(); webhookConfig.Enabled {
+		go services.NewWebhookDispatcher(db, webhookConfig).Start(ctx)
+	}
+	if outboxConfig := config.LoadOutboxConfig(); outboxConfig.Enabled {
+		go services.NewOutboxDispatcher(db, setupEvents(db, push), outboxConfig).Start(ctx)
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
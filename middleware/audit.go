@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"gopay-clone/models"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const auditReasonKey = "audit_reason"
+
+// SetAuditReason lets a handler put the reason the admin gave on the audit entry
+func SetAuditReason(c echo.Context, reason string) {
+	c.Set(auditReasonKey, reason)
+}
+
+// AuditAdmin records every request made with an admin token once the handler is done,
+// whatever route it hits and whether it succeeded. Goes after the JWT check.
+func AuditAdmin(auditService *services.AuditService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+
+			userID, role := utils.GetIDAndRoleFromJWT(c)
+			if models.UserType(role) != models.Admin {
+				return err
+			}
+
+			entry := &models.AuditLog{
+				ActorID:    uint(userID),
+				Action:     c.Request().Method + " " + c.Path(),
+				StatusCode: c.Response().Status,
+				IPAddress:  c.RealIP(),
+			}
+			if he, ok := err.(*echo.HTTPError); ok {
+				entry.StatusCode = he.Code
+			}
+			entry.Reason, _ = c.Get(auditReasonKey).(string)
+			entry.TargetType, entry.TargetID = auditTarget(c)
+
+			if recordErr := auditService.Record(entry); recordErr != nil {
+				c.Logger().Error(recordErr)
+			}
+			return err
+		}
+	}
+}
+
+// auditTarget takes the first path param, the segment in front of it names what it is:
+// /admin/drivers/:driver_id/verify gives drivers and the id
+func auditTarget(c echo.Context) (string, uint) {
+	names := c.ParamNames()
+	if len(names) == 0 {
+		return "", 0
+	}
+	id, _ := strconv.Atoi(c.Param(names[0]))
+
+	segments := strings.Split(c.Path(), "/")
+	for i, segment := range segments {
+		if segment == ":"+names[0] && i > 0 {
+			return segments[i-1], uint(id)
+		}
+	}
+	return names[0], uint(id)
+}
+
+// Chain runs the middlewares in the order given
+func Chain(middlewares ...echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
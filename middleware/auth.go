@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"gopay-clone/config"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+
+	echojwt "github.com/labstack/echo-jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+// JWT verifies the bearer token and then that its session was not logged out,
+// a signature alone is not enough once tokens can be revoked
+func JWT(cfg config.AuthConfig, authService *services.AuthService) echo.MiddlewareFunc {
+	verify := echojwt.WithConfig(echojwt.Config{
+		SigningKey:  []byte(cfg.Secret),
+		ContextKey:  "user", // This stores the *jwt.Token in context
+		TokenLookup: "header:Authorization:Bearer ",
+	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return verify(func(c echo.Context) error {
+			if err := authService.CheckSession(uint(utils.CLaimJwt(c)), utils.ClaimSessionID(c)); err != nil {
+				return utils.SplitErrorResponse(c, err)
+			}
+			return next(c)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/services"
+	"gopay-clone/utils"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// KeyFunc says whose budget a request spends
+type KeyFunc func(c echo.Context) string
+
+func ByIP(c echo.Context) string {
+	return "ip:" + c.RealIP()
+}
+
+// ByUser keys on the user of the token, routes without a token fall back to the IP
+func ByUser(c echo.Context) string {
+	if _, ok := c.Get("user").(*jwt.Token); ok {
+		return "user:" + strconv.Itoa(utils.CLaimJwt(c))
+	}
+	return ByIP(c)
+}
+
+type RateLimiter struct {
+	store services.LimiterStore
+	cfg   config.RateLimitConfig
+}
+
+func NewRateLimiter(store services.LimiterStore, cfg config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{store: store, cfg: cfg}
+}
+
+// Store is shared with the login lockout so one backend holds every counter
+func (l *RateLimiter) Store() services.LimiterStore {
+	return l.store
+}
+
+func (l *RateLimiter) Login() echo.MiddlewareFunc {
+	return l.Limit("login", l.cfg.Login, ByIP)
+}
+
+func (l *RateLimiter) Otp() echo.MiddlewareFunc {
+	return l.Limit("otp", l.cfg.Otp, ByUser)
+}
+
+// Verify goes after the JWT middleware
+func (l *RateLimiter) Verify() echo.MiddlewareFunc {
+	return l.Limit("verify", l.cfg.Verify, ByUser)
+}
+
+// Lookup goes after the JWT middleware
+func (l *RateLimiter) Lookup() echo.MiddlewareFunc {
+	return l.Limit("lookup", l.cfg.Lookup, ByUser)
+}
+
+// Money goes after the JWT middleware
+func (l *RateLimiter) Money() echo.MiddlewareFunc {
+	return l.Limit("money", l.cfg.Money, ByUser)
+}
+
+// MerchantApi goes after MerchantSignature
+func (l *RateLimiter) MerchantApi() echo.MiddlewareFunc {
+	return l.Limit("merchant-api", l.cfg.MerchantApi, ByMerchantKey)
+}
+
+// Limit counts requests per key in fixed windows, routes limited under the same name share the budget
+func (l *RateLimiter) Limit(name string, budget config.Budget, key KeyFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if budget.Limit <= 0 {
+			return next
+		}
+		return func(c echo.Context) error {
+			count, resetAt := l.store.Incr("rate:"+name+":"+key(c), budget.Window)
+			header := c.Response().Header()
+			header.Set("X-RateLimit-Limit", strconv.Itoa(budget.Limit))
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(max(budget.Limit-count, 0)))
+			if count > budget.Limit {
+				retryAfter := time.Until(resetAt)
+				header.Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				return utils.SplitErrorResponse(c, apperrors.NewRateLimitedError(retryAfter))
+			}
+			return next(c)
+		}
+	}
+}
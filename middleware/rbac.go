@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Permission string
+
+const (
+	PermWallet         Permission = "wallet"          // accounts, transfers, QR, bills, pockets and the rest of the money features
+	PermPlaceOrder     Permission = "orders:place"    // order food and follow the order
+	PermUpdateOrder    Permission = "orders:update"   // move an order along, the order handler still checks which transition
+	PermManageMerchant Permission = "merchant:manage" // own merchant profile and menu
+	PermManageDriver   Permission = "driver:manage"   // own driver profile, status and location
+	PermFindDrivers    Permission = "drivers:find"
+	PermManageUsers    Permission = "users:manage" // list and remove any user
+	PermReadAny        Permission = "records:read" // read orders and transactions of other users
+	PermReviewDrivers  Permission = "drivers:review"
+	PermFreezeUsers    Permission = "users:freeze"
+	PermReadAudit      Permission = "audit:read"
+)
+
+// rolePermissions is the single place that says which role may do what
+var rolePermissions = map[models.UserType][]Permission{
+	models.Consumer: {PermWallet, PermPlaceOrder, PermUpdateOrder, PermFindDrivers},
+	models.Driver:   {PermWallet, PermPlaceOrder, PermUpdateOrder, PermManageDriver},
+	models.Merchant: {PermWallet, PermPlaceOrder, PermUpdateOrder, PermManageMerchant, PermFindDrivers},
+	models.Admin:    {PermManageUsers, PermReadAny, PermFindDrivers, PermReviewDrivers, PermFreezeUsers, PermReadAudit},
+}
+
+func HasPermission(role models.UserType, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Require lets the request through when the role of the token has every permission,
+// it goes after the JWT middleware
+func Require(permissions ...Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			_, role := utils.GetIDAndRoleFromJWT(c)
+			for _, permission := range permissions {
+				if !HasPermission(models.UserType(role), permission) {
+					return utils.SplitErrorResponse(c, apperrors.ErrForbidden)
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// SelfOrPermission guards /users/:id style routes, the user in the path must be the caller
+// unless the caller's role has the permission
+func SelfOrPermission(param string, permission Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id, err := strconv.Atoi(c.Param(param))
+			if err != nil {
+				return utils.ValidationErrorResponse(c, err)
+			}
+			userID, role := utils.GetIDAndRoleFromJWT(c)
+			if id != userID && !HasPermission(models.UserType(role), permission) {
+				return utils.SplitErrorResponse(c, apperrors.ErrForbidden)
+			}
+			return next(c)
+		}
+	}
+}
+
+// Self is SelfOrPermission without an escape hatch, for changes only the user may make
+func Self(param string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id, err := strconv.Atoi(c.Param(param))
+			if err != nil {
+				return utils.ValidationErrorResponse(c, err)
+			}
+			if id != utils.CLaimJwt(c) {
+				return utils.SplitErrorResponse(c, apperrors.ErrForbidden)
+			}
+			return next(c)
+		}
+	}
+}
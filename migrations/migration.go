@@ -12,6 +12,7 @@ func RunMigration(db *config.Database) error {
 		&models.Account{},
 		&models.DriverProfile{},
 		&models.MerchantProfile{},
+		&models.MerchantLogo{},
 		&models.Contact{},
 		&models.QrCode{},
 		&models.Transaction{},
@@ -19,12 +20,74 @@ func RunMigration(db *config.Database) error {
 		&models.Order{},
 		&models.OrderItem{},
 		&models.Ride{},
+		&models.Budget{},
+		&models.Notification{},
+		&models.DeviceToken{},
+		&models.SplitBill{},
+		&models.PaymentRequest{},
+		&models.ScheduledTransfer{},
+		&models.ScheduledTransferRun{},
+		&models.Biller{},
+		&models.BillInquiry{},
+		&models.BillPayment{},
+		&models.AutoSaveRule{},
+		&models.PaymentPin{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.AuditLog{},
+		&models.PhoneOtp{},
+		&models.EmailToken{},
+		&models.TwoFactor{},
+		&models.RecoveryCode{},
+		&models.LoginChallenge{},
+		&models.MerchantApiKey{},
+		&models.MerchantRefund{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.WebhookAttempt{},
+		&models.OutboxEvent{},
+		&models.OutboxReceipt{},
 	}
 	fmt.Println("Running database migrations...")
 
+	hadInitialBalance := db.Migrator().HasColumn("accounts", "initial_balance")
 	if err := db.AutoMigrate(models...); err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
+	if !hadInitialBalance {
+		if err := backfillInitialBalances(db); err != nil {
+			return fmt.Errorf("backfilling initial balances failed: %w", err)
+		}
+	}
+	if err := SeedBillers(db); err != nil {
+		return fmt.Errorf("seeding billers failed: %w", err)
+	}
+	if err := SeedAdmin(db); err != nil {
+		return fmt.Errorf("seeding admin failed: %w", err)
+	}
+	if err := backfillDriverVerification(db); err != nil {
+		return fmt.Errorf("backfilling driver verification failed: %w", err)
+	}
 	fmt.Println("migration completed")
 	return nil
 }
+
+// backfillDriverVerification marks drivers verified before admin review existed as verified
+func backfillDriverVerification(db *config.Database) error {
+	return db.Model(&models.DriverProfile{}).
+		Where("is_verified = ? AND verification = ?", true, models.VerificationPending).
+		Update("verification", models.VerificationVerified).Error
+}
+
+// backfillInitialBalances works out what existing accounts were created with from their
+// current balance and transactions, the same movements statements count
+func backfillInitialBalances(db *config.Database) error {
+	return db.Exec(`UPDATE accounts SET initial_balance = balance - COALESCE((
+		SELECT SUM(CASE WHEN t.receiver_account_id = accounts.id THEN t.amount ELSE 0 END)
+			- SUM(CASE WHEN t.sender_account_id = accounts.id THEN t.amount ELSE 0 END)
+		FROM transactions t
+		WHERE (t.sender_account_id = accounts.id OR t.receiver_account_id = accounts.id)
+			AND t.status <> ?
+			AND NOT (t.is_hold AND t.status = ? AND t.receiver_account_id = accounts.id)
+	), 0)`, models.TransactionFailed, models.TransactionPending).Error
+}
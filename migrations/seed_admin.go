@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"fmt"
+	"gopay-clone/config"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+// SeedAdmin creates the first admin from ADMIN_EMAIL and ADMIN_PASSWORD, admins can not sign up
+// through the API. Nothing happens when the variables are unset or the email is taken.
+func SeedAdmin(db *config.Database) error {
+	email, password := os.Getenv("ADMIN_EMAIL"), os.Getenv("ADMIN_PASSWORD")
+	if email == "" || password == "" {
+		return nil
+	}
+
+	var existing models.User
+	err := db.Where("email = ?", email).First(&existing).Error
+	if err == nil {
+		if existing.Type != models.Admin {
+			return fmt.Errorf("ADMIN_EMAIL %s belongs to a %s user", email, existing.Type)
+		}
+		return nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	admin := models.User{Name: "Admin", Email: email, Type: models.Admin, Password: hashed}
+	return db.Create(&admin).Error
+}
@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"gopay-clone/config"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+
+	"gorm.io/gorm"
+)
+
+const billerSettlementEmail = "billers@goclone.internal"
+
+var billerCatalog = []models.Biller{
+	{Code: "PLN_POSTPAID", Name: "PLN Postpaid", Category: models.BillerElectricity, AdminFee: 2500},
+	{Code: "PLN_PREPAID", Name: "PLN Token", Category: models.BillerElectricity, Prepaid: true, MinAmount: 20000, MaxAmount: 5000000, AdminFee: 2500},
+	{Code: "PDAM_JAKARTA", Name: "PDAM Jakarta", Category: models.BillerWater, AdminFee: 2500},
+	{Code: "PDAM_SURABAYA", Name: "PDAM Surabaya", Category: models.BillerWater, AdminFee: 2500},
+	{Code: "INDIHOME", Name: "IndiHome", Category: models.BillerInternet, AdminFee: 2500},
+	{Code: "FIRST_MEDIA", Name: "First Media", Category: models.BillerInternet, AdminFee: 2500},
+	{Code: "TELKOMSEL_CREDIT", Name: "Telkomsel Credit", Category: models.BillerPhoneCredit, Prepaid: true, MinAmount: 5000, MaxAmount: 1000000, AdminFee: 1500},
+	{Code: "XL_CREDIT", Name: "XL Credit", Category: models.BillerPhoneCredit, Prepaid: true, MinAmount: 5000, MaxAmount: 1000000, AdminFee: 1500},
+}
+
+// SeedBillers makes sure the biller catalog and the account collecting bill payments exist.
+// Existing billers are left alone so fees changed in the database are kept.
+func SeedBillers(db *config.Database) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		settlement, err := billerSettlementAccount(tx)
+		if err != nil {
+			return err
+		}
+		for _, biller := range billerCatalog {
+			biller.Adapter = "simulated"
+			biller.IsActive = true
+			biller.SettlementAccountID = settlement.ID
+			if err := tx.Where(models.Biller{Code: biller.Code}).FirstOrCreate(&biller).Error; err != nil {
+				return fmt.Errorf("seeding biller %s: %w", biller.Code, err)
+			}
+		}
+		return nil
+	})
+}
+
+// the settlement user can not log in, its password is random and never stored anywhere
+func billerSettlementAccount(tx *gorm.DB) (*models.Account, error) {
+	var user models.User
+	err := tx.Where("email = ?", billerSettlementEmail).First(&user).Error
+	if err == gorm.ErrRecordNotFound {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		hashed, err := utils.HashPassword(hex.EncodeToString(secret))
+		if err != nil {
+			return nil, err
+		}
+		user = models.User{Name: "Bill Payments", Email: billerSettlementEmail, Type: models.Merchant, Password: hashed}
+		if err := tx.Create(&user).Error; err != nil {
+			return nil, fmt.Errorf("creating biller settlement user: %w", err)
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	account := models.Account{Name: "biller settlement", UserId: user.ID, AccountType: models.MainBalance}
+	if err := tx.Where(models.Account{UserId: user.ID, AccountType: models.MainBalance}).FirstOrCreate(&account).Error; err != nil {
+		return nil, fmt.Errorf("creating biller settlement account: %w", err)
+	}
+	return &account, nil
+}
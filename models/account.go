@@ -1,20 +1,28 @@
 package models
 
+import "time"
+
 type AccountType string
 
 const (
 	MainBalance AccountType = "main_balance"
 	Points      AccountType = "points"
+	Pocket      AccountType = "pocket" // savings pocket with an optional goal
 )
 
 type Account struct {
 	BaseModel
 	Name                 string        `json:"name" gorm:"not null"`
 	Balance              float64       `json:"balance" gorm:"default:0;check:balance >= 0"`
+	InitialBalance       float64       `json:"-" gorm:"default:0"` // the balance it was created with, statements count from here
 	UserId               uint          `json:"user_id" gorm:"not null;index:idx_user_id"`
 	AccountType          AccountType   `json:"account_type" gorm:"not null;default:main_balance"`
 	User                 User          `json:"-"`
 	SentTransactions     []Transaction `json:"sent_transactions,omitempty" gorm:"foreignKey:SenderAccountID"`
 	ReceivedTransactions []Transaction `json:"received_transactions,omitempty" gorm:"foreignKey:ReceiverAccountID"`
 	QRCodes              []QrCode      `json:"qr_codes,omitempty" gorm:"foreignKey:ReceiverAccountID"`
+	TargetAmount         *float64      `json:"target_amount,omitempty"`
+	TargetDate           *time.Time    `json:"target_date,omitempty"`
+	IsLocked             bool          `json:"is_locked" gorm:"default:false"` // locked accounts only pay out to the owner's own accounts
+	LockedUntil          *time.Time    `json:"locked_until,omitempty"`         // and not even that before this time
 }
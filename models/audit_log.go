@@ -0,0 +1,13 @@
+package models
+
+// AuditLog is written for every request an admin makes, nothing updates or deletes it
+type AuditLog struct {
+	BaseModel
+	ActorID    uint   `json:"actor_id" gorm:"not null;index"`
+	Action     string `json:"action" gorm:"not null;index"` // route, e.g. POST /api/v1/admin/drivers/:driver_id/verify
+	TargetType string `json:"target_type,omitempty" gorm:"index:idx_audit_target"`
+	TargetID   uint   `json:"target_id,omitempty" gorm:"index:idx_audit_target"`
+	Reason     string `json:"reason,omitempty"`
+	StatusCode int    `json:"status_code"`
+	IPAddress  string `json:"ip_address"`
+}
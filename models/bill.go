@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+type BillerCategory string
+
+const (
+	BillerElectricity BillerCategory = "electricity"
+	BillerWater       BillerCategory = "water"
+	BillerInternet    BillerCategory = "internet"
+	BillerPhoneCredit BillerCategory = "phone_credit"
+)
+
+// Biller is one entry of the catalog. Adapter names the Biller implementation that talks to it.
+type Biller struct {
+	BaseModel
+	Code                string         `json:"code" gorm:"uniqueIndex;not null"`
+	Name                string         `json:"name" gorm:"not null"`
+	Category            BillerCategory `json:"category" gorm:"not null;index:idx_biller_category"`
+	Adapter             string         `json:"-" gorm:"not null;default:simulated"`
+	Prepaid             bool           `json:"prepaid"` // the customer picks the amount, e.g. phone credit or electricity tokens
+	MinAmount           float64        `json:"min_amount,omitempty"`
+	MaxAmount           float64        `json:"max_amount,omitempty"`
+	AdminFee            float64        `json:"admin_fee"`
+	SettlementAccountID uint           `json:"-" gorm:"not null"` // where the money collected for this biller goes
+	IsActive            bool           `json:"is_active" gorm:"default:true"`
+}
+
+type BillInquiryStatus string
+
+const (
+	BillInquiryOpen       BillInquiryStatus = "open"
+	BillInquiryProcessing BillInquiryStatus = "processing" // payment sent to the biller
+	BillInquiryPaid       BillInquiryStatus = "paid"
+)
+
+// BillInquiry holds what the biller said is owed, a payment always pays one inquiry
+type BillInquiry struct {
+	BaseModel
+	UserID         uint              `json:"user_id" gorm:"not null;index:idx_bill_inquiry_user"`
+	BillerID       uint              `json:"biller_id" gorm:"not null"`
+	Biller         Biller            `json:"biller"`
+	CustomerNumber string            `json:"customer_number" gorm:"not null"`
+	CustomerName   string            `json:"customer_name"`
+	Period         string            `json:"period,omitempty"` // billing period, empty for prepaid
+	Amount         float64           `json:"amount"`
+	AdminFee       float64           `json:"admin_fee"`
+	Total          float64           `json:"total"`
+	BillerRef      string            `json:"biller_ref"`
+	Status         BillInquiryStatus `json:"status" gorm:"default:open"`
+	ExpiresAt      time.Time         `json:"expires_at"`
+}
+
+type BillPaymentStatus string
+
+const (
+	BillPaymentPending BillPaymentStatus = "pending"
+	BillPaymentSuccess BillPaymentStatus = "success"
+	BillPaymentFailed  BillPaymentStatus = "failed" // the biller refused, the money was returned
+)
+
+// BillPayment is the receipt of a paid inquiry
+type BillPayment struct {
+	BaseModel
+	UserID         uint              `json:"user_id" gorm:"not null;index:idx_bill_payment_user"`
+	InquiryID      uint              `json:"inquiry_id" gorm:"not null;index:idx_bill_payment_inquiry"`
+	BillerID       uint              `json:"biller_id" gorm:"not null"`
+	Biller         Biller            `json:"biller"`
+	CustomerNumber string            `json:"customer_number"`
+	CustomerName   string            `json:"customer_name"`
+	Period         string            `json:"period,omitempty"`
+	Amount         float64           `json:"amount"`
+	AdminFee       float64           `json:"admin_fee"`
+	Total          float64           `json:"total"`
+	Status         BillPaymentStatus `json:"status" gorm:"default:pending;index:idx_bill_payment_status"`
+	TransactionID  uint              `json:"transaction_id"`
+	Transaction    *Transaction      `json:"transaction,omitempty" gorm:"foreignKey:TransactionID"`
+	ReceiptNumber  string            `json:"receipt_number,omitempty"`
+	Token          string            `json:"token,omitempty"` // prepaid electricity token
+	FailureReason  string            `json:"failure_reason,omitempty"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+type Budget struct {
+	BaseModel
+	UserID        uint                `json:"user_id" gorm:"not null;uniqueIndex:idx_budget_user_category"`
+	User          User                `json:"-"`
+	Category      TransactionCategory `json:"category" gorm:"not null;uniqueIndex:idx_budget_user_category"`
+	MonthlyLimit  float64             `json:"monthly_limit" gorm:"not null;check:monthly_limit > 0"`
+	WarnAtPercent int                 `json:"warn_at_percent" gorm:"not null;default:80"` // warn before actually going over
+}
+
+// BudgetStatus is a budget with what has been spent against it this month
+type BudgetStatus struct {
+	Budget
+	Spent       float64 `json:"spent"`
+	Remaining   float64 `json:"remaining"`
+	UsedPercent float64 `json:"used_percent"`
+	OverBudget  bool    `json:"over_budget"`
+}
+
+// BudgetCheck answers whether paying an amount in a category would break the budget
+type BudgetCheck struct {
+	Category    TransactionCategory `json:"category"`
+	HasBudget   bool                `json:"has_budget"`
+	Amount      float64             `json:"amount"`
+	SpentBefore float64             `json:"spent_before"`
+	SpentAfter  float64             `json:"spent_after"`
+	Limit       float64             `json:"limit,omitempty"`
+	WarnAt      float64             `json:"warn_at,omitempty"`
+	Warning     bool                `json:"warning"`     // crosses warn_at_percent
+	OverBudget  bool                `json:"over_budget"` // crosses monthly_limit
+}
+
+type SpendingGroup string
+
+const (
+	SpendingByCategory SpendingGroup = "category"
+	SpendingByMerchant SpendingGroup = "merchant"
+	SpendingByMonth    SpendingGroup = "month"
+)
+
+type SpendingItem struct {
+	Key     string  `json:"key"`
+	Label   string  `json:"label"`
+	Total   float64 `json:"total"`
+	Count   int64   `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+type SpendingReport struct {
+	UserID  uint           `json:"user_id"`
+	GroupBy SpendingGroup  `json:"group_by"`
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	Total   float64        `json:"total"`
+	Items   []SpendingItem `json:"items"`
+}
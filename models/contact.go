@@ -1,9 +1,32 @@
 package models
 
+import "time"
+
 type Contact struct {
 	BaseModel
-	OwnerID  uint `json:"owner_id" gorm:"not null"`
-	Owner    User `json:"owner" gorm:"foreignKey:OwnerID"`
-	TargetID uint `json:"target_id" gorm:"not null"`
-	Target   User `json:"target" gorm:"foreignKey:TargetID"`
+	OwnerID     uint   `json:"owner_id" gorm:"not null;uniqueIndex:idx_contact_owner_target"`
+	Owner       User   `json:"-" gorm:"foreignKey:OwnerID"`
+	TargetID    uint   `json:"target_id" gorm:"not null;uniqueIndex:idx_contact_owner_target"`
+	Target      User   `json:"target" gorm:"foreignKey:TargetID"`
+	Nickname    string `json:"nickname,omitempty"`
+	IsFavourite bool   `json:"is_favourite" gorm:"default:false;index:idx_contact_favourite"`
+}
+
+// UserLookup is the little we show about a registered user before they are added as a contact
+type UserLookup struct {
+	ID                uint     `json:"id"`
+	Name              string   `json:"name"`
+	Type              UserType `json:"user_type"`
+	ProfilePictureURL string   `json:"profile_picture_url"`
+	IsContact         bool     `json:"is_contact"`
+}
+
+// RecentCounterparty is someone the user recently sent money to or received money from
+type RecentCounterparty struct {
+	UserID            uint      `json:"user_id"`
+	Name              string    `json:"name"`
+	ProfilePictureURL string    `json:"profile_picture_url"`
+	LastTransactionAt time.Time `json:"last_transaction_at"`
+	TransactionCount  int64     `json:"transaction_count"`
+	ContactID         *uint     `json:"contact_id,omitempty"`
 }
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type EmailTokenPurpose string
+
+const (
+	EmailVerify   EmailTokenPurpose = "verify_email"
+	PasswordReset EmailTokenPurpose = "reset_password"
+)
+
+// EmailToken is a link sent by mail, only the hash is kept and a token works once
+type EmailToken struct {
+	BaseModel
+	UserID    uint              `json:"user_id" gorm:"not null;index"`
+	Purpose   EmailTokenPurpose `json:"purpose" gorm:"not null;index"`
+	Email     string            `json:"email"` // the address it was sent to, a changed email voids the token
+	TokenHash string            `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	UsedAt    *time.Time        `json:"used_at,omitempty"`
+}
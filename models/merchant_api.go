@@ -0,0 +1,77 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+type ApiScope string
+
+const (
+	ScopeQrWrite      ApiScope = "qr:write"      // create dynamic QR payment requests
+	ScopePaymentsRead ApiScope = "payments:read" // query the status of a payment
+	ScopeRefundsWrite ApiScope = "refunds:write" // refund a payment, fully or in part
+)
+
+var ApiScopes = []ApiScope{ScopeQrWrite, ScopePaymentsRead, ScopeRefundsWrite}
+
+// MerchantApiKey lets a merchant backend call the merchant API without a user login.
+// Requests are signed with the secret, which is stored sealed with the server's encryption key
+// so the row alone can't sign requests.
+type MerchantApiKey struct {
+	BaseModel
+	MerchantID           uint       `json:"merchant_id" gorm:"not null;index"`
+	Name                 string     `json:"name"`
+	KeyID                string     `json:"key_id" gorm:"not null;uniqueIndex"`
+	SealedSecret         string     `json:"-" gorm:"not null"` // see utils.SealSecret
+	PreviousSealedSecret string     `json:"-"`
+	PreviousValidUntil   *time.Time `json:"previous_valid_until,omitempty"` // the secret before the last rotation works until then
+	Scopes               string     `json:"scopes"`                         // comma separated ApiScope values
+	LastUsedAt           *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt            *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (k *MerchantApiKey) HasScope(scope ApiScope) bool {
+	for _, s := range strings.Split(k.Scopes, ",") {
+		if ApiScope(s) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// MerchantApiCredentials is the only time the secret is shown
+type MerchantApiCredentials struct {
+	Key    MerchantApiKey `json:"key"`
+	Secret string         `json:"secret"`
+}
+
+// MerchantRefund sends (part of) a payment back to the payer, Reference is the merchant's own id for it
+type MerchantRefund struct {
+	BaseModel
+	MerchantID          uint    `json:"merchant_id" gorm:"not null;index"`
+	TransactionID       uint    `json:"transaction_id" gorm:"not null;index"` // the payment refunded
+	RefundTransactionID uint    `json:"refund_transaction_id" gorm:"not null"`
+	Amount              float64 `json:"amount" gorm:"not null"`
+	Reason              string  `json:"reason,omitempty"`
+	Reference           string  `json:"reference,omitempty" gorm:"index"`
+}
+
+type MerchantPaymentStatus string
+
+const (
+	MerchantPaymentPending           MerchantPaymentStatus = "pending"
+	MerchantPaymentPaid              MerchantPaymentStatus = "paid"
+	MerchantPaymentExpired           MerchantPaymentStatus = "expired"
+	MerchantPaymentPartiallyRefunded MerchantPaymentStatus = "partially_refunded"
+	MerchantPaymentRefunded          MerchantPaymentStatus = "refunded"
+)
+
+// MerchantPayment is a dynamic QR as the merchant API sees it, not a table
+type MerchantPayment struct {
+	QrCode         QrCode                `json:"qr_code"`
+	Status         MerchantPaymentStatus `json:"status"`
+	Transaction    *Transaction          `json:"transaction,omitempty"`
+	RefundedAmount float64               `json:"refunded_amount"`
+	Refunds        []MerchantRefund      `json:"refunds,omitempty"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+type NotificationType string
+
+const (
+	NotificationBudgetWarning    NotificationType = "budget_warning"
+	NotificationBudgetExceeded   NotificationType = "budget_exceeded"
+	NotificationPaymentRequest   NotificationType = "payment_request"
+	NotificationRequestPaid      NotificationType = "payment_request_paid"
+	NotificationRequestDenied    NotificationType = "payment_request_declined"
+	NotificationScheduleFailed   NotificationType = "scheduled_transfer_failed"
+	NotificationTransferReceived NotificationType = "transfer_received"
+	NotificationOrderStatus      NotificationType = "order_status"
+	NotificationDriverAssigned   NotificationType = "driver_assigned"
+	NotificationRide             NotificationType = "ride"
+	NotificationLowBalance       NotificationType = "low_balance"
+)
+
+type Notification struct {
+	BaseModel
+	UserID        uint             `json:"user_id" gorm:"not null;index:idx_notification_user;uniqueIndex:idx_notification_event,priority:1"`
+	User          User             `json:"-"`
+	Type          NotificationType `json:"type" gorm:"not null;index:idx_notification_type;uniqueIndex:idx_notification_event,priority:3"`
+	Title         string           `json:"title" gorm:"not null"`
+	Body          string           `json:"body"`
+	ReadAt        *time.Time       `json:"read_at,omitempty"`
+	SourceEventID *uint            `json:"-" gorm:"uniqueIndex:idx_notification_event,priority:2"` // the outbox event it was made for, an event handed over again makes no second one
+}
+
+type UnreadCount struct {
+	Unread int64 `json:"unread"`
+}
+
+type DevicePlatform string
+
+const (
+	PlatformAndroid DevicePlatform = "android"
+	PlatformIOS     DevicePlatform = "ios"
+	PlatformWeb     DevicePlatform = "web"
+)
+
+// DeviceToken is where push messages for a user go, a token belongs to one user at a time
+type DeviceToken struct {
+	BaseModel
+	UserID     uint           `json:"user_id" gorm:"not null;index"`
+	Token      string         `json:"token" gorm:"not null;uniqueIndex"`
+	Platform   DevicePlatform `json:"platform" gorm:"not null"`
+	LastSeenAt time.Time      `json:"last_seen_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type OtpPurpose string
+
+const (
+	OtpRegister    OtpPurpose = "register"     // prove the phone before signing up
+	OtpLogin       OtpPurpose = "login"        // log in with phone and code instead of a password
+	OtpVerifyPhone OtpPurpose = "verify_phone" // verify the phone of an existing user
+	OtpPin         OtpPurpose = "pin"          // step-up for changing or resetting the payment PIN
+	OtpNewDevice   OtpPurpose = "new_device"   // step-up for a password login from a new device
+)
+
+// PhoneOtp is one code sent to a phone, only the hash is kept
+type PhoneOtp struct {
+	BaseModel
+	Phone      string     `json:"phone" gorm:"not null;index:idx_otp_phone_purpose"`
+	Purpose    OtpPurpose `json:"purpose" gorm:"not null;index:idx_otp_phone_purpose"`
+	CodeHash   string     `json:"-" gorm:"not null"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Attempts   int        `json:"attempts" gorm:"default:0"`
+	ConsumedAt *time.Time `json:"consumed_at,omitempty"`
+}
+
+type OtpChallenge struct {
+	Phone       string     `json:"phone"` // masked
+	Purpose     OtpPurpose `json:"purpose"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	ResendAfter time.Time  `json:"resend_after"`
+}
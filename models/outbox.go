@@ -0,0 +1,97 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventOrderPlaced        EventType = "order.placed"
+	EventOrderStatusChanged EventType = "order.status_changed"
+	EventPaymentCompleted   EventType = "payment.completed" // a QR was paid
+	EventPaymentRefunded    EventType = "payment.refunded"  // a merchant refunded (part of) a payment
+	EventTransactionCreated EventType = "transaction.created"
+	EventRideCompleted      EventType = "ride.completed"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxProcessed OutboxStatus = "processed" // every subscriber handled it
+	OutboxFailed    OutboxStatus = "failed"    // out of attempts, some subscriber never handled it
+)
+
+// OutboxEvent is a domain event written in the same db transaction as the change it describes,
+// the outbox dispatcher hands it to the subscribers afterwards
+type OutboxEvent struct {
+	BaseModel
+	Type          EventType    `json:"type" gorm:"not null;index"`
+	AggregateID   uint         `json:"aggregate_id" gorm:"index"` // the order, QR, transaction... the event is about
+	Payload       string       `json:"payload" gorm:"type:text;not null"`
+	Status        OutboxStatus `json:"status" gorm:"default:pending;index:idx_outbox_due,priority:1"`
+	Attempts      int          `json:"attempts"`
+	NextAttemptAt time.Time    `json:"next_attempt_at" gorm:"index:idx_outbox_due,priority:2"`
+	ProcessedAt   *time.Time   `json:"processed_at,omitempty"`
+	Error         string       `json:"error,omitempty"` // of the last attempt
+}
+
+// Decode reads the payload into the event data struct of the type
+func (e *OutboxEvent) Decode(v any) error {
+	return json.Unmarshal([]byte(e.Payload), v)
+}
+
+// OutboxReceipt records that a subscriber handled an event, a retry of the event skips it
+type OutboxReceipt struct {
+	BaseModel
+	EventID    uint   `json:"event_id" gorm:"not null;uniqueIndex:idx_outbox_receipt"`
+	Subscriber string `json:"subscriber" gorm:"not null;uniqueIndex:idx_outbox_receipt"`
+}
+
+// OrderEvent is the data of order.placed and order.status_changed
+type OrderEvent struct {
+	OrderID        uint        `json:"order_id"`
+	UserID         uint        `json:"user_id"`
+	MerchantID     uint        `json:"merchant_id"`
+	DriverID       *uint       `json:"driver_id,omitempty"`
+	Status         OrderStatus `json:"status"`
+	PreviousStatus OrderStatus `json:"previous_status,omitempty"`
+	TotalAmount    float64     `json:"total_amount"`
+	DeliveryFee    float64     `json:"delivery_fee"`
+}
+
+// PaymentEvent is the data of payment.completed
+type PaymentEvent struct {
+	QrCodeID          uint      `json:"qr_code_id"`
+	MerchantID        *uint     `json:"merchant_id,omitempty"` // set for merchant QRs
+	Reference         string    `json:"reference,omitempty"`
+	TransactionID     uint      `json:"transaction_id"`
+	SenderAccountID   uint      `json:"sender_account_id"`
+	ReceiverAccountID uint      `json:"receiver_account_id"`
+	Amount            float64   `json:"amount"`
+	PaidAt            time.Time `json:"paid_at"`
+}
+
+// TransactionEvent is the data of transaction.created, sent for every balance movement
+type TransactionEvent struct {
+	TransactionID     uint                `json:"transaction_id"`
+	Type              TransactionType     `json:"type"`
+	Status            TransactionStatus   `json:"status"`
+	Category          TransactionCategory `json:"category"`
+	Amount            float64             `json:"amount"`
+	SenderAccountID   uint                `json:"sender_account_id"`
+	ReceiverAccountID uint                `json:"receiver_account_id"`
+	SenderBalance     float64             `json:"sender_balance"` // right after the transaction
+}
+
+// RideEvent is the data of ride events
+type RideEvent struct {
+	RideID        uint       `json:"ride_id"`
+	UserID        uint       `json:"user_id"`
+	DriverID      *uint      `json:"driver_id,omitempty"`
+	Status        RideStatus `json:"status"`
+	Fare          float64    `json:"fare"`
+	TransactionID *uint      `json:"transaction_id,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// PaymentPin is the hashed 6 digit PIN that authorises payments of one user
+type PaymentPin struct {
+	BaseModel
+	UserID         uint       `json:"user_id" gorm:"uniqueIndex;not null"`
+	User           User       `json:"-"`
+	PinHash        string     `json:"-" gorm:"not null"`
+	FailedAttempts int        `json:"-"`
+	LockedUntil    *time.Time `json:"-"`
+}
+
+// PinStatus is what the user may know about their PIN
+type PinStatus struct {
+	IsSet         bool       `json:"is_set"`
+	RequiredAbove float64    `json:"required_above"`
+	AttemptsLeft  int        `json:"attempts_left"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+	ChangedAt     *time.Time `json:"changed_at,omitempty"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+type PaymentRequestStatus string
+
+const (
+	PaymentRequestPending   PaymentRequestStatus = "pending"
+	PaymentRequestPaid      PaymentRequestStatus = "paid"
+	PaymentRequestDeclined  PaymentRequestStatus = "declined"
+	PaymentRequestCancelled PaymentRequestStatus = "cancelled"
+)
+
+// PaymentRequest asks one payer for money, paying it creates a normal transfer to the requester's main balance
+type PaymentRequest struct {
+	BaseModel
+	RequesterID   uint                 `json:"requester_id" gorm:"not null;index:idx_payment_request_requester"`
+	Requester     User                 `json:"requester" gorm:"foreignKey:RequesterID"`
+	PayerID       uint                 `json:"payer_id" gorm:"not null;index:idx_payment_request_payer"`
+	Payer         User                 `json:"payer" gorm:"foreignKey:PayerID"`
+	Amount        float64              `json:"amount" gorm:"not null"`
+	Note          string               `json:"note,omitempty"`
+	Category      TransactionCategory  `json:"category" gorm:"default:transfer"`
+	Status        PaymentRequestStatus `json:"status" gorm:"default:pending;index:idx_payment_request_status"`
+	SplitBillID   *uint                `json:"split_bill_id,omitempty" gorm:"index:idx_payment_request_split_bill"`
+	TransactionID *uint                `json:"transaction_id,omitempty"`
+	Transaction   *Transaction         `json:"transaction,omitempty" gorm:"foreignKey:TransactionID"`
+	RespondedAt   *time.Time           `json:"responded_at,omitempty"` // when it was paid, declined or cancelled
+}
+
+type SplitMode string
+
+const (
+	SplitEven   SplitMode = "even"
+	SplitCustom SplitMode = "custom"
+)
+
+type SplitBillStatus string
+
+const (
+	SplitBillOpen      SplitBillStatus = "open"
+	SplitBillSettled   SplitBillStatus = "settled" // every participant paid
+	SplitBillCancelled SplitBillStatus = "cancelled"
+)
+
+// SplitBill divides a total across participants, each participant gets one PaymentRequest
+type SplitBill struct {
+	BaseModel
+	OwnerID     uint                `json:"owner_id" gorm:"not null;index:idx_split_bill_owner"`
+	Owner       User                `json:"-" gorm:"foreignKey:OwnerID"`
+	Title       string              `json:"title" gorm:"not null"`
+	TotalAmount float64             `json:"total_amount" gorm:"not null"`
+	OwnerShare  float64             `json:"owner_share"` // what the owner covers themselves
+	Mode        SplitMode           `json:"mode" gorm:"not null"`
+	Category    TransactionCategory `json:"category" gorm:"default:other"`
+	OrderID     *uint               `json:"order_id,omitempty" gorm:"index:idx_split_bill_order"`
+	Status      SplitBillStatus     `json:"status" gorm:"default:open;index:idx_split_bill_status"`
+	Requests    []PaymentRequest    `json:"requests,omitempty" gorm:"foreignKey:SplitBillID"`
+	Collected   float64             `json:"collected" gorm:"-"` // sum of the paid requests
+}
+
+// SplitShare is one participant of a split bill, Amount is only used by custom splits
+type SplitShare struct {
+	ContactID uint
+	Amount    float64
+}
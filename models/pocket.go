@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// PocketStatus is a pocket with how far it is from its goal
+type PocketStatus struct {
+	Account
+	Remaining        float64 `json:"remaining"`
+	ProgressPercent  float64 `json:"progress_percent"`
+	Reached          bool    `json:"reached"`
+	DaysLeft         *int    `json:"days_left,omitempty"`
+	SuggestedMonthly float64 `json:"suggested_monthly,omitempty"` // to reach the target by the target date
+}
+
+type AutoSaveType string
+
+const (
+	AutoSaveRoundUp   AutoSaveType = "round_up"  // round every payment up and save the difference
+	AutoSaveScheduled AutoSaveType = "scheduled" // fixed amount through a scheduled transfer
+)
+
+type AutoSaveRule struct {
+	BaseModel
+	UserID          uint               `json:"user_id" gorm:"not null;index:idx_auto_save_user"`
+	User            User               `json:"-"`
+	PocketID        uint               `json:"pocket_id" gorm:"not null;index:idx_auto_save_pocket"`
+	SourceAccountID uint               `json:"source_account_id" gorm:"not null;index:idx_auto_save_source"`
+	Type            AutoSaveType       `json:"type" gorm:"not null"`
+	RoundTo         float64            `json:"round_to,omitempty"` // round_up only, e.g. 1000
+	ScheduleID      *uint              `json:"schedule_id,omitempty"`
+	Schedule        *ScheduledTransfer `json:"schedule,omitempty" gorm:"foreignKey:ScheduleID"`
+	IsActive        bool               `json:"is_active" gorm:"default:true"`
+	LastSavedAt     *time.Time         `json:"last_saved_at,omitempty"`
+}
@@ -2,12 +2,82 @@ package models
 
 import "time"
 
+// QrType tells single use QRs for an exact amount from reusable ones where the payer enters the amount
+type QrType string
+
+const (
+	QrDynamic QrType = "dynamic"
+	QrStatic  QrType = "static"
+)
+
 type QrCode struct {
 	BaseModel
-	ReceiverAccountID uint      `json:"receiver_account_id" gorm:"not null"`
-	ReceiverAccount   Account   `json:"-" gorm:"foreignKey:ReceiverAccountID"`
-	Amount            float64   `json:"amount" gorm:"not null"`
-	URL               string    `json:"url" gorm:"not null"`
-	IsUsed            bool      `json:"is_used" gorm:"default:false"`
-	ExpiresAt         time.Time `json:"expires_at"`
+	Type              QrType     `json:"type" gorm:"type:varchar(10);default:'dynamic';not null;index"`
+	ReceiverAccountID uint       `json:"receiver_account_id" gorm:"not null"`
+	ReceiverAccount   Account    `json:"-" gorm:"foreignKey:ReceiverAccountID"`
+	Amount            float64    `json:"amount,omitempty" gorm:"not null"`   // zero for static QRs
+	URL               string     `json:"url"`                                // optional deep link, Payload is what goes in the QR
+	Payload           string     `json:"payload" gorm:"type:text"`           // EMVCo merchant-presented payload, generated by the server
+	IsUsed            bool       `json:"is_used" gorm:"default:false"`       // only dynamic QRs are used up
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`               // static QRs don't expire
+	MerchantID        *uint      `json:"merchant_id,omitempty" gorm:"index"` // set for merchant QRs, static ones and those of the merchant API
+	Reference         string     `json:"reference,omitempty"`                // the merchant's own order id
+	Label             string     `json:"label,omitempty"`                    // where a static QR is put up, e.g. "Counter 1"
+	DisabledAt        *time.Time `json:"disabled_at,omitempty"`              // a disabled static QR can't be paid anymore
+}
+
+func (q *QrCode) IsStatic() bool {
+	return q.Type == QrStatic
+}
+
+// Expired is false for QRs without an expiry
+func (q *QrCode) Expired(now time.Time) bool {
+	return q.ExpiresAt != nil && q.ExpiresAt.Before(now)
+}
+
+// QrPaymentTotals sums the completed payments made with a QR
+type QrPaymentTotals struct {
+	Payments       int64      `json:"payments"`
+	TotalAmount    float64    `json:"total_amount"`
+	RefundedAmount float64    `json:"refunded_amount"` // through merchant refunds
+	LastPaidAt     *time.Time `json:"last_paid_at,omitempty"`
+}
+
+// QrCodeSummary is a QR with what was paid with it
+type QrCodeSummary struct {
+	QrCode
+	Totals QrPaymentTotals `json:"totals"`
+}
+
+// QrPayloadInfo is what a scanned payload says, resolved against our own QRs
+type QrPayloadInfo struct {
+	Static            bool     `json:"static"` // the payer enters the amount
+	MerchantName      string   `json:"merchant_name"`
+	MerchantCity      string   `json:"merchant_city"`
+	CategoryCode      string   `json:"merchant_category_code"`
+	Currency          string   `json:"currency"`
+	Amount            *float64 `json:"amount,omitempty"`
+	Reference         string   `json:"reference,omitempty"`
+	QrCodeID          uint     `json:"qr_code_id"`
+	ReceiverAccountID uint     `json:"receiver_account_id"`
+	ReceiverName      string   `json:"receiver_name"`
+	MerchantID        *uint    `json:"merchant_id,omitempty"`
+	Label             string   `json:"label,omitempty"`
+	Payable           bool     `json:"payable"` // false once a dynamic QR is used or expired, or a static one disabled
+}
+
+// QrImageOptions picks how a QR is rendered
+type QrImageOptions struct {
+	Format string // png or svg
+	Size   int    // edge in pixels
+	Level  string // error correction L, M, Q or H
+	Logo   bool   // draw the merchant logo in the middle, forces level H
+}
+
+// QrStandee is what goes on a printed counter standee
+type QrStandee struct {
+	MerchantName string
+	Location     string
+	QrCodeID     uint
+	Image        []byte // PNG of the QR
 }
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+type ScheduleFrequency string
+
+const (
+	ScheduleOnce    ScheduleFrequency = "once"
+	ScheduleDaily   ScheduleFrequency = "daily"
+	ScheduleWeekly  ScheduleFrequency = "weekly"
+	ScheduleMonthly ScheduleFrequency = "monthly"
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleActive    ScheduleStatus = "active"
+	SchedulePaused    ScheduleStatus = "paused"
+	ScheduleCompleted ScheduleStatus = "completed" // no occurrences left
+	ScheduleCancelled ScheduleStatus = "cancelled"
+	ScheduleFailed    ScheduleStatus = "failed" // stopped because it can never succeed, e.g. an account is gone
+)
+
+// ScheduledTransfer moves Amount on StartAt and then every Interval days, weeks or months
+type ScheduledTransfer struct {
+	BaseModel
+	UserID            uint                `json:"user_id" gorm:"not null;index:idx_schedule_user"`
+	User              User                `json:"-"`
+	SenderAccountID   uint                `json:"sender_account_id" gorm:"not null"`
+	ReceiverAccountID uint                `json:"receiver_account_id" gorm:"not null"`
+	Amount            float64             `json:"amount" gorm:"not null"`
+	Description       string              `json:"description,omitempty"`
+	Category          TransactionCategory `json:"category" gorm:"default:transfer"`
+	Frequency         ScheduleFrequency   `json:"frequency" gorm:"not null"`
+	Interval          int                 `json:"interval" gorm:"not null;default:1"` // every n days, weeks or months
+	StartAt           time.Time           `json:"start_at" gorm:"not null"`
+	EndAt             *time.Time          `json:"end_at,omitempty"`
+	MaxRuns           *int                `json:"max_runs,omitempty"` // how many occurrences at most
+	Timezone          string              `json:"timezone" gorm:"type:varchar(64);not null;default:'Asia/Jakarta'"`
+	Status            ScheduleStatus      `json:"status" gorm:"default:active;index:idx_schedule_status"`
+	Occurrence        int                 `json:"occurrence"` // index of the occurrence NextRunAt points at
+	NextRunAt         time.Time           `json:"next_run_at" gorm:"index:idx_schedule_next_run"`
+	RetryAt           *time.Time          `json:"retry_at,omitempty" gorm:"index:idx_schedule_retry"` // set while an occurrence waits for balance
+	Attempts          int                 `json:"attempts"`                                           // attempts made for the current occurrence
+	SuccessCount      int                 `json:"success_count"`
+	LastRunAt         *time.Time          `json:"last_run_at,omitempty"`
+}
+
+type ScheduleRunStatus string
+
+const (
+	ScheduleRunSuccess  ScheduleRunStatus = "success"
+	ScheduleRunRetrying ScheduleRunStatus = "retrying" // failed, another attempt is planned
+	ScheduleRunFailed   ScheduleRunStatus = "failed"
+)
+
+// ScheduledTransferRun records one attempt of one occurrence. It is written in the same db
+// transaction as the transfer, the unique index is a last guard against paying an attempt twice.
+type ScheduledTransferRun struct {
+	BaseModel
+	ScheduleID    uint              `json:"schedule_id" gorm:"not null;uniqueIndex:idx_schedule_run_attempt"`
+	ScheduledFor  time.Time         `json:"scheduled_for" gorm:"not null;uniqueIndex:idx_schedule_run_attempt"`
+	Attempt       int               `json:"attempt" gorm:"not null;uniqueIndex:idx_schedule_run_attempt"`
+	Status        ScheduleRunStatus `json:"status" gorm:"not null"`
+	TransactionID *uint             `json:"transaction_id,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	FinishedAt    *time.Time        `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Session is one login, every refresh token issued after it belongs to the same family
+type Session struct {
+	BaseModel
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	DeviceID     string     `json:"device_id" gorm:"index"` // SHA-256 of the device token
+	DeviceName   string     `json:"device_name"`
+	UserAgent    string     `json:"user_agent"`
+	IP           string     `json:"ip"`
+	LastSeenAt   time.Time  `json:"last_seen_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokeReason string     `json:"revoke_reason,omitempty"`
+	Current      bool       `json:"current" gorm:"-"` // the session of the token asking
+}
+
+// DeviceInfo is what a login tells about the client
+type DeviceInfo struct {
+	Token     string // X-Device-Token, only counts when the server issued it to the user before
+	Name      string
+	UserAgent string
+	IP        string
+}
+
+// RefreshToken only keeps the hash, a token is spent once it has been rotated
+type RefreshToken struct {
+	BaseModel
+	SessionID uint       `json:"session_id" gorm:"not null;index"`
+	Session   Session    `json:"-"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+type TokenPair struct {
+	Token            string    `json:"token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	DeviceToken      string    `json:"device_token,omitempty"` // on login, send it as X-Device-Token next time
+}
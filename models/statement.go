@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Statement is generated on request from the transaction history, it is not stored
+type Statement struct {
+	AccountID      uint            `json:"account_id"`
+	AccountName    string          `json:"account_name"`
+	AccountType    AccountType     `json:"account_type"`
+	HolderName     string          `json:"holder_name"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	OpeningBalance float64         `json:"opening_balance"`
+	TotalIn        float64         `json:"total_in"`
+	TotalOut       float64         `json:"total_out"`
+	ClosingBalance float64         `json:"closing_balance"`
+	Lines          []StatementLine `json:"lines"`
+
+	// the opening balance is summed from the transactions before the period, so
+	// closing_balance + net_after_period has to come out at the live Account.Balance.
+	// Discrepancy is what it misses by, money that moved without a transaction behind it.
+	NetAfterPeriod float64   `json:"net_after_period"`
+	CurrentBalance float64   `json:"current_balance"`
+	Reconciled     bool      `json:"reconciled"`
+	Discrepancy    float64   `json:"discrepancy"`
+	GeneratedAt    time.Time `json:"generated_at"`
+}
+
+type StatementLine struct {
+	Date          time.Time            `json:"date"`
+	TransactionID uint                 `json:"transaction_id"`
+	Description   string               `json:"description"`
+	Counterparty  string               `json:"counterparty"`
+	Type          TransactionType      `json:"type"`
+	Category      TransactionCategory  `json:"category"`
+	Direction     TransactionDirection `json:"direction"`
+	Amount        float64              `json:"amount"` // signed
+	Balance       float64              `json:"balance"`
+}
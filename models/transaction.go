@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type TransactionType string
 type TransactionCategory string
 type TransactionStatus string
@@ -10,6 +12,7 @@ const (
 	Transfer TransactionType = "transfer"
 	Topup    TransactionType = "topup"
 	Cashback TransactionType = "cashback"
+	Refund   TransactionType = "refund"
 )
 
 const (
@@ -36,17 +39,53 @@ const (
 
 type Transaction struct {
 	BaseModel
-	Amount            float64             `json:"amount" gorm:"not null;check:amount > 0"`
-	SenderAccountID   uint                `json:"sender_account_id" gorm:"not null;index:idx_sender_receiver;priority:1"`
-	SenderAccount     Account             `json:"-" gorm:"foreignKey:SenderAccountID"`
-	ReceiverAccountID uint                `json:"receiver_account_id" gorm:"not null;index:idx_sender_receiver,priority:2"`
-	ReceiverAccount   Account             `json:"-" gorm:"foreignKey:ReceiverAccountID"`
-	Category          TransactionCategory `json:"category" gorm:"default:other;index:idx_category"`
-	Type              TransactionType     `json:"type" gorm:"default:payment;index:idx_type"`
-	Status            TransactionStatus   `json:"status" gorm:"default:pending;index:idx_status"`
-	QrCodeID          *uint               `json:"qr_code_id,omitempty"`
-	QrCode            *QrCode             `json:"qr_code,omitempty" gorm:"foreignKey:QrCodeID"`
-	Description       string              `json:"description,omitempty"`
-	ServiceType       ServiceType         `json:"service_type" gorm:"default:none;index:idx_service_type"`
-	ServiceID         *uint               `json:"service_id,omitempty" gorm:"index:idx_service_id"` // optional because it might be just a transfer // this could be ride.id, order.id (comes from food)
+	Amount              float64             `json:"amount" gorm:"not null;check:amount > 0"`
+	SenderAccountID     uint                `json:"sender_account_id" gorm:"not null;index:idx_sender_receiver;priority:1"`
+	SenderAccount       Account             `json:"-" gorm:"foreignKey:SenderAccountID"`
+	ReceiverAccountID   uint                `json:"receiver_account_id" gorm:"not null;index:idx_sender_receiver,priority:2"`
+	ReceiverAccount     Account             `json:"-" gorm:"foreignKey:ReceiverAccountID"`
+	Category            TransactionCategory `json:"category" gorm:"default:other;index:idx_category"`
+	Type                TransactionType     `json:"type" gorm:"default:payment;index:idx_type"`
+	Status              TransactionStatus   `json:"status" gorm:"default:pending;index:idx_status"`
+	QrCodeID            *uint               `json:"qr_code_id,omitempty"`
+	QrCode              *QrCode             `json:"qr_code,omitempty" gorm:"foreignKey:QrCodeID"`
+	Description         string              `json:"description,omitempty"`
+	ServiceType         ServiceType         `json:"service_type" gorm:"default:none;index:idx_service_type"`
+	ServiceID           *uint               `json:"service_id,omitempty" gorm:"index:idx_service_id"` // optional because it might be just a transfer // this could be ride.id, order.id (comes from food)
+	ParentTransactionID *uint               `json:"parent_transaction_id,omitempty" gorm:"index"`     // the payment a round-up saving was made for
+	IsHold              bool                `json:"is_hold,omitempty" gorm:"default:false"`           // taken from the sender, the receiver is only paid when it settles
+}
+
+type TransactionDirection string
+
+const (
+	DirectionIn  TransactionDirection = "in"
+	DirectionOut TransactionDirection = "out"
+)
+
+// TransactionHistoryItem is a transaction seen from one account, not a table
+type TransactionHistoryItem struct {
+	Transaction
+	Direction             TransactionDirection `json:"direction"`
+	SignedAmount          float64              `json:"signed_amount"` // negative when money left the account
+	CounterpartyAccountID uint                 `json:"counterparty_account_id"`
+	CounterpartyName      string               `json:"counterparty_name"`
+}
+
+// TransactionPeriodSummary is the money in/out of an account for one day, week or month
+type TransactionPeriodSummary struct {
+	Period   time.Time `json:"period"`
+	TotalIn  float64   `json:"total_in"`
+	TotalOut float64   `json:"total_out"`
+	Net      float64   `json:"net"`
+	Count    int64     `json:"count"`
+}
+
+type TransactionSummary struct {
+	AccountID uint                       `json:"account_id"`
+	Period    string                     `json:"period"`
+	TotalIn   float64                    `json:"total_in"`
+	TotalOut  float64                    `json:"total_out"`
+	Net       float64                    `json:"net"`
+	Periods   []TransactionPeriodSummary `json:"periods"`
 }
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// TwoFactor holds the TOTP secret of a user, it only counts once EnabledAt is set
+type TwoFactor struct {
+	BaseModel
+	UserID       uint       `json:"user_id" gorm:"not null;uniqueIndex"`
+	Secret       string     `json:"-" gorm:"not null"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty"`
+	LastUsedStep int64      `json:"-"` // a code is accepted once, codes of this step or earlier are refused
+}
+
+// RecoveryCode stands in for the authenticator once, only the hash is kept
+type RecoveryCode struct {
+	BaseModel
+	UserID   uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash string     `json:"-" gorm:"not null"`
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+}
+
+// LoginChallenge is what a correct password buys when 2FA is on, the code finishes the login
+type LoginChallenge struct {
+	BaseModel
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	Attempts  int        `json:"attempts" gorm:"default:0"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+type TwoFactorEnrolment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+type RecoveryCodes struct {
+	Codes []string `json:"codes"` // shown once, store them somewhere safe
+}
+
+// TwoFactorChallenge is returned by login instead of tokens when 2FA is on
+type TwoFactorChallenge struct {
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
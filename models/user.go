@@ -1,8 +1,11 @@
 package models
 
+import "time"
+
 type VehicleType string
 type UserType string
 type DriverStatus string
+type DriverVerification string
 
 const (
 	Car        VehicleType = "car"
@@ -13,6 +16,7 @@ const (
 	Consumer UserType = "consumer"
 	Driver   UserType = "driver"
 	Merchant UserType = "merchant"
+	Admin    UserType = "admin"
 )
 
 const (
@@ -22,15 +26,25 @@ const (
 	Sending   DriverStatus = "sending"
 )
 
+const (
+	VerificationPending  DriverVerification = "pending"
+	VerificationVerified DriverVerification = "verified"
+	VerificationRejected DriverVerification = "rejected"
+)
+
 type User struct {
 	BaseModel
-	Name              string    `json:"name" gorm:"not null"`
-	Email             string    `json:"email" gorm:"unique;not null;index:idx_email"`
-	Phone             string    `json:"phone" gorm:"index:idx_phone"`                          // Changed from int to string
-	Type              UserType  `json:"user_type" gorm:"default:consumer;index:idx_user_type"` // Fixed case
-	Password          string    `json:"-"`
-	ProfilePictureURL string    `json:"profile_picture_url"`
-	Accounts          []Account `json:"accounts,omitempty"` // we dont have to put gorm fk here because we haev UserId at account, so gorm will assume it is the fk
+	Name              string     `json:"name" gorm:"not null"`
+	Email             string     `json:"email" gorm:"unique;not null;index:idx_email"`
+	EmailVerifiedAt   *time.Time `json:"email_verified_at,omitempty"`                           // set once a verification link sent to Email was opened
+	Phone             string     `json:"phone" gorm:"index:idx_phone"`                          // Changed from int to string
+	PhoneVerifiedAt   *time.Time `json:"phone_verified_at,omitempty"`                           // set once an OTP sent to Phone came back
+	Type              UserType   `json:"user_type" gorm:"default:consumer;index:idx_user_type"` // Fixed case
+	Password          string     `json:"-"`
+	ProfilePictureURL string     `json:"profile_picture_url"`
+	FrozenAt          *time.Time `json:"frozen_at,omitempty"` // set by an admin, no money moves in or out while frozen
+	FrozenReason      string     `json:"frozen_reason,omitempty"`
+	Accounts          []Account  `json:"accounts,omitempty"` // we dont have to put gorm fk here because we haev UserId at account, so gorm will assume it is the fk
 
 	// contacts i created
 	Contacts []Contact `json:"contacts,omitempty" gorm:"foreignKey:OwnerID"` // the reason we put it here foreignkey ownerId is because this is an user struct and at contact, we have it as owner, not UserId, so gorm needs precise fk explicitly.
@@ -48,6 +62,12 @@ type DriverProfile struct {
 	CurrentLocation   string       `json:"current_location" gorm:"index:idx_location"`
 	Status            DriverStatus `json:"status" gorm:"default:offline;index:idx_status"` // offline online suspend
 	IsVerified        bool         `json:"is_verified" gorm:"default:false"`
+
+	// admin review of the license documents, IsVerified stays in sync for the availability queries
+	Verification     DriverVerification `json:"verification" gorm:"default:pending;index"`
+	ReviewNote       string             `json:"review_note,omitempty"`
+	ReviewedAt       *time.Time         `json:"reviewed_at,omitempty"`
+	SuspensionReason string             `json:"suspension_reason,omitempty"`
 }
 
 type MerchantProfile struct {
@@ -66,6 +86,15 @@ type MerchantProfile struct {
 	Menu            []MenuItem `json:"menu,omitempty" gorm:"foreignKey:MerchantId"`
 }
 
+// MerchantLogo is the image at MerchantLogoURL, fetched when the URL is set so rendering a QR
+// never makes a request
+type MerchantLogo struct {
+	MerchantID uint   `gorm:"primaryKey;autoIncrement:false"`
+	SourceURL  string `gorm:"not null"`
+	Image      []byte `gorm:"not null"`
+	UpdatedAt  time.Time
+}
+
 type LoggedinUser struct {
 	Email    string `json:"email" `
 	Password string `json:"-"`
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+type WebhookEvent string
+
+const (
+	WebhookPaymentCompleted   WebhookEvent = "payment.completed"    // a QR of the merchant was paid
+	WebhookPaymentRefunded    WebhookEvent = "payment.refunded"     // the merchant refunded (part of) a payment
+	WebhookOrderCreated       WebhookEvent = "order.created"        // a customer ordered from the merchant
+	WebhookOrderStatusChanged WebhookEvent = "order.status_changed" // an order of the merchant moved on
+)
+
+var WebhookEvents = []WebhookEvent{WebhookPaymentCompleted, WebhookPaymentRefunded, WebhookOrderCreated, WebhookOrderStatusChanged}
+
+// WebhookEndpoint receives the events of a merchant. Deliveries are signed with the secret, so
+// like the merchant API signing keys it is kept sealed rather than hashed.
+type WebhookEndpoint struct {
+	BaseModel
+	MerchantID   uint       `json:"merchant_id" gorm:"not null;index"`
+	URL          string     `json:"url" gorm:"not null"`
+	Events       string     `json:"events"`                // comma separated WebhookEvent values
+	SealedSecret string     `json:"-" gorm:"not null"`     // see utils.SealSecret
+	DisabledAt   *time.Time `json:"disabled_at,omitempty"` // disabled endpoints get no new deliveries
+}
+
+func (e *WebhookEndpoint) Subscribed(event WebhookEvent) bool {
+	for _, s := range strings.Split(e.Events, ",") {
+		if WebhookEvent(s) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEndpointCredentials is the only time the secret is shown
+type WebhookEndpointCredentials struct {
+	Endpoint WebhookEndpoint `json:"endpoint"`
+	Secret   string          `json:"secret"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending" // waiting for its next attempt
+	WebhookDelivered WebhookDeliveryStatus = "delivered"
+	WebhookFailed    WebhookDeliveryStatus = "failed" // out of attempts, can still be redelivered by hand
+)
+
+// WebhookDelivery is one event for one endpoint. A redelivery is a new row with the same
+// EventID, so the merchant can drop events it already handled.
+type WebhookDelivery struct {
+	BaseModel
+	EndpointID     uint                  `json:"endpoint_id" gorm:"not null;index"`
+	MerchantID     uint                  `json:"merchant_id" gorm:"not null;index"`
+	EventID        string                `json:"event_id" gorm:"not null;index"`
+	Event          WebhookEvent          `json:"event" gorm:"not null"`
+	Payload        string                `json:"payload" gorm:"type:text;not null"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"default:pending;index:idx_webhook_due,priority:1"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" gorm:"index:idx_webhook_due,priority:2"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	ResponseStatus int                   `json:"response_status,omitempty"` // of the last attempt
+	Error          string                `json:"error,omitempty"`           // of the last attempt
+	Log            []WebhookAttempt      `json:"log,omitempty" gorm:"foreignKey:DeliveryID"`
+}
+
+// WebhookAttempt is one try of a delivery
+type WebhookAttempt struct {
+	BaseModel
+	DeliveryID     uint   `json:"delivery_id" gorm:"not null;index"`
+	Attempt        int    `json:"attempt"`
+	ResponseStatus int    `json:"response_status,omitempty"`
+	ResponseBody   string `json:"response_body,omitempty"` // the start of it
+	Error          string `json:"error,omitempty"`
+	DurationMs     int64  `json:"duration_ms"`
+}
+
+// WebhookPayload is the JSON body posted to the endpoint
+type WebhookPayload struct {
+	ID        string       `json:"id"`
+	Event     WebhookEvent `json:"event"`
+	CreatedAt time.Time    `json:"created_at"`
+	Data      any          `json:"data"`
+}
+
+// WebhookPaymentData is the data of payment.completed
+type WebhookPaymentData struct {
+	QrCodeID      uint      `json:"qr_code_id"`
+	Reference     string    `json:"reference,omitempty"` // set for QRs created through the merchant API
+	TransactionID uint      `json:"transaction_id"`
+	Amount        float64   `json:"amount"`
+	PaidAt        time.Time `json:"paid_at"`
+}
+
+// WebhookOrderData is the data of order.created and order.status_changed
+type WebhookOrderData struct {
+	OrderID        uint        `json:"order_id"`
+	Status         OrderStatus `json:"status"`
+	PreviousStatus OrderStatus `json:"previous_status,omitempty"`
+	TotalAmount    float64     `json:"total_amount"`
+	DeliveryFee    float64     `json:"delivery_fee"`
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"gopay-clone/config"
 	"gopay-clone/handlers"
+	"gopay-clone/middleware"
 	"gopay-clone/services"
 
 	"github.com/labstack/echo/v4"
@@ -11,15 +12,18 @@ import (
 func RegisterAccountRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc) {
 	accountService := services.NewAccountService(db)
 	transactionService := services.NewTransactionService(db)
-	accountHandler := handlers.NewAccountHandler(accountService, transactionService)
+	statementService := services.NewStatementService(db)
+	accountHandler := handlers.NewAccountHandler(accountService, transactionService, statementService)
 
 	accounts := api.Group("/accounts")
-	accounts.Use(jwtMiddleware)
+	accounts.Use(jwtMiddleware, middleware.Require(middleware.PermWallet))
 	{
 		accounts.POST("", accountHandler.CreateAccount)
 		accounts.GET("/:account_id/balance", accountHandler.GetBalanceByAccountId)
 		accounts.PUT("/:account_id", accountHandler.UpdateAccount)
 		accounts.GET("/:account_id/detail", accountHandler.GetAccountDetail)
 		accounts.GET("/:account_id/transactions", accountHandler.GetTransactionByAccounts)
+		accounts.GET("/:account_id/transactions/summary", accountHandler.GetTransactionSummary)
+		accounts.GET("/:account_id/statement", accountHandler.GetStatement)
 	}
 }
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"gopay-clone/config"
+	"gopay-clone/handlers"
+	"gopay-clone/middleware"
+	"gopay-clone/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterAdminRoutes needs no audit middleware of its own, every request with an
+// admin token is already audited through the JWT middleware chain
+func RegisterAdminRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc) {
+	driverService := services.NewDriverService(db)
+	userService := services.NewUserService(db)
+	orderService := services.NewOrderService(db)
+	transactionService := services.NewTransactionService(db)
+	auditService := services.NewAuditService(db)
+	adminHandler := handlers.NewAdminHandler(driverService, userService, orderService, transactionService, auditService)
+
+	admin := api.Group("/admin")
+	admin.Use(jwtMiddleware)
+	reviewDrivers := middleware.Require(middleware.PermReviewDrivers)
+	freezeUsers := middleware.Require(middleware.PermFreezeUsers)
+	readAny := middleware.Require(middleware.PermReadAny)
+	{
+		admin.GET("/drivers", adminHandler.GetDrivers, reviewDrivers)
+		admin.GET("/drivers/:driver_id", adminHandler.GetDriver, reviewDrivers)
+		admin.POST("/drivers/:driver_id/verify", adminHandler.VerifyDriver, reviewDrivers)
+		admin.POST("/drivers/:driver_id/reject", adminHandler.RejectDriver, reviewDrivers)
+		admin.POST("/drivers/:driver_id/suspend", adminHandler.SuspendDriver, reviewDrivers)
+		admin.POST("/drivers/:driver_id/reinstate", adminHandler.ReinstateDriver, reviewDrivers)
+
+		admin.POST("/users/:user_id/freeze", adminHandler.FreezeUser, freezeUsers)
+		admin.POST("/users/:user_id/unfreeze", adminHandler.UnfreezeUser, freezeUsers)
+
+		admin.GET("/orders", adminHandler.GetOrders, readAny)
+		admin.GET("/orders/:order_id", adminHandler.GetOrder, readAny)
+		admin.GET("/transactions", adminHandler.GetTransactions, readAny)
+		admin.GET("/transactions/:transaction_id", adminHandler.GetTransaction, readAny)
+
+		admin.GET("/audit-logs", adminHandler.GetAuditLogs, middleware.Require(middleware.PermReadAudit))
+	}
+}
@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"gopay-clone/config"
+	"gopay-clone/handlers"
+	"gopay-clone/middleware"
+	"gopay-clone/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterAuthRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc, limiter *middleware.RateLimiter) {
+	authService := services.NewAuthService(db, config.LoadAuthConfig())
+	mailConfig := config.LoadMailConfig()
+	emailService := services.NewEmailService(db, mailConfig, services.NewMailer(mailConfig))
+	authHandler := handlers.NewAuthHandler(authService, emailService)
+	twoFactorService := services.NewTwoFactorService(db, config.LoadTwoFactorConfig())
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService, authService, emailService)
+
+	publicAuth := api.Group("/public/auth")
+	publicAuth.POST("/refresh", authHandler.RefreshToken, limiter.Login())
+	publicAuth.POST("/password/forgot", authHandler.ForgotPassword, limiter.Login())
+	publicAuth.POST("/password/reset", authHandler.ResetPassword, limiter.Login())
+	publicAuth.POST("/email/verify", authHandler.VerifyEmail)
+	publicAuth.POST("/2fa", twoFactorHandler.CompleteLogin, limiter.Login())
+
+	auth := api.Group("/auth")
+	auth.Use(jwtMiddleware)
+	{
+		auth.POST("/logout", authHandler.Logout)
+		auth.POST("/logout-all", authHandler.LogoutAll)
+		auth.GET("/sessions", authHandler.GetSessions)
+		auth.DELETE("/sessions/:session_id", authHandler.RevokeSession)
+		auth.POST("/email/verification", authHandler.SendEmailVerification)
+		auth.GET("/2fa", twoFactorHandler.GetStatus)
+		auth.POST("/2fa/enrol", twoFactorHandler.Enrol)
+		auth.POST("/2fa/enable", twoFactorHandler.Enable)
+		auth.POST("/2fa/disable", twoFactorHandler.Disable)
+		auth.POST("/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+	}
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"gopay-clone/config"
+	"gopay-clone/handlers"
+	"gopay-clone/middleware"
+	"gopay-clone/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterBillRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc, limiter *middleware.RateLimiter) {
+	billService := services.NewBillService(db)
+	budgetService := services.NewBudgetService(db, services.NewNotificationService(db, services.NewPushProvider(config.LoadNotificationConfig())))
+	pinService := services.NewPinService(db, config.LoadPinConfig())
+	mailConfig := config.LoadMailConfig()
+	emailService := services.NewEmailService(db, mailConfig, services.NewMailer(mailConfig))
+	billHandler := handlers.NewBillHandler(billService, budgetService, pinService, emailService)
+
+	bills := api.Group("/bills")
+	bills.Use(jwtMiddleware, middleware.Require(middleware.PermWallet))
+	{
+		bills.GET("/billers", billHandler.GetBillers)
+		bills.POST("/inquiries", billHandler.InquireBill)
+		bills.POST("/payments", billHandler.PayBill, limiter.Money())
+		bills.GET("/payments", billHandler.GetBillPayments)
+		bills.GET("/payments/:payment_id", billHandler.GetBillPayment)
+	}
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"gopay-clone/config"
+	"gopay-clone/handlers"
+	"gopay-clone/middleware"
+	"gopay-clone/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterContactRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc, limiter *middleware.RateLimiter) {
+	contactService := services.NewContactService(db)
+	accountService := services.NewAccountService(db)
+	transactionService := services.NewTransactionService(db)
+	budgetService := services.NewBudgetService(db, services.NewNotificationService(db, services.NewPushProvider(config.LoadNotificationConfig())))
+	pinService := services.NewPinService(db, config.LoadPinConfig())
+	mailConfig := config.LoadMailConfig()
+	emailService := services.NewEmailService(db, mailConfig, services.NewMailer(mailConfig))
+	contactHandler := handlers.NewContactHandler(contactService, accountService, transactionService, budgetService, pinService, emailService)
+
+	contacts := api.Group("/contacts")
+	contacts.Use(jwtMiddleware, middleware.Require(middleware.PermWallet))
+	{
+		contacts.GET("", contactHandler.GetContacts)
+		contacts.POST("", contactHandler.AddContact)
+		contacts.GET("/lookup", contactHandler.LookupUser, limiter.Lookup())
+		contacts.GET("/recent", contactHandler.GetRecentCounterparties)
+		contacts.PUT("/:contact_id", contactHandler.UpdateContact)
+		contacts.DELETE("/:contact_id", contactHandler.DeleteContact)
+		contacts.POST("/:contact_id/transfer", contactHandler.TransferToContact, limiter.Money())
+	}
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"gopay-clone/config"
 	"gopay-clone/handlers"
+	"gopay-clone/middleware"
 	"gopay-clone/services"
 
 	"github.com/labstack/echo/v4"
@@ -11,7 +12,7 @@ import (
 func RegisterDriverRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc) {
 	driverService := services.NewDriverService(db)
 	userService := services.NewUserService(db)
-	driverHandler := handlers.NewDriverHandler(driverService, userService)
+	driverHandler := handlers.NewDriverHandler(driverService, userService, services.NewRideService(db))
 
 	drivers := api.Group("/drivers")
 
@@ -20,16 +21,20 @@ func RegisterDriverRoutes(api *echo.Group, db *config.Database, jwtMiddleware ec
 	publicDrivers.POST("", driverHandler.CreateDriver)
 
 	drivers.Use(jwtMiddleware)
+	manageDriver := middleware.Require(middleware.PermManageDriver)
 	{
-		drivers.GET("/available", driverHandler.GetAvailableDrivers)
+		drivers.GET("/available", driverHandler.GetAvailableDrivers, middleware.Require(middleware.PermFindDrivers))
 
 		// driver profile management (for drivers themselves)
-		drivers.GET("/:driver_id", driverHandler.GetDriverByID)
-		drivers.PUT("/profile", driverHandler.UpdateDriverProfile)
-		drivers.DELETE("/profile", driverHandler.DeleteDriverProfile)
+		drivers.GET("/:driver_id", driverHandler.GetDriverByID, manageDriver)
+		drivers.PUT("/profile", driverHandler.UpdateDriverProfile, manageDriver)
+		drivers.DELETE("/profile", driverHandler.DeleteDriverProfile, manageDriver)
 
 		// driver status and location (for drivers themselves)
-		drivers.PUT("/status", driverHandler.UpdateDriverStatus)
-		drivers.PUT("/location", driverHandler.UpdateDriverLocation)
+		drivers.PUT("/status", driverHandler.UpdateDriverStatus, manageDriver)
+		drivers.PUT("/location", driverHandler.UpdateDriverLocation, manageDriver)
+
+		// rides assigned to the driver
+		drivers.PUT("/rides/:ride_id/status", driverHandler.UpdateRideStatus, manageDriver)
 	}
 }
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"gopay-clone/config"
+	"gopay-clone/handlers"
+	"gopay-clone/middleware"
+	"gopay-clone/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterInsightRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc) {
+	insightService := services.NewInsightService(db)
+	notificationService := services.NewNotificationService(db, services.NewPushProvider(config.LoadNotificationConfig()))
+	budgetService := services.NewBudgetService(db, notificationService)
+	insightHandler := handlers.NewInsightHandler(insightService, budgetService)
+
+	insights := api.Group("/insights")
+	budgets := api.Group("/budgets")
+	insights.Use(jwtMiddleware, middleware.Require(middleware.PermWallet))
+	budgets.Use(jwtMiddleware, middleware.Require(middleware.PermWallet))
+	{
+		insights.GET("/spending", insightHandler.GetSpending)
+
+		budgets.GET("", insightHandler.GetBudgets)
+		budgets.PUT("", insightHandler.SetBudget)
+		budgets.GET("/check", insightHandler.CheckBudget)
+		budgets.DELETE("/:category", insightHandler.DeleteBudget)
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"gopay-clone/config"
+	"gopay-clone/handlers"
+	"gopay-clone/middleware"
+	"gopay-clone/models"
+	"gopay-clone/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterMerchantApiRoutes is the server-to-server API, signed with merchant API keys instead of a JWT
+func RegisterMerchantApiRoutes(api *echo.Group, db *config.Database, limiter *middleware.RateLimiter) {
+	merchantApiConfig := config.LoadMerchantApiConfig()
+	keyService := services.NewMerchantApiKeyService(db, merchantApiConfig)
+	merchantApiHandler := handlers.NewMerchantApiHandler(services.NewMerchantPaymentService(db, merchantApiConfig, config.LoadQrConfig()))
+
+	merchantApi := api.Group("/merchant-api")
+	merchantApi.Use(middleware.MerchantSignature(keyService, limiter.Store()), limiter.MerchantApi())
+	{
+		merchantApi.POST("/qr", merchantApiHandler.CreateQR, middleware.RequireScope(models.ScopeQrWrite))
+		merchantApi.GET("/qr/:qr_id", merchantApiHandler.GetPayment, middleware.RequireScope(models.ScopePaymentsRead))
+		merchantApi.POST("/refunds", merchantApiHandler.CreateRefund, middleware.RequireScope(models.ScopeRefundsWrite))
+	}
+}
@@ -3,22 +3,26 @@ package routes
 import (
 	"gopay-clone/config"
 	"gopay-clone/handlers"
+	"gopay-clone/middleware"
 	"gopay-clone/services"
 
 	"github.com/labstack/echo/v4"
 )
 
 func RegisterMerchantRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc) {
-	merchantService := services.NewMerchantService(db)
+	merchantService := services.NewMerchantService(db, config.LoadQrConfig())
 	menuService := services.NewMenuItemService(db)
 	userService := services.NewUserService(db)
 	merchantHandler := handlers.NewMerchantHandler(userService, merchantService)
 	menuHandler := handlers.NewMenuHandler(menuService, merchantService)
+	apiKeyHandler := handlers.NewMerchantApiKeyHandler(services.NewMerchantApiKeyService(db, config.LoadMerchantApiConfig()), merchantService)
+	webhookHandler := handlers.NewWebhookHandler(services.NewWebhookService(db, config.LoadWebhookConfig()), merchantService)
+	qrHandler := handlers.NewMerchantQRHandler(services.NewQRService(db, config.LoadQrConfig()), merchantService)
 
 	publicMerchantAPI := api.Group("/public/merchants")
 	merchants := api.Group("/merchants")
 	menus := api.Group("/menus")
-	merchants.Use(jwtMiddleware)
+	merchants.Use(jwtMiddleware, middleware.Require(middleware.PermManageMerchant))
 	menus.Use(jwtMiddleware)
 	{
 		publicMerchantAPI.POST("", merchantHandler.CreateMerchant)
@@ -33,6 +37,29 @@ func RegisterMerchantRoutes(api *echo.Group, db *config.Database, jwtMiddleware
 		merchants.PUT("/:merchant_id/menu-item/:menu_id", menuHandler.UpdateMenuItem)
 		merchants.DELETE("/:merchant_id/menu-item/:menu_id", menuHandler.DeleteMenuItem)
 
+		// API keys for the merchant API
+		merchants.GET("/:merchant_id/api-keys", apiKeyHandler.GetKeys)
+		merchants.POST("/:merchant_id/api-keys", apiKeyHandler.CreateKey)
+		merchants.POST("/:merchant_id/api-keys/:key_id/rotate", apiKeyHandler.RotateKey)
+		merchants.DELETE("/:merchant_id/api-keys/:key_id", apiKeyHandler.RevokeKey)
+
+		// webhooks and their delivery log
+		merchants.GET("/:merchant_id/webhooks", webhookHandler.GetEndpoints)
+		merchants.POST("/:merchant_id/webhooks", webhookHandler.CreateEndpoint)
+		merchants.PUT("/:merchant_id/webhooks/:webhook_id", webhookHandler.UpdateEndpoint)
+		merchants.DELETE("/:merchant_id/webhooks/:webhook_id", webhookHandler.DeleteEndpoint)
+		merchants.GET("/:merchant_id/webhooks/deliveries", webhookHandler.GetDeliveries)
+		merchants.GET("/:merchant_id/webhooks/deliveries/:delivery_id", webhookHandler.GetDelivery)
+		merchants.POST("/:merchant_id/webhooks/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+
+		// static QRs for the counter, their payments and printable standees
+		merchants.GET("/:merchant_id/qr", qrHandler.GetStaticQRs)
+		merchants.POST("/:merchant_id/qr", qrHandler.CreateStaticQR)
+		merchants.GET("/:merchant_id/qr/:qr_id", qrHandler.GetQR)
+		merchants.DELETE("/:merchant_id/qr/:qr_id", qrHandler.DisableStaticQR)
+		merchants.GET("/:merchant_id/qr/:qr_id/payments", qrHandler.GetQRPayments)
+		merchants.GET("/:merchant_id/qr/:qr_id/standee", qrHandler.GetStandee)
+
 		// get all menus by filter
 		menus.GET("/menu-items", menuHandler.GetAllMenus)
 	}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"gopay-clone/config"
+	"gopay-clone/handlers"
+	"gopay-clone/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterNotificationRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc) {
+	notificationService := services.NewNotificationService(db, services.NewPushProvider(config.LoadNotificationConfig()))
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+
+	// every role gets notifications, drivers and merchants included
+	notifications := api.Group("/notifications")
+	notifications.Use(jwtMiddleware)
+	{
+		notifications.GET("", notificationHandler.GetNotifications)
+		notifications.GET("/unread-count", notificationHandler.GetUnreadCount)
+		notifications.POST("/read-all", notificationHandler.MarkAllRead)
+		notifications.POST("/:notification_id/read", notificationHandler.MarkRead)
+
+		notifications.GET("/devices", notificationHandler.GetDevices)
+		notifications.POST("/devices", notificationHandler.RegisterDevice)
+		notifications.DELETE("/devices/:device_id", notificationHandler.RemoveDevice)
+	}
+}
@@ -3,27 +3,31 @@ package routes
 import (
 	"gopay-clone/config"
 	"gopay-clone/handlers"
+	"gopay-clone/middleware"
 	"gopay-clone/services"
 
 	"github.com/labstack/echo/v4"
 )
 
-func RegisterOrderRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc) {
+func RegisterOrderRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc, limiter *middleware.RateLimiter) {
 	orderService := services.NewOrderService(db)
-	merchantService := services.NewMerchantService(db)
+	merchantService := services.NewMerchantService(db, config.LoadQrConfig())
 	userService := services.NewUserService(db)
 	menuService := services.NewMenuItemService(db)
 	accountService := services.NewAccountService(db)
-	transactionService := services.NewTransactionService(db)
 	driverService := services.NewDriverService(db)
+	budgetService := services.NewBudgetService(db, services.NewNotificationService(db, services.NewPushProvider(config.LoadNotificationConfig())))
+	pinService := services.NewPinService(db, config.LoadPinConfig())
+	mailConfig := config.LoadMailConfig()
+	emailService := services.NewEmailService(db, mailConfig, services.NewMailer(mailConfig))
 
-	orderHandler := handlers.NewOrderHandler(orderService, merchantService, userService, menuService, accountService, transactionService, driverService)
+	orderHandler := handlers.NewOrderHandler(orderService, merchantService, userService, menuService, accountService, driverService, budgetService, pinService, emailService)
 
 	orders := api.Group("/orders")
 	orders.Use(jwtMiddleware)
 	{
-		orders.POST("", orderHandler.CreateOrder)
-		orders.GET("/:order_id", orderHandler.GetOrderByID)
-		orders.PUT("/:order_id/status", orderHandler.UpdateOrderStatus)
+		orders.POST("", orderHandler.CreateOrder, middleware.Require(middleware.PermPlaceOrder), limiter.Money())
+		orders.GET("/:order_id", orderHandler.GetOrderByID) // customer, merchant, driver or admin, checked in the handler
+		orders.PUT("/:order_id/status", orderHandler.UpdateOrderStatus, middleware.Require(middleware.PermUpdateOrder))
 	}
 }
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"gopay-clone/config"
+	"gopay-clone/handlers"
+	"gopay-clone/middleware"
+	"gopay-clone/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterOtpRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc, limiter *middleware.RateLimiter) {
+	otpConfig := config.LoadOtpConfig()
+	otpService := services.NewOtpService(db, otpConfig, services.NewSMSSender(otpConfig))
+	otpHandler := handlers.NewOtpHandler(otpService)
+
+	// PUBLIC routes, codes for sign up and login by phone
+	api.POST("/public/otp", otpHandler.RequestOtp, limiter.Otp())
+
+	phone := api.Group("/phone")
+	phone.Use(jwtMiddleware)
+	{
+		phone.POST("/otp", otpHandler.SendPhoneVerification, limiter.Otp())
+		phone.POST("/verify", otpHandler.VerifyPhone, limiter.Verify())
+	}
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"gopay-clone/config"
+	"gopay-clone/handlers"
+	"gopay-clone/middleware"
+	"gopay-clone/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterPaymentRequestRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc, limiter *middleware.RateLimiter) {
+	notificationService := services.NewNotificationService(db, services.NewPushProvider(config.LoadNotificationConfig()))
+	paymentRequestService := services.NewPaymentRequestService(db, notificationService)
+	budgetService := services.NewBudgetService(db, notificationService)
+	pinService := services.NewPinService(db, config.LoadPinConfig())
+	mailConfig := config.LoadMailConfig()
+	emailService := services.NewEmailService(db, mailConfig, services.NewMailer(mailConfig))
+	paymentRequestHandler := handlers.NewPaymentRequestHandler(paymentRequestService, budgetService, pinService, emailService)
+
+	requests := api.Group("/payment-requests")
+	splitBills := api.Group("/split-bills")
+	requests.Use(jwtMiddleware, middleware.Require(middleware.PermWallet))
+	splitBills.Use(jwtMiddleware, middleware.Require(middleware.PermWallet))
+	{
+		requests.POST("", paymentRequestHandler.CreatePaymentRequest)
+		requests.GET("/incoming", paymentRequestHandler.GetIncomingRequests)
+		requests.GET("/outgoing", paymentRequestHandler.GetOutgoingRequests)
+		requests.GET("/:request_id", paymentRequestHandler.GetPaymentRequest)
+		requests.POST("/:request_id/pay", paymentRequestHandler.PayRequest, limiter.Money())
+		requests.POST("/:request_id/decline", paymentRequestHandler.DeclineRequest)
+		requests.POST("/:request_id/cancel", paymentRequestHandler.CancelRequest)
+
+		splitBills.POST("", paymentRequestHandler.CreateSplitBill)
+		splitBills.GET("", paymentRequestHandler.GetSplitBills)
+		splitBills.GET("/:split_bill_id", paymentRequestHandler.GetSplitBill)
+		splitBills.POST("/:split_bill_id/cancel", paymentRequestHandler.CancelSplitBill)
+	}
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"gopay-clone/config"
+	"gopay-clone/handlers"
+	"gopay-clone/middleware"
+	"gopay-clone/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterPinRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc, limiter *middleware.RateLimiter) {
+	pinService := services.NewPinService(db, config.LoadPinConfig())
+	otpConfig := config.LoadOtpConfig()
+	otpService := services.NewOtpService(db, otpConfig, services.NewSMSSender(otpConfig))
+	pinHandler := handlers.NewPinHandler(pinService, otpService)
+
+	pin := api.Group("/pin")
+	pin.Use(jwtMiddleware, middleware.Require(middleware.PermWallet))
+	{
+		pin.GET("", pinHandler.GetPinStatus)
+		pin.POST("", pinHandler.SetPin)
+		pin.PUT("", pinHandler.ChangePin)
+		pin.POST("/otp", pinHandler.SendPinOtp, limiter.Otp())
+		pin.POST("/reset", pinHandler.ResetPin, limiter.Verify())
+	}
+}
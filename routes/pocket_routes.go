@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"gopay-clone/config"
+	"gopay-clone/handlers"
+	"gopay-clone/middleware"
+	"gopay-clone/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterPocketRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc, limiter *middleware.RateLimiter) {
+	pocketService := services.NewPocketService(db, services.NewScheduledTransferService(db))
+	accountService := services.NewAccountService(db)
+	pinService := services.NewPinService(db, config.LoadPinConfig())
+	mailConfig := config.LoadMailConfig()
+	emailService := services.NewEmailService(db, mailConfig, services.NewMailer(mailConfig))
+	pocketHandler := handlers.NewPocketHandler(pocketService, accountService, pinService, emailService)
+
+	pockets := api.Group("/pockets")
+	pockets.Use(jwtMiddleware, middleware.Require(middleware.PermWallet))
+	{
+		pockets.POST("", pocketHandler.CreatePocket)
+		pockets.GET("", pocketHandler.GetPockets)
+		pockets.POST("/transfer", pocketHandler.InternalTransfer, limiter.Money())
+		pockets.GET("/:pocket_id", pocketHandler.GetPocket)
+		pockets.PUT("/:pocket_id", pocketHandler.UpdatePocket)
+		pockets.GET("/:pocket_id/auto-save", pocketHandler.GetAutoSaveRules)
+		pockets.POST("/:pocket_id/auto-save", pocketHandler.AddAutoSaveRule)
+		pockets.DELETE("/:pocket_id/auto-save/:rule_id", pocketHandler.DeleteAutoSaveRule)
+	}
+}
@@ -3,19 +3,27 @@ package routes
 import (
 	"gopay-clone/config"
 	"gopay-clone/handlers"
+	"gopay-clone/middleware"
 	"gopay-clone/services"
 
 	"github.com/labstack/echo/v4"
 )
 
-func RegisterQRRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc) {
-	qrService := services.NewQRService(db)
-	transactionHandler := handlers.NewQRHandler(qrService)
+func RegisterQRRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc, limiter *middleware.RateLimiter) {
+	qrService := services.NewQRService(db, config.LoadQrConfig())
+	budgetService := services.NewBudgetService(db, services.NewNotificationService(db, services.NewPushProvider(config.LoadNotificationConfig())))
+	pinService := services.NewPinService(db, config.LoadPinConfig())
+	mailConfig := config.LoadMailConfig()
+	emailService := services.NewEmailService(db, mailConfig, services.NewMailer(mailConfig))
+	accountService := services.NewAccountService(db)
+	transactionHandler := handlers.NewQRHandler(qrService, budgetService, pinService, emailService, accountService)
 
 	transactions := api.Group("/qr")
-	transactions.Use(jwtMiddleware)
+	transactions.Use(jwtMiddleware, middleware.Require(middleware.PermWallet))
 	{
 		transactions.POST("", transactionHandler.CreateQR)
-		transactions.PUT("/:qr_id", transactionHandler.ScanQr)
+		transactions.POST("/parse", transactionHandler.ParseQR)
+		transactions.GET("/:qr_id/image", transactionHandler.GetQRImage)
+		transactions.PUT("/:qr_id", transactionHandler.ScanQr, limiter.Money())
 	}
 }
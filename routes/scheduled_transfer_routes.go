@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"gopay-clone/config"
+	"gopay-clone/handlers"
+	"gopay-clone/middleware"
+	"gopay-clone/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterScheduledTransferRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc, limiter *middleware.RateLimiter) {
+	scheduleService := services.NewScheduledTransferService(db)
+	accountService := services.NewAccountService(db)
+	contactService := services.NewContactService(db)
+	pinService := services.NewPinService(db, config.LoadPinConfig())
+	mailConfig := config.LoadMailConfig()
+	emailService := services.NewEmailService(db, mailConfig, services.NewMailer(mailConfig))
+	scheduleHandler := handlers.NewScheduledTransferHandler(scheduleService, accountService, contactService, pinService, emailService)
+
+	schedules := api.Group("/scheduled-transfers")
+	schedules.Use(jwtMiddleware, middleware.Require(middleware.PermWallet))
+	{
+		schedules.POST("", scheduleHandler.CreateSchedule, limiter.Money())
+		schedules.GET("", scheduleHandler.GetSchedules)
+		schedules.GET("/:schedule_id", scheduleHandler.GetSchedule)
+		schedules.GET("/:schedule_id/runs", scheduleHandler.GetScheduleRuns)
+		schedules.POST("/:schedule_id/pause", scheduleHandler.PauseSchedule)
+		schedules.POST("/:schedule_id/resume", scheduleHandler.ResumeSchedule)
+		schedules.DELETE("/:schedule_id", scheduleHandler.CancelSchedule)
+	}
+}
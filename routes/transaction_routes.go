@@ -3,19 +3,25 @@ package routes
 import (
 	"gopay-clone/config"
 	"gopay-clone/handlers"
+	"gopay-clone/middleware"
 	"gopay-clone/services"
 
 	"github.com/labstack/echo/v4"
 )
 
-func RegisterTransactionRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc) {
+func RegisterTransactionRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc, limiter *middleware.RateLimiter) {
 	transactionService := services.NewTransactionService(db)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	budgetService := services.NewBudgetService(db, services.NewNotificationService(db, services.NewPushProvider(config.LoadNotificationConfig())))
+	pinService := services.NewPinService(db, config.LoadPinConfig())
+	mailConfig := config.LoadMailConfig()
+	emailService := services.NewEmailService(db, mailConfig, services.NewMailer(mailConfig))
+	accountService := services.NewAccountService(db)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, budgetService, pinService, emailService, accountService)
 
 	transactions := api.Group("/transactions")
-	transactions.Use(jwtMiddleware)
+	transactions.Use(jwtMiddleware, middleware.Require(middleware.PermWallet))
 	{
-		transactions.POST("", transactionHandler.CreateTransaction)
+		transactions.POST("", transactionHandler.CreateTransaction, limiter.Money())
 		transactions.GET("/:transaction_id", transactionHandler.GetTransactionDetail)
 		transactions.PUT("/:transaction_id", transactionHandler.UpdateTransactionDetail)
 	}
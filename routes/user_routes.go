@@ -3,30 +3,39 @@ package routes
 import (
 	"gopay-clone/config"
 	"gopay-clone/handlers"
+	"gopay-clone/middleware"
 	"gopay-clone/services"
 
 	"github.com/labstack/echo/v4"
 )
 
-func RegisterUserRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc) {
+func RegisterUserRoutes(api *echo.Group, db *config.Database, jwtMiddleware echo.MiddlewareFunc, limiter *middleware.RateLimiter) {
 	userService := services.NewUserService(db)
 	accountService := services.NewAccountService(db)
 	orderService := services.NewOrderService(db)
-	userHandler := handlers.NewUserHandler(userService, accountService, orderService)
+	authService := services.NewAuthService(db, config.LoadAuthConfig())
+	otpConfig := config.LoadOtpConfig()
+	otpService := services.NewOtpService(db, otpConfig, services.NewSMSSender(otpConfig))
+	mailConfig := config.LoadMailConfig()
+	emailService := services.NewEmailService(db, mailConfig, services.NewMailer(mailConfig))
+	loginGuard := services.NewLoginGuard(config.LoadLoginGuardConfig(), limiter.Store())
+	twoFactorService := services.NewTwoFactorService(db, config.LoadTwoFactorConfig())
+	userHandler := handlers.NewUserHandler(userService, accountService, orderService, authService, otpService, emailService, twoFactorService, loginGuard)
 
 	users := api.Group("/users")
 	publicUsers := api.Group("/public/users")
 
 	// PUBLIC routes (no middleware)
 	publicUsers.POST("", userHandler.CreateUser)
-	publicUsers.POST("/login", userHandler.Login)
-	users.GET("", userHandler.GetAllUsers)
+	publicUsers.POST("/login", userHandler.Login, limiter.Login())
+	publicUsers.POST("/login/phone", userHandler.LoginByPhone, limiter.Login())
 
 	// PROTECTED routes (with middleware)
 	users.Use(jwtMiddleware)
-	users.GET("/:id", userHandler.GetUserById)
-	users.PUT("/:id", userHandler.UpdateUser)
-	users.DELETE("/:id", userHandler.DeleteUser)
-	users.GET("/:user_id/accounts", userHandler.GetAccountsByUser)
-	users.GET("/:user_id/orders", userHandler.GetAllOrdersByUser)
+	users.GET("", userHandler.GetAllUsers, middleware.Require(middleware.PermManageUsers))
+	users.GET("/:id", userHandler.GetUserById, middleware.SelfOrPermission("id", middleware.PermManageUsers))
+	users.PUT("/:id", userHandler.UpdateUser, middleware.Self("id"))
+	users.DELETE("/:id", userHandler.DeleteUser, middleware.SelfOrPermission("id", middleware.PermManageUsers))
+	users.GET("/:user_id/accounts", userHandler.GetAccountsByUser, middleware.SelfOrPermission("user_id", middleware.PermReadAny))
+	users.GET("/:user_id/orders", userHandler.GetAllOrdersByUser, middleware.Self("user_id"))
 }
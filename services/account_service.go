@@ -4,6 +4,7 @@ import (
 	"gopay-clone/config"
 	apperrors "gopay-clone/errors"
 	"gopay-clone/models"
+	"gopay-clone/utils"
 
 	"gorm.io/gorm"
 )
@@ -17,18 +18,22 @@ func NewAccountService(db *config.Database) *AccountService {
 }
 
 func (s *AccountService) CreateAccount(account *models.Account) error {
+	account.InitialBalance = account.Balance
 	if err := s.db.Create(account).Error; err != nil {
 		return apperrors.ErrAccountCreateFailed
 	}
 	return nil
 }
 
-func (s *AccountService) GetAccountsByUser(userId uint) ([]models.Account, error) {
+func (s *AccountService) GetAccountsByUser(userId uint, page *utils.PageParams) ([]models.Account, *utils.PageMeta, error) {
 	var accounts []models.Account
-	if err := s.db.Where("user_id = ?", userId).Find(&accounts).Error; err != nil {
-		return nil, apperrors.NewInternalError("Failed to fetch user accounts")
+	if err := page.Apply(s.db.Where("user_id = ?", userId)).Find(&accounts).Error; err != nil {
+		return nil, nil, apperrors.NewInternalError("Failed to fetch user accounts")
 	}
-	return accounts, nil
+	accounts, meta := utils.Page(accounts, page, func(a models.Account) (uint, map[string]any) {
+		return a.ID, map[string]any{"created_at": a.CreatedAt, "balance": a.Balance}
+	})
+	return accounts, meta, nil
 }
 
 func (s *AccountService) GetAccountById(id uint) (*models.Account, error) {
@@ -47,6 +52,18 @@ func (s *AccountService) GetAccountById(id uint) (*models.Account, error) {
 	return &account, nil
 }
 
+// GetAccount fetches only the account row, use GetAccountById when the transactions are needed
+func (s *AccountService) GetAccount(id uint) (*models.Account, error) {
+	var account models.Account
+	if err := s.db.First(&account, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrAccountNotFound
+		}
+		return nil, apperrors.NewInternalError("Failed to fetch account")
+	}
+	return &account, nil
+}
+
 func (s *AccountService) GetMainBalanceAccount(userID uint) (*models.Account, error) {
 	var account models.Account
 	err := s.db.Where("user_id = ? AND account_type = ?", userID, "main_balance").First(&account).Error
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+)
+
+type AuditService struct {
+	db *config.Database
+}
+
+func NewAuditService(db *config.Database) *AuditService {
+	return &AuditService{db: db}
+}
+
+func (s *AuditService) Record(entry *models.AuditLog) error {
+	if err := s.db.Create(entry).Error; err != nil {
+		return apperrors.ErrAuditWriteFailed
+	}
+	return nil
+}
+
+func (s *AuditService) GetLogs(page *utils.PageParams) ([]models.AuditLog, *utils.PageMeta, error) {
+	var logs []models.AuditLog
+	if err := page.Apply(s.db.Model(&models.AuditLog{})).Find(&logs).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	logs, meta := utils.Page(logs, page, func(l models.AuditLog) (uint, map[string]any) {
+		return l.ID, map[string]any{"created_at": l.CreatedAt}
+	})
+	return logs, meta, nil
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	revokeLogout    = "logout"
+	revokeLogoutAll = "logout_all"
+	revokeReuse     = "refresh_token_reuse"
+	revokeByUser    = "revoked_by_user"
+)
+
+type AuthService struct {
+	db  *config.Database
+	cfg config.AuthConfig
+}
+
+func NewAuthService(db *config.Database, cfg config.AuthConfig) *AuthService {
+	return &AuthService{db: db, cfg: cfg}
+}
+
+// NewDeviceOtp tells login whether a new device needs a code sent to the phone
+func (s *AuthService) NewDeviceOtp() bool {
+	return s.cfg.NewDeviceOtp
+}
+
+// KnownDevice tells whether the user logged in from the device before, which only a device
+// token the server issued to the user proves. The very first login of a user counts as known,
+// there is nothing to warn about yet.
+func (s *AuthService) KnownDevice(userID uint, deviceToken string) (bool, error) {
+	var sessions int64
+	if err := s.db.Model(&models.Session{}).Where("user_id = ?", userID).Count(&sessions).Error; err != nil {
+		return false, apperrors.ErrDatabaseError
+	}
+	if sessions == 0 {
+		return true, nil
+	}
+	known, err := deviceIssuedTx(s.db.DB, userID, deviceToken)
+	if err != nil {
+		return false, apperrors.ErrDatabaseError
+	}
+	return known, nil
+}
+
+func deviceIssuedTx(tx *gorm.DB, userID uint, deviceToken string) (bool, error) {
+	if deviceToken == "" {
+		return false, nil
+	}
+	var fromDevice int64
+	if err := tx.Model(&models.Session{}).Where("user_id = ? AND device_id = ?", userID, utils.HashToken(deviceToken)).Count(&fromDevice).Error; err != nil {
+		return false, err
+	}
+	return fromDevice > 0, nil
+}
+
+// StartSession opens a new token family for a user who just proved who they are. A device
+// without a token issued to the user before gets a new one, so a client can't pick its own.
+func (s *AuthService) StartSession(user *models.User, device models.DeviceInfo) (*models.TokenPair, error) {
+	var pair *models.TokenPair
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		deviceToken := device.Token
+		known, err := deviceIssuedTx(tx, user.ID, deviceToken)
+		if err != nil {
+			return apperrors.ErrDatabaseError
+		}
+		if !known {
+			if deviceToken, _, err = utils.NewOpaqueToken(); err != nil {
+				return apperrors.ErrTokenCreation
+			}
+		}
+
+		now := time.Now()
+		session := models.Session{
+			UserID:     user.ID,
+			DeviceID:   utils.HashToken(deviceToken),
+			DeviceName: device.Name,
+			UserAgent:  device.UserAgent,
+			IP:         device.IP,
+			LastSeenAt: now,
+			ExpiresAt:  now.Add(s.cfg.RefreshTTL),
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return apperrors.ErrSessionCreateFailed
+		}
+		if pair, err = s.issueTx(tx, user, &session); err != nil {
+			return err
+		}
+		pair.DeviceToken = deviceToken
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Refresh spends the refresh token and hands out a new pair. A token that was already
+// spent means it leaked, so the whole session is ended for the thief and the owner alike.
+func (s *AuthService) Refresh(rawToken, ip string) (*models.TokenPair, error) {
+	var pair *models.TokenPair
+	reused := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var token models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Session").
+			Where("token_hash = ?", utils.HashToken(rawToken)).
+			First(&token).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperrors.ErrInvalidRefreshToken
+			}
+			return apperrors.ErrDatabaseError
+		}
+
+		now := time.Now()
+		session := token.Session
+		if session.RevokedAt != nil {
+			return apperrors.ErrSessionRevoked
+		}
+		if token.UsedAt != nil {
+			reused = true
+			return revokeSessionsTx(tx, revokeReuse, "id = ?", session.ID)
+		}
+		if token.ExpiresAt.Before(now) || session.ExpiresAt.Before(now) {
+			return apperrors.ErrInvalidRefreshToken
+		}
+
+		token.UsedAt = &now
+		if err := tx.Model(&token).Update("used_at", now).Error; err != nil {
+			return apperrors.ErrDatabaseError
+		}
+
+		var user models.User
+		if err := tx.First(&user, session.UserID).Error; err != nil {
+			return apperrors.ErrUserNotFound
+		}
+		session.ExpiresAt = now.Add(s.cfg.RefreshTTL)
+		if err := tx.Model(&session).Updates(map[string]any{
+			"expires_at":   session.ExpiresAt,
+			"last_seen_at": now,
+			"ip":           ip,
+		}).Error; err != nil {
+			return apperrors.ErrDatabaseError
+		}
+
+		var err error
+		pair, err = s.issueTx(tx, &user, &session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the revoke has to be committed, so the error is only returned after the transaction
+	if reused {
+		return nil, apperrors.ErrRefreshTokenReused
+	}
+	return pair, nil
+}
+
+func (s *AuthService) Logout(userID, sessionID uint) error {
+	return revokeSessionsTx(s.db.DB, revokeLogout, "id = ? AND user_id = ?", sessionID, userID)
+}
+
+func (s *AuthService) LogoutAll(userID uint) error {
+	return revokeSessionsTx(s.db.DB, revokeLogoutAll, "user_id = ?", userID)
+}
+
+// GetSessions lists the sessions still in use, most recently seen first
+func (s *AuthService) GetSessions(userID, currentSessionID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	if err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession logs one of the user's devices out remotely
+func (s *AuthService) RevokeSession(userID, sessionID uint) error {
+	var session models.Session
+	if err := s.db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperrors.ErrSessionNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	return revokeSessionsTx(s.db.DB, revokeByUser, "id = ?", session.ID)
+}
+
+// CheckSession is what the JWT middleware asks on every request
+func (s *AuthService) CheckSession(userID, sessionID uint) error {
+	if sessionID == 0 {
+		return apperrors.ErrInvalidToken
+	}
+	var session models.Session
+	if err := s.db.Select("id", "user_id", "revoked_at", "last_seen_at").First(&session, sessionID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperrors.ErrSessionRevoked
+		}
+		return apperrors.ErrDatabaseError
+	}
+	if session.UserID != userID {
+		return apperrors.ErrInvalidToken
+	}
+	if session.RevokedAt != nil {
+		return apperrors.ErrSessionRevoked
+	}
+	// last seen is only written once per interval to spare a write on every request
+	if now := time.Now(); now.Sub(session.LastSeenAt) >= s.cfg.LastSeenInterval {
+		if err := s.db.Model(&session).Update("last_seen_at", now).Error; err != nil {
+			return apperrors.ErrDatabaseError
+		}
+	}
+	return nil
+}
+
+func (s *AuthService) issueTx(tx *gorm.DB, user *models.User, session *models.Session) (*models.TokenPair, error) {
+	now := time.Now()
+	raw, hash, err := utils.NewOpaqueToken()
+	if err != nil {
+		return nil, apperrors.ErrTokenCreation
+	}
+	refresh := models.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: hash,
+		ExpiresAt: session.ExpiresAt,
+	}
+	if err := tx.Create(&refresh).Error; err != nil {
+		return nil, apperrors.ErrSessionCreateFailed
+	}
+
+	accessExpiresAt := now.Add(s.cfg.AccessTTL)
+	access, err := utils.CreateToken(*user, session.ID, accessExpiresAt)
+	if err != nil {
+		return nil, apperrors.ErrTokenCreation
+	}
+	return &models.TokenPair{
+		Token:            access,
+		TokenType:        "Bearer",
+		ExpiresAt:        accessExpiresAt,
+		RefreshToken:     raw,
+		RefreshExpiresAt: refresh.ExpiresAt,
+	}, nil
+}
+
+func revokeSessionsTx(tx *gorm.DB, reason string, query string, args ...any) error {
+	err := tx.Model(&models.Session{}).
+		Where(query, args...).
+		Where("revoked_at IS NULL").
+		Updates(map[string]any{"revoked_at": time.Now(), "revoke_reason": reason}).Error
+	if err != nil {
+		return apperrors.ErrSessionRevokeFailed
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const billInquiryTTL = 15 * time.Minute
+
+type BillService struct {
+	db       *config.Database
+	adapters map[string]Biller // keyed by models.Biller.Adapter
+}
+
+func NewBillService(db *config.Database) *BillService {
+	return &BillService{
+		db: db,
+		adapters: map[string]Biller{
+			"simulated": NewSimulatedBiller(),
+		},
+	}
+}
+
+func (s *BillService) GetBillers(category string) ([]models.Biller, error) {
+	var billers []models.Biller
+	query := s.db.Where("is_active = ?", true)
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if err := query.Order("category ASC").Order("name ASC").Find(&billers).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return billers, nil
+}
+
+// Inquire asks the biller what customerNumber owes and keeps the answer for a short while
+func (s *BillService) Inquire(ctx context.Context, userID uint, billerCode, customerNumber string, amount float64) (*models.BillInquiry, error) {
+	var biller models.Biller
+	if err := s.db.Where("code = ? AND is_active = ?", billerCode, true).First(&biller).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrBillerNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if biller.Prepaid && (amount < biller.MinAmount || (biller.MaxAmount > 0 && amount > biller.MaxAmount)) {
+		return nil, apperrors.ErrBillAmountOutOfRange
+	}
+	adapter, ok := s.adapters[biller.Adapter]
+	if !ok {
+		return nil, apperrors.ErrBillerUnavailable
+	}
+
+	result, err := adapter.Inquire(ctx, &biller, customerNumber, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	inquiry := &models.BillInquiry{
+		UserID:         userID,
+		BillerID:       biller.ID,
+		Biller:         biller,
+		CustomerNumber: customerNumber,
+		CustomerName:   result.CustomerName,
+		Period:         result.Period,
+		Amount:         roundMoney(result.Amount),
+		AdminFee:       biller.AdminFee,
+		Total:          roundMoney(result.Amount + biller.AdminFee),
+		BillerRef:      result.Reference,
+		Status:         models.BillInquiryOpen,
+		ExpiresAt:      time.Now().Add(billInquiryTTL),
+	}
+	if err := s.db.Omit("Biller").Create(inquiry).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return inquiry, nil
+}
+
+// Pay holds the total on the sender account, asks the biller to settle the inquiry and
+// releases the hold when the biller refuses. The database transaction is not held
+// open while the biller is called.
+func (s *BillService) Pay(ctx context.Context, userID, inquiryID uint, senderAccountID *uint) (*models.BillPayment, error) {
+	var inquiry models.BillInquiry
+	var payment models.BillPayment
+	transaction := &models.Transaction{}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Biller").
+			Where("user_id = ?", userID).First(&inquiry, inquiryID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperrors.ErrBillInquiryNotFound
+			}
+			return apperrors.ErrDatabaseError
+		}
+		if inquiry.Status != models.BillInquiryOpen {
+			return apperrors.ErrBillInquiryNotOpen
+		}
+		if time.Now().After(inquiry.ExpiresAt) {
+			return apperrors.ErrBillInquiryExpired
+		}
+
+		var sender models.Account
+		if senderAccountID != nil {
+			if err := tx.First(&sender, *senderAccountID).Error; err != nil {
+				return apperrors.ErrAccountNotFound
+			}
+			if sender.UserId != userID {
+				return apperrors.ErrForbidden
+			}
+		} else if err := mainBalance(tx, userID, &sender); err != nil {
+			return err
+		}
+
+		*transaction = models.Transaction{
+			Amount:            inquiry.Total,
+			SenderAccountID:   sender.ID,
+			ReceiverAccountID: inquiry.Biller.SettlementAccountID,
+			Type:              models.Payment,
+			Category:          models.Bills,
+			Status:            models.TransactionPending,
+			ServiceType:       models.ServiceNone,
+			Description:       fmt.Sprintf("%s %s", inquiry.Biller.Name, inquiry.CustomerNumber),
+		}
+		// the settlement account is only paid once the biller confirms
+		if err := holdTransactionTx(tx, transaction); err != nil {
+			return err
+		}
+
+		payment = models.BillPayment{
+			UserID:         userID,
+			InquiryID:      inquiry.ID,
+			BillerID:       inquiry.BillerID,
+			CustomerNumber: inquiry.CustomerNumber,
+			CustomerName:   inquiry.CustomerName,
+			Period:         inquiry.Period,
+			Amount:         inquiry.Amount,
+			AdminFee:       inquiry.AdminFee,
+			Total:          inquiry.Total,
+			Status:         models.BillPaymentPending,
+			TransactionID:  transaction.ID,
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return apperrors.ErrDatabaseError
+		}
+		if err := tx.Model(&inquiry).Update("status", models.BillInquiryProcessing).Error; err != nil {
+			return apperrors.ErrDatabaseError
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	adapter, ok := s.adapters[inquiry.Biller.Adapter]
+	var receipt *BillerReceipt
+	var payErr error = apperrors.ErrBillerUnavailable
+	if ok {
+		receipt, payErr = adapter.Pay(ctx, &inquiry.Biller, &inquiry)
+	}
+
+	if payErr != nil {
+		if err := s.refund(&payment, &inquiry, transaction, payErr); err != nil {
+			return nil, err
+		}
+		return nil, apperrors.ErrBillPaymentFailed
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := settleHoldTx(tx, transaction); err != nil {
+			return err
+		}
+		if err := tx.Model(&payment).Updates(map[string]any{
+			"status":         models.BillPaymentSuccess,
+			"receipt_number": receipt.ReceiptNumber,
+			"token":          receipt.Token,
+		}).Error; err != nil {
+			return apperrors.ErrDatabaseError
+		}
+		return tx.Model(&inquiry).Update("status", models.BillInquiryPaid).Error
+	})
+	if err != nil {
+		// the biller has the money, this has to be reconciled from the pending payment row
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	return s.GetPayment(userID, payment.ID)
+}
+
+// refund releases the hold of a payment the biller refused, with its round-up
+func (s *BillService) refund(payment *models.BillPayment, inquiry *models.BillInquiry, transaction *models.Transaction, reason error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := releaseHoldTx(tx, transaction); err != nil {
+			return err
+		}
+		if err := tx.Model(payment).Updates(map[string]any{
+			"status":         models.BillPaymentFailed,
+			"failure_reason": reason.Error(),
+		}).Error; err != nil {
+			return apperrors.ErrDatabaseError
+		}
+		// the customer may try again with the same inquiry while it has not expired
+		return tx.Model(inquiry).Update("status", models.BillInquiryOpen).Error
+	})
+}
+
+func (s *BillService) GetInquiry(userID, id uint) (*models.BillInquiry, error) {
+	var inquiry models.BillInquiry
+	if err := s.db.Preload("Biller").Where("user_id = ?", userID).First(&inquiry, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrBillInquiryNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &inquiry, nil
+}
+
+func (s *BillService) GetPayments(userID uint, page *utils.PageParams) ([]models.BillPayment, *utils.PageMeta, error) {
+	var payments []models.BillPayment
+	if err := page.Apply(s.db.Preload("Biller").Where("user_id = ?", userID)).Find(&payments).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	payments, meta := utils.Page(payments, page, func(p models.BillPayment) (uint, map[string]any) {
+		return p.ID, map[string]any{"created_at": p.CreatedAt, "total": p.Total}
+	})
+	return payments, meta, nil
+}
+
+func (s *BillService) GetPayment(userID, id uint) (*models.BillPayment, error) {
+	var payment models.BillPayment
+	if err := s.db.Preload("Biller").Preload("Transaction").Where("user_id = ?", userID).First(&payment, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrBillPaymentNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &payment, nil
+}
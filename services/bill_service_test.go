@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/migrations"
+	"gopay-clone/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// refusingBiller answers inquiries and refuses every payment
+type refusingBiller struct{}
+
+func (refusingBiller) Inquire(ctx context.Context, biller *models.Biller, customerNumber string, amount float64) (*BillerInquiry, error) {
+	return &BillerInquiry{CustomerName: "Test Customer", Period: "2026-10", Amount: 95000, Reference: "ref"}, nil
+}
+
+func (refusingBiller) Pay(ctx context.Context, biller *models.Biller, inquiry *models.BillInquiry) (*BillerReceipt, error) {
+	return nil, apperrors.ErrBillerUnavailable
+}
+
+// testDatabase connects to TEST_DATABASE_URL, these tests need postgres for the row locks
+func testDatabase(t *testing.T) *config.Database {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(url), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	database := &config.Database{DB: db}
+	if err := migrations.RunMigration(database); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return database
+}
+
+func TestBillPayRefundsHoldAndRoundUp(t *testing.T) {
+	db := testDatabase(t)
+	suffix := time.Now().UnixNano()
+
+	user := models.User{Name: "Payer", Email: fmt.Sprintf("payer-%d@test.local", suffix)}
+	owner := models.User{Name: "Biller", Email: fmt.Sprintf("biller-%d@test.local", suffix)}
+	for _, u := range []*models.User{&user, &owner} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	main := models.Account{Name: "Main", UserId: user.ID, AccountType: models.MainBalance, Balance: 200000}
+	pocket := models.Account{Name: "Savings", UserId: user.ID, AccountType: models.Pocket}
+	// the settlement account is empty, taking the refund back from it would fail
+	settlement := models.Account{Name: "Settlement", UserId: owner.ID, AccountType: models.MainBalance}
+	for _, a := range []*models.Account{&main, &pocket, &settlement} {
+		if err := db.Create(a).Error; err != nil {
+			t.Fatalf("create account: %v", err)
+		}
+	}
+	rule := models.AutoSaveRule{UserID: user.ID, PocketID: pocket.ID, SourceAccountID: main.ID, Type: models.AutoSaveRoundUp, RoundTo: 1000, IsActive: true}
+	if err := db.Create(&rule).Error; err != nil {
+		t.Fatalf("create rule: %v", err)
+	}
+	biller := models.Biller{
+		Code:                fmt.Sprintf("TEST-%d", suffix),
+		Name:                "Test Water",
+		Category:            models.BillerWater,
+		Adapter:             "refusing",
+		AdminFee:            2500.5,
+		SettlementAccountID: settlement.ID,
+		IsActive:            true,
+	}
+	if err := db.Create(&biller).Error; err != nil {
+		t.Fatalf("create biller: %v", err)
+	}
+
+	service := &BillService{db: db, adapters: map[string]Biller{"refusing": refusingBiller{}}}
+	inquiry, err := service.Inquire(context.Background(), user.ID, biller.Code, "12345", 0)
+	if err != nil {
+		t.Fatalf("inquire: %v", err)
+	}
+	if _, err := service.Pay(context.Background(), user.ID, inquiry.ID, nil); err != apperrors.ErrBillPaymentFailed {
+		t.Fatalf("pay: got %v, want %v", err, apperrors.ErrBillPaymentFailed)
+	}
+
+	balances := map[string]struct {
+		id   uint
+		want float64
+	}{
+		"main":       {main.ID, 200000},
+		"pocket":     {pocket.ID, 0},
+		"settlement": {settlement.ID, 0},
+	}
+	for name, b := range balances {
+		var account models.Account
+		if err := db.First(&account, b.id).Error; err != nil {
+			t.Fatalf("load %s: %v", name, err)
+		}
+		if account.Balance != b.want {
+			t.Errorf("%s balance = %v, want %v", name, account.Balance, b.want)
+		}
+	}
+
+	var payment models.BillPayment
+	if err := db.Where("inquiry_id = ?", inquiry.ID).First(&payment).Error; err != nil {
+		t.Fatalf("load payment: %v", err)
+	}
+	if payment.Status != models.BillPaymentFailed {
+		t.Errorf("payment status = %s, want %s", payment.Status, models.BillPaymentFailed)
+	}
+	var saving models.Transaction
+	if err := db.Where("parent_transaction_id = ?", payment.TransactionID).First(&saving).Error; err != nil {
+		t.Fatalf("load round-up: %v", err)
+	}
+	if saving.Status != models.TransactionFailed {
+		t.Errorf("round-up status = %s, want %s", saving.Status, models.TransactionFailed)
+	}
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"context"
+	"gopay-clone/models"
+)
+
+// Biller is implemented once per biller integration. Implementations return the
+// apperrors bill errors so handlers can show them as they are.
+type Biller interface {
+	// Inquire looks up what customerNumber owes. amount is only set for prepaid billers.
+	Inquire(ctx context.Context, biller *models.Biller, customerNumber string, amount float64) (*BillerInquiry, error)
+	// Pay settles an inquiry at the biller once the money has been taken from the customer
+	Pay(ctx context.Context, biller *models.Biller, inquiry *models.BillInquiry) (*BillerReceipt, error)
+}
+
+type BillerInquiry struct {
+	CustomerName string
+	Period       string
+	Amount       float64
+	Reference    string
+}
+
+type BillerReceipt struct {
+	ReceiptNumber string
+	Token         string
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BudgetService struct {
+	db                  *config.Database
+	notificationService *NotificationService
+}
+
+func NewBudgetService(db *config.Database, notificationService *NotificationService) *BudgetService {
+	return &BudgetService{db: db, notificationService: notificationService}
+}
+
+// SetBudget creates or replaces the monthly limit of one category
+func (s *BudgetService) SetBudget(budget *models.Budget) error {
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}},
+		DoUpdates: clause.AssignmentColumns([]string{"monthly_limit", "warn_at_percent", "updated_at"}),
+	}).Create(budget).Error
+	if err != nil {
+		return apperrors.ErrBudgetUpdateFailed
+	}
+	return nil
+}
+
+func (s *BudgetService) DeleteBudget(userID uint, category models.TransactionCategory) error {
+	result := s.db.Where("user_id = ? AND category = ?", userID, category).Delete(&models.Budget{})
+	if result.Error != nil {
+		return apperrors.ErrBudgetDeleteFailed
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrBudgetNotFound
+	}
+	return nil
+}
+
+// GetBudgets lists the user's budgets with this month's spending against each
+func (s *BudgetService) GetBudgets(userID uint) ([]models.BudgetStatus, error) {
+	var budgets []models.Budget
+	if err := s.db.Where("user_id = ?", userID).Order("category ASC").Find(&budgets).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	spent, err := s.spentThisMonth(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]models.BudgetStatus, 0, len(budgets))
+	for _, b := range budgets {
+		used := spent[b.Category]
+		statuses = append(statuses, models.BudgetStatus{
+			Budget:      b,
+			Spent:       roundMoney(used),
+			Remaining:   roundMoney(max(b.MonthlyLimit-used, 0)),
+			UsedPercent: roundMoney(used / b.MonthlyLimit * 100),
+			OverBudget:  used > b.MonthlyLimit,
+		})
+	}
+	return statuses, nil
+}
+
+// CheckPayment tells whether paying amount in a category would cross the warning
+// threshold or the limit of the user's budget, nothing is blocked
+func (s *BudgetService) CheckPayment(userID uint, category models.TransactionCategory, amount float64) (*models.BudgetCheck, error) {
+	check := &models.BudgetCheck{Category: category, Amount: amount}
+
+	var budget models.Budget
+	if err := s.db.Where("user_id = ? AND category = ?", userID, category).First(&budget).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return check, nil
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	spent, err := s.spentThisMonth(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	check.HasBudget = true
+	check.Limit = budget.MonthlyLimit
+	check.WarnAt = roundMoney(budget.MonthlyLimit * float64(budget.WarnAtPercent) / 100)
+	check.SpentBefore = roundMoney(spent[category])
+	check.SpentAfter = roundMoney(spent[category] + amount)
+	check.OverBudget = check.SpentAfter > budget.MonthlyLimit
+	check.Warning = check.SpentAfter >= check.WarnAt
+	return check, nil
+}
+
+// NotifyAfterPayment runs once a payment went through. The payer gets one notification
+// when the category crosses the warning threshold and one when it goes over the limit,
+// not one for every payment after that.
+func (s *BudgetService) NotifyAfterPayment(transaction *models.Transaction) error {
+	var sender, receiver models.Account
+	if err := s.db.First(&sender, transaction.SenderAccountID).Error; err != nil {
+		return apperrors.ErrAccountNotFound
+	}
+	if err := s.db.First(&receiver, transaction.ReceiverAccountID).Error; err != nil {
+		return apperrors.ErrAccountNotFound
+	}
+	if sender.UserId == receiver.UserId {
+		return nil
+	}
+
+	category := transaction.Category
+	if category == "" {
+		category = models.Other
+	}
+	// the payment is already counted in this month's spending
+	check, err := s.CheckPayment(sender.UserId, category, 0)
+	if err != nil || !check.HasBudget {
+		return err
+	}
+	before := check.SpentAfter - transaction.Amount
+	body := fmt.Sprintf("You have spent %.2f of your %.2f %s budget this month.", check.SpentAfter, check.Limit, category)
+
+	switch {
+	case before <= check.Limit && check.SpentAfter > check.Limit:
+		return s.notificationService.Notify(sender.UserId, models.NotificationBudgetExceeded,
+			fmt.Sprintf("Your %s budget is used up", category), body)
+	case before < check.WarnAt && check.SpentAfter >= check.WarnAt:
+		return s.notificationService.Notify(sender.UserId, models.NotificationBudgetWarning,
+			fmt.Sprintf("You are close to your %s budget", category), body)
+	}
+	return nil
+}
+
+func (s *BudgetService) spentThisMonth(userID uint) (map[models.TransactionCategory]float64, error) {
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	var rows []struct {
+		Category models.TransactionCategory
+		Total    float64
+	}
+	if err := userSpending(s.db.DB, userID).
+		Select("transactions.category AS category, SUM(transactions.amount) AS total").
+		Where("transactions.created_at >= ?", monthStart).
+		Group("transactions.category").
+		Scan(&rows).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	spent := make(map[models.TransactionCategory]float64, len(rows))
+	for _, r := range rows {
+		spent[r.Category] = r.Total
+	}
+	return spent, nil
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+
+	"gorm.io/gorm"
+)
+
+type ContactService struct {
+	db *config.Database
+}
+
+func NewContactService(db *config.Database) *ContactService {
+	return &ContactService{db: db}
+}
+
+func (s *ContactService) AddContact(contact *models.Contact) error {
+	if contact.OwnerID == contact.TargetID {
+		return apperrors.ErrContactSelf
+	}
+
+	var target models.User
+	if err := s.db.First(&target, contact.TargetID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperrors.ErrUserNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+
+	var existing models.Contact
+	if err := s.db.Where("owner_id = ? AND target_id = ?", contact.OwnerID, contact.TargetID).First(&existing).Error; err == nil {
+		return apperrors.ErrContactExists
+	}
+
+	if err := s.db.Create(contact).Error; err != nil {
+		return apperrors.ErrContactCreateFailed
+	}
+	contact.Target = target
+	return nil
+}
+
+func (s *ContactService) GetContacts(ownerID uint, page *utils.PageParams) ([]models.Contact, *utils.PageMeta, error) {
+	var contacts []models.Contact
+	if err := page.Apply(s.db.Preload("Target").Where("owner_id = ?", ownerID)).Find(&contacts).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	contacts, meta := utils.Page(contacts, page, func(c models.Contact) (uint, map[string]any) {
+		return c.ID, map[string]any{"created_at": c.CreatedAt, "nickname": c.Nickname}
+	})
+	return contacts, meta, nil
+}
+
+// GetContact only returns contacts owned by ownerID, someone else's contact is not found
+func (s *ContactService) GetContact(ownerID, id uint) (*models.Contact, error) {
+	var contact models.Contact
+	if err := s.db.Preload("Target").Where("owner_id = ?", ownerID).First(&contact, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrContactNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &contact, nil
+}
+
+func (s *ContactService) UpdateContact(ownerID, id uint, updates map[string]any) error {
+	result := s.db.Model(&models.Contact{}).Where("id = ? AND owner_id = ?", id, ownerID).Updates(updates)
+	if result.Error != nil {
+		return apperrors.ErrContactUpdateFailed
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrContactNotFound
+	}
+	return nil
+}
+
+func (s *ContactService) DeleteContact(ownerID, id uint) error {
+	result := s.db.Where("owner_id = ?", ownerID).Delete(&models.Contact{}, id)
+	if result.Error != nil {
+		return apperrors.ErrContactDeleteFailed
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrContactNotFound
+	}
+	return nil
+}
+
+// LookupUser finds a registered user by exact phone or email so they can be added
+func (s *ContactService) LookupUser(ownerID uint, phone, email string) (*models.UserLookup, error) {
+	query := s.db.Model(&models.User{})
+	if phone != "" {
+		query = query.Where("phone = ?", phone)
+	} else {
+		query = query.Where("LOWER(email) = LOWER(?)", email)
+	}
+
+	var user models.User
+	if err := query.First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	var count int64
+	s.db.Model(&models.Contact{}).Where("owner_id = ? AND target_id = ?", ownerID, user.ID).Count(&count)
+
+	return &models.UserLookup{
+		ID:                user.ID,
+		Name:              user.Name,
+		Type:              user.Type,
+		ProfilePictureURL: user.ProfilePictureURL,
+		IsContact:         count > 0,
+	}, nil
+}
+
+// GetRecentCounterparties lists the people the user last moved money with, newest first
+func (s *ContactService) GetRecentCounterparties(userID uint, limit int) ([]models.RecentCounterparty, error) {
+	var recent []models.RecentCounterparty
+	err := s.db.Table("transactions").
+		Select(`CASE WHEN sender.user_id = ? THEN receiver.user_id ELSE sender.user_id END AS user_id,
+			MAX(transactions.created_at) AS last_transaction_at,
+			COUNT(*) AS transaction_count`, userID).
+		Joins("JOIN accounts sender ON sender.id = transactions.sender_account_id").
+		Joins("JOIN accounts receiver ON receiver.id = transactions.receiver_account_id").
+		Where("(sender.user_id = ? OR receiver.user_id = ?) AND sender.user_id <> receiver.user_id", userID, userID).
+		Where("transactions.status <> ?", models.TransactionFailed).
+		Group("1").
+		Order("last_transaction_at DESC").
+		Limit(limit).
+		Scan(&recent).Error
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if len(recent) == 0 {
+		return recent, nil
+	}
+
+	ids := make([]uint, 0, len(recent))
+	for _, r := range recent {
+		ids = append(ids, r.UserID)
+	}
+
+	var users []models.User
+	if err := s.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	var contacts []models.Contact
+	if err := s.db.Where("owner_id = ? AND target_id IN ?", userID, ids).Find(&contacts).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	usersByID := make(map[uint]models.User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+	contactsByTarget := make(map[uint]uint, len(contacts))
+	for _, c := range contacts {
+		contactsByTarget[c.TargetID] = c.ID
+	}
+	for i := range recent {
+		u := usersByID[recent[i].UserID]
+		recent[i].Name = u.Name
+		recent[i].ProfilePictureURL = u.ProfilePictureURL
+		if contactID, ok := contactsByTarget[recent[i].UserID]; ok {
+			recent[i].ContactID = &contactID
+		}
+	}
+	return recent, nil
+}
@@ -4,6 +4,8 @@ import (
 	"gopay-clone/config"
 	apperrors "gopay-clone/errors"
 	"gopay-clone/models"
+	"gopay-clone/utils"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -51,12 +53,13 @@ func (s *DriverService) GetDriverByUserID(userID uint) (*models.DriverProfile, e
 	return &driver, nil
 }
 
-func (s *DriverService) GetAllDrivers() ([]models.DriverProfile, error) {
+func (s *DriverService) GetAllDrivers(page *utils.PageParams) ([]models.DriverProfile, *utils.PageMeta, error) {
 	var drivers []models.DriverProfile
-	if err := s.db.Preload("User").Find(&drivers).Error; err != nil {
-		return nil, apperrors.ErrDatabaseError
+	if err := page.Apply(s.db.Preload("User")).Find(&drivers).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
 	}
-	return drivers, nil
+	drivers, meta := utils.Page(drivers, page, driverPageKeys)
+	return drivers, meta, nil
 }
 
 func (s *DriverService) GetAvailableDrivers() ([]models.DriverProfile, error) {
@@ -72,6 +75,22 @@ func (s *DriverService) GetAvailableDrivers() ([]models.DriverProfile, error) {
 	return drivers, nil
 }
 
+// GetAvailableDriversPage is the paginated listing behind GET /drivers/available,
+// vehicle type comes in through the page filters
+func (s *DriverService) GetAvailableDriversPage(page *utils.PageParams) ([]models.DriverProfile, *utils.PageMeta, error) {
+	var drivers []models.DriverProfile
+	query := s.db.Preload("User").Where("status = ? AND is_verified = ?", "online", true)
+	if err := page.Apply(query).Find(&drivers).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	drivers, meta := utils.Page(drivers, page, driverPageKeys)
+	return drivers, meta, nil
+}
+
+func driverPageKeys(d models.DriverProfile) (uint, map[string]any) {
+	return d.ID, map[string]any{"created_at": d.CreatedAt, "rating": d.Rating}
+}
+
 func (s *DriverService) GetDriversByVehicleType(vehicleType models.VehicleType) ([]models.DriverProfile, error) {
 	var drivers []models.DriverProfile
 	if err := s.db.Preload("User").Where("vehicle_type = ? AND status = ? AND is_verified = ?", vehicleType, "online", true).Find(&drivers).Error; err != nil {
@@ -137,8 +156,22 @@ func (s *DriverService) DeleteDriver(id uint) error {
 	return nil
 }
 
-func (s *DriverService) VerifyDriver(id uint) error {
-	result := s.db.Model(&models.DriverProfile{}).Where("id = ?", id).Update("is_verified", true)
+// VerifyDriver approves the license documents, only verified drivers show up as available
+func (s *DriverService) VerifyDriver(id uint, note string) error {
+	return s.review(id, models.VerificationVerified, note)
+}
+
+func (s *DriverService) RejectDriver(id uint, reason string) error {
+	return s.review(id, models.VerificationRejected, reason)
+}
+
+func (s *DriverService) review(id uint, verification models.DriverVerification, note string) error {
+	result := s.db.Model(&models.DriverProfile{}).Where("id = ?", id).Updates(map[string]any{
+		"verification": verification,
+		"is_verified":  verification == models.VerificationVerified,
+		"review_note":  note,
+		"reviewed_at":  time.Now(),
+	})
 	if result.Error != nil {
 		return apperrors.ErrDriverProfileUpdateFailed
 	}
@@ -149,3 +182,45 @@ func (s *DriverService) VerifyDriver(id uint) error {
 
 	return nil
 }
+
+// SuspendDriver takes the driver off the road until an admin reinstates them
+func (s *DriverService) SuspendDriver(id uint, reason string) error {
+	result := s.db.Model(&models.DriverProfile{}).Where("id = ?", id).Updates(map[string]any{
+		"status":            models.Suspended,
+		"suspension_reason": reason,
+	})
+	if result.Error != nil {
+		return apperrors.ErrDriverStatusUpdateFailed
+	}
+
+	if result.RowsAffected == 0 {
+		return apperrors.ErrDriverNotFound
+	}
+
+	return nil
+}
+
+func (s *DriverService) ReinstateDriver(id uint) error {
+	driver, err := s.GetDriverByID(id)
+	if err != nil {
+		return err
+	}
+	if driver.Status != models.Suspended {
+		return apperrors.ErrDriverNotSuspended
+	}
+	if err := s.db.Model(driver).Updates(map[string]any{"status": models.Offline, "suspension_reason": ""}).Error; err != nil {
+		return apperrors.ErrDriverStatusUpdateFailed
+	}
+	return nil
+}
+
+// GetDriversForReview lists every driver with its user for the admin, the page filters
+// narrow it down by verification or status
+func (s *DriverService) GetDriversForReview(page *utils.PageParams) ([]models.DriverProfile, *utils.PageMeta, error) {
+	var drivers []models.DriverProfile
+	if err := page.Apply(s.db.Preload("User")).Find(&drivers).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	drivers, meta := utils.Page(drivers, page, driverPageKeys)
+	return drivers, meta, nil
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"log"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const revokePasswordReset = "password_reset"
+
+type EmailService struct {
+	db     *config.Database
+	cfg    config.MailConfig
+	mailer Mailer
+}
+
+func NewEmailService(db *config.Database, cfg config.MailConfig, mailer Mailer) *EmailService {
+	return &EmailService{db: db, cfg: cfg, mailer: mailer}
+}
+
+// RequireVerified fails when payments need a verified email and the user has not verified theirs
+func (s *EmailService) RequireVerified(userID uint) error {
+	if !s.cfg.RequireVerifiedForPayments {
+		return nil
+	}
+	var user models.User
+	if err := s.db.Select("id", "email_verified_at").First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperrors.ErrUserNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	if user.EmailVerifiedAt == nil {
+		return apperrors.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// SendVerification mails a verification link to the current email of the user
+func (s *EmailService) SendVerification(ctx context.Context, userID uint) error {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperrors.ErrUserNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	if user.EmailVerifiedAt != nil {
+		return apperrors.ErrEmailAlreadyVerified
+	}
+
+	raw, err := s.issue(&user, models.EmailVerify, s.cfg.VerifyTTL)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Hi %s,\n\nConfirm your email for GoClone by opening the link below, it works for %d hours:\n\n%s\n\nIf you did not sign up you can ignore this mail.",
+		user.Name, int(s.cfg.VerifyTTL.Hours()), s.link("/verify-email", raw))
+	if err := s.mailer.Send(ctx, user.Email, "Verify your GoClone email", body); err != nil {
+		return apperrors.ErrEmailSendFailed
+	}
+	return nil
+}
+
+// VerifyEmail spends a verification token
+func (s *EmailService) VerifyEmail(rawToken string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		token, user, err := consumeEmailTokenTx(tx, rawToken, models.EmailVerify)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(user).Update("email_verified_at", token.UsedAt).Error; err != nil {
+			return apperrors.ErrUserUpdateFailed
+		}
+		return nil
+	})
+}
+
+// RequestPasswordReset mails a reset link. Unknown emails and a too early resend are not
+// reported so the endpoint can not be used to find out who is registered.
+func (s *EmailService) RequestPasswordReset(ctx context.Context, email string) error {
+	var user models.User
+	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return apperrors.ErrDatabaseError
+	}
+
+	raw, err := s.issue(&user, models.PasswordReset, s.cfg.ResetTTL)
+	if err == apperrors.ErrEmailCooldown {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your GoClone account. Open the link below to choose a new one, it works for %d minutes:\n\n%s\n\nIf this was not you, ignore this mail and your password stays the same.",
+		user.Name, int(s.cfg.ResetTTL.Minutes()), s.link("/reset-password", raw))
+	if err := s.mailer.Send(ctx, user.Email, "Reset your GoClone password", body); err != nil {
+		// the caller always answers the same, a failed delivery only shows up in the log
+		log.Printf("password reset mail to user %d failed: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword spends a reset token and sets the new password. Every session of the
+// user is ended, whoever knew the old password is logged out too.
+func (s *EmailService) ResetPassword(rawToken, password string) error {
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return apperrors.ErrUserUpdateFailed
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		token, user, err := consumeEmailTokenTx(tx, rawToken, models.PasswordReset)
+		if err != nil {
+			return err
+		}
+		updates := map[string]any{"password": hashed}
+		// opening the link proves the inbox belongs to the user as well
+		if user.EmailVerifiedAt == nil {
+			updates["email_verified_at"] = token.UsedAt
+		}
+		if err := tx.Model(user).Updates(updates).Error; err != nil {
+			return apperrors.ErrUserUpdateFailed
+		}
+		return revokeSessionsTx(tx, revokePasswordReset, "user_id = ?", user.ID)
+	})
+}
+
+// NotifyNewDevice tells the user about a login from a device they did not use before
+func (s *EmailService) NotifyNewDevice(ctx context.Context, user *models.User, device models.DeviceInfo) error {
+	body := fmt.Sprintf("Hi %s,\n\nYour GoClone account was just logged in from a new device:\n\nDevice: %s\nIP address: %s\nTime: %s\n\nIf this was not you, end the session under active sessions in the app and change your password.",
+		user.Name, device.Name, device.IP, time.Now().Format(time.RFC1123))
+	if err := s.mailer.Send(ctx, user.Email, "New login to your GoClone account", body); err != nil {
+		return apperrors.ErrEmailSendFailed
+	}
+	return nil
+}
+
+// issue replaces any open token of the purpose with a new one and returns the raw token
+func (s *EmailService) issue(user *models.User, purpose models.EmailTokenPurpose, ttl time.Duration) (string, error) {
+	now := time.Now()
+	var last models.EmailToken
+	err := s.db.Where("user_id = ? AND purpose = ?", user.ID, purpose).Order("created_at DESC").First(&last).Error
+	if err == nil && now.Sub(last.CreatedAt) < s.cfg.ResendCooldown {
+		return "", apperrors.ErrEmailCooldown
+	}
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return "", apperrors.ErrDatabaseError
+	}
+
+	raw, hash, err := utils.NewOpaqueToken()
+	if err != nil {
+		return "", apperrors.ErrEmailTokenCreateFailed
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.EmailToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, purpose).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EmailToken{
+			UserID:    user.ID,
+			Purpose:   purpose,
+			Email:     user.Email,
+			TokenHash: hash,
+			ExpiresAt: now.Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", apperrors.ErrEmailTokenCreateFailed
+	}
+	return raw, nil
+}
+
+func (s *EmailService) link(path, rawToken string) string {
+	return s.cfg.AppURL + path + "?token=" + url.QueryEscape(rawToken)
+}
+
+// consumeEmailTokenTx marks the token used and loads its user. A token sent to an address
+// the user has since changed does not work anymore.
+func consumeEmailTokenTx(tx *gorm.DB, rawToken string, purpose models.EmailTokenPurpose) (*models.EmailToken, *models.User, error) {
+	var token models.EmailToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ? AND purpose = ?", utils.HashToken(rawToken), purpose).
+		First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, apperrors.ErrInvalidEmailToken
+		}
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	now := time.Now()
+	if token.UsedAt != nil || token.ExpiresAt.Before(now) {
+		return nil, nil, apperrors.ErrInvalidEmailToken
+	}
+
+	var user models.User
+	if err := tx.First(&user, token.UserID).Error; err != nil {
+		return nil, nil, apperrors.ErrInvalidEmailToken
+	}
+	if user.Email != token.Email {
+		return nil, nil, apperrors.ErrInvalidEmailToken
+	}
+
+	token.UsedAt = &now
+	if err := tx.Model(&token).Update("used_at", now).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	return &token, &user, nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EventHandler handles one outbox event. Events are delivered at least once, a handler may
+// see the same event again after a crash and has to be idempotent.
+type EventHandler func(ctx context.Context, event *models.OutboxEvent) error
+
+type subscriber struct {
+	name   string
+	handle EventHandler
+}
+
+// EventBus routes outbox events to the in-process subscribers of their type
+type EventBus struct {
+	subscribers map[models.EventType][]subscriber
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[models.EventType][]subscriber)}
+}
+
+// Subscribe registers handler for the event types. The name is recorded per handled event,
+// it has to be unique and stay the same across releases.
+func (b *EventBus) Subscribe(name string, handler EventHandler, types ...models.EventType) {
+	for _, eventType := range types {
+		b.subscribers[eventType] = append(b.subscribers[eventType], subscriber{name: name, handle: handler})
+	}
+}
+
+func (b *EventBus) subscribersOf(eventType models.EventType) []subscriber {
+	return b.subscribers[eventType]
+}
+
+// publishEventTx writes an event to the outbox inside the transaction of the change, it is
+// only dispatched when that transaction commits
+func publishEventTx(tx *gorm.DB, eventType models.EventType, aggregateID uint, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return apperrors.ErrEventPublishFailed
+	}
+	event := models.OutboxEvent{
+		Type:          eventType,
+		AggregateID:   aggregateID,
+		Payload:       string(payload),
+		Status:        models.OutboxPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return apperrors.ErrEventPublishFailed
+	}
+	return nil
+}
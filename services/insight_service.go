@@ -0,0 +1,73 @@
+package services
+
+import (
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type InsightService struct {
+	db *config.Database
+}
+
+func NewInsightService(db *config.Database) *InsightService {
+	return &InsightService{db: db}
+}
+
+// GetSpending groups what a user paid to other people between from and to
+func (s *InsightService) GetSpending(userID uint, groupBy models.SpendingGroup, from, to time.Time) (*models.SpendingReport, error) {
+	query := userSpending(s.db.DB, userID).Where("transactions.created_at >= ? AND transactions.created_at <= ?", from, to)
+
+	switch groupBy {
+	case models.SpendingByCategory:
+		query = query.Select(`transactions.category AS key, transactions.category AS label,
+			SUM(transactions.amount) AS total, COUNT(*) AS count`).
+			Group("transactions.category")
+	case models.SpendingByMerchant:
+		query = query.Joins("JOIN merchant_profiles ON merchant_profiles.user_id = receiver.user_id").
+			Select(`CAST(merchant_profiles.id AS TEXT) AS key, merchant_profiles.merchant_name AS label,
+				SUM(transactions.amount) AS total, COUNT(*) AS count`).
+			Group("merchant_profiles.id, merchant_profiles.merchant_name")
+	case models.SpendingByMonth:
+		query = query.Select(`to_char(date_trunc('month', transactions.created_at), 'YYYY-MM') AS key,
+			to_char(date_trunc('month', transactions.created_at), 'Mon YYYY') AS label,
+			SUM(transactions.amount) AS total, COUNT(*) AS count`).
+			Group("date_trunc('month', transactions.created_at)")
+	}
+
+	// months read better in calendar order, the rest biggest spending first
+	order := "total DESC"
+	if groupBy == models.SpendingByMonth {
+		order = "key ASC"
+	}
+
+	var items []models.SpendingItem
+	if err := query.Order(order).Scan(&items).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	report := &models.SpendingReport{UserID: userID, GroupBy: groupBy, From: from, To: to, Items: items}
+	for _, item := range items {
+		report.Total += item.Total
+	}
+	for i := range report.Items {
+		if report.Total > 0 {
+			report.Items[i].Percent = roundMoney(report.Items[i].Total / report.Total * 100)
+		}
+	}
+	report.Total = roundMoney(report.Total)
+	return report, nil
+}
+
+// userSpending is every payment that left one of the user's accounts for someone else,
+// moving money between the user's own accounts is not spending
+func userSpending(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Model(&models.Transaction{}).
+		Joins("JOIN accounts sender ON sender.id = transactions.sender_account_id").
+		Joins("JOIN accounts receiver ON receiver.id = transactions.receiver_account_id").
+		Where("sender.user_id = ? AND receiver.user_id <> ?", userID, userID).
+		Where("transactions.status NOT IN ?", []models.TransactionStatus{models.TransactionFailed, models.TransactionCancelled})
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// LimiterStore keeps the expiring counters behind rate limits and login lockouts.
+// The in-memory store is per process, a shared store only has to implement this.
+type LimiterStore interface {
+	// Incr adds one to key, a missing or expired key starts again at 1 and expires after ttl
+	Incr(key string, ttl time.Duration) (int, time.Time)
+	// Get returns the count of key and when it expires, 0 when it is missing or expired
+	Get(key string) (int, time.Time)
+	Set(key string, count int, expiresAt time.Time)
+	Delete(key string)
+}
+
+const limiterSweepInterval = time.Minute
+
+type limiterEntry struct {
+	count     int
+	expiresAt time.Time
+}
+
+type MemoryLimiterStore struct {
+	mu        sync.Mutex
+	entries   map[string]limiterEntry
+	lastSweep time.Time
+}
+
+func NewMemoryLimiterStore() *MemoryLimiterStore {
+	return &MemoryLimiterStore{entries: make(map[string]limiterEntry), lastSweep: time.Now()}
+}
+
+func (s *MemoryLimiterStore) Incr(key string, ttl time.Duration) (int, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+	entry, ok := s.entries[key]
+	if !ok || !entry.expiresAt.After(now) {
+		entry = limiterEntry{expiresAt: now.Add(ttl)}
+	}
+	entry.count++
+	s.entries[key] = entry
+	return entry.count, entry.expiresAt
+}
+
+func (s *MemoryLimiterStore) Get(key string) (int, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || !entry.expiresAt.After(time.Now()) {
+		return 0, time.Time{}
+	}
+	return entry.count, entry.expiresAt
+}
+
+func (s *MemoryLimiterStore) Set(key string, count int, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(time.Now())
+	s.entries[key] = limiterEntry{count: count, expiresAt: expiresAt}
+}
+
+func (s *MemoryLimiterStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+}
+
+// sweep drops expired keys now and then so the map does not grow with every IP seen
+func (s *MemoryLimiterStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < limiterSweepInterval {
+		return
+	}
+	for key, entry := range s.entries {
+		if !entry.expiresAt.After(now) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"strings"
+	"time"
+)
+
+// LoginGuard locks an account, and separately an IP, after too many wrong passwords.
+// Each failure past the limit doubles the lockout, a correct password clears the account.
+type LoginGuard struct {
+	cfg   config.LoginGuardConfig
+	store LimiterStore
+}
+
+func NewLoginGuard(cfg config.LoginGuardConfig, store LimiterStore) *LoginGuard {
+	return &LoginGuard{cfg: cfg, store: store}
+}
+
+// Check refuses the attempt while the account or the IP is locked
+func (g *LoginGuard) Check(email, ip string) error {
+	now := time.Now()
+	var until time.Time
+	for _, key := range []string{accountKey(email), ipKey(ip)} {
+		if locked, expiresAt := g.store.Get("lock:" + key); locked > 0 && expiresAt.After(until) {
+			until = expiresAt
+		}
+	}
+	if until.After(now) {
+		return apperrors.NewLoginLockedError(until.Sub(now))
+	}
+	return nil
+}
+
+func (g *LoginGuard) Fail(email, ip string) {
+	g.fail(accountKey(email), g.cfg.MaxFailures)
+	g.fail(ipKey(ip), g.cfg.IPMaxFailures)
+}
+
+// Succeed forgets the failures of the account. The IP keeps its count, one account the
+// attacker owns should not buy more guesses at the others.
+func (g *LoginGuard) Succeed(email string) {
+	g.store.Delete(accountKey(email))
+	g.store.Delete("lock:" + accountKey(email))
+}
+
+func (g *LoginGuard) fail(key string, limit int) {
+	if limit <= 0 {
+		return
+	}
+	failures, _ := g.store.Incr(key, g.cfg.FailureWindow)
+	if failures < limit {
+		return
+	}
+	lockout := g.cfg.Lockout
+	for i := limit; i < failures && lockout < g.cfg.MaxLockout; i++ {
+		lockout *= 2
+	}
+	lockout = min(lockout, g.cfg.MaxLockout)
+
+	now := time.Now()
+	g.store.Set("lock:"+key, 1, now.Add(lockout))
+	// the count has to outlive the lockout, otherwise the next failure starts at the first step again
+	g.store.Set(key, failures, now.Add(lockout+g.cfg.FailureWindow))
+}
+
+func accountKey(email string) string {
+	return "login:account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "login:ip:" + ip
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gopay-clone/config"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mailer delivers plain text emails
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// NewMailer picks the mailer from MAIL_DRIVER, log and file are meant for development
+func NewMailer(cfg config.MailConfig) Mailer {
+	switch cfg.Driver {
+	case "smtp":
+		return &SMTPMailer{cfg: cfg}
+	case "file":
+		return &FileMailer{path: cfg.File}
+	}
+	return LogMailer{}
+}
+
+// LogMailer writes mails to the application log
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, to, subject, body string) error {
+	log.Printf("mail to %s: %s\n%s", to, subject, body)
+	return nil
+}
+
+// FileMailer appends every mail to a file, handy to click the links locally
+type FileMailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (m *FileMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z), to, subject, body)
+	return err
+}
+
+// SMTPMailer sends through an SMTP server, STARTTLS is used when the server offers it
+type SMTPMailer struct {
+	cfg config.MailConfig
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, to, subject, body string) error {
+	var auth smtp.Auth
+	if m.cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", m.cfg.SMTPUsername, m.cfg.SMTPPassword, m.cfg.SMTPHost)
+	}
+	msg := strings.Join([]string{
+		"From: " + m.cfg.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+	return smtp.SendMail(net.JoinHostPort(m.cfg.SMTPHost, m.cfg.SMTPPort), auth, m.cfg.From, []string{to}, []byte(msg))
+}
@@ -4,6 +4,7 @@ import (
 	"gopay-clone/config"
 	apperrors "gopay-clone/errors"
 	"gopay-clone/models"
+	"gopay-clone/utils"
 
 	"gorm.io/gorm"
 )
@@ -23,14 +24,16 @@ func (s *MenuItemService) CreateMenu(menu *models.MenuItem) error {
 	return nil
 }
 
-func (s *MenuItemService) GetAllMenusFromMerchant(merchantId uint) ([]models.MenuItem, error) {
+func (s *MenuItemService) GetAllMenusFromMerchant(merchantId uint, page *utils.PageParams) ([]models.MenuItem, *utils.PageMeta, error) {
 	var menuItems []models.MenuItem
-	if err := s.db.Where("merchant_id = ?", merchantId).
-		Order("category ASC, name ASC").
+	if err := page.Apply(s.db.Where("merchant_id = ?", merchantId)).
 		Find(&menuItems).Error; err != nil {
-		return nil, apperrors.ErrDatabaseError
+		return nil, nil, apperrors.ErrDatabaseError
 	}
-	return menuItems, nil
+	menuItems, meta := utils.Page(menuItems, page, func(m models.MenuItem) (uint, map[string]any) {
+		return m.ID, map[string]any{"category": string(m.Category), "created_at": m.CreatedAt, "name": m.Name, "price": m.Price, "total_sold": m.TotalSold}
+	})
+	return menuItems, meta, nil
 }
 
 func (s *MenuItemService) GetMenuItemByID(id uint) (*models.MenuItem, error) {
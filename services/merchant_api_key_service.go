@@ -0,0 +1,190 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type MerchantApiKeyService struct {
+	db  *config.Database
+	cfg config.MerchantApiConfig
+}
+
+func NewMerchantApiKeyService(db *config.Database, cfg config.MerchantApiConfig) *MerchantApiKeyService {
+	return &MerchantApiKeyService{db: db, cfg: cfg}
+}
+
+func (s *MerchantApiKeyService) GetKeys(merchantID uint) ([]models.MerchantApiKey, error) {
+	var keys []models.MerchantApiKey
+	if err := s.db.Where("merchant_id = ?", merchantID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return keys, nil
+}
+
+// CreateKey returns the new key with its secret, the secret can not be read again later
+func (s *MerchantApiKeyService) CreateKey(merchantID uint, name string, scopes []models.ApiScope) (*models.MerchantApiCredentials, error) {
+	var active int64
+	if err := s.db.Model(&models.MerchantApiKey{}).Where("merchant_id = ? AND revoked_at IS NULL", merchantID).Count(&active).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if int(active) >= s.cfg.MaxKeys {
+		return nil, apperrors.ErrApiKeyLimit
+	}
+
+	keyID, err := newKeyID()
+	if err != nil {
+		return nil, apperrors.ErrApiKeyCreateFailed
+	}
+	secret, sealed, err := s.newApiSecret()
+	if err != nil {
+		return nil, apperrors.ErrApiKeyCreateFailed
+	}
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	key := models.MerchantApiKey{
+		MerchantID:   merchantID,
+		Name:         name,
+		KeyID:        keyID,
+		SealedSecret: sealed,
+		Scopes:       strings.Join(names, ","),
+	}
+	if err := s.db.Create(&key).Error; err != nil {
+		return nil, apperrors.ErrApiKeyCreateFailed
+	}
+	return &models.MerchantApiCredentials{Key: key, Secret: secret}, nil
+}
+
+// RotateKey gives the key a new secret, the old one keeps working for the rotation grace
+// period so the merchant can roll out the new one without downtime
+func (s *MerchantApiKeyService) RotateKey(merchantID, id uint) (*models.MerchantApiCredentials, error) {
+	key, err := s.activeKey(merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	secret, sealed, err := s.newApiSecret()
+	if err != nil {
+		return nil, apperrors.ErrApiKeyCreateFailed
+	}
+	graceUntil := time.Now().Add(s.cfg.RotationGrace)
+	if err := s.db.Model(key).Updates(map[string]any{
+		"sealed_secret":          sealed,
+		"previous_sealed_secret": key.SealedSecret,
+		"previous_valid_until":   graceUntil,
+	}).Error; err != nil {
+		return nil, apperrors.ErrApiKeyUpdateFailed
+	}
+	key.PreviousSealedSecret = key.SealedSecret
+	key.SealedSecret = sealed
+	key.PreviousValidUntil = &graceUntil
+	return &models.MerchantApiCredentials{Key: *key, Secret: secret}, nil
+}
+
+// RevokeKey stops the key at once, including a secret still in its rotation grace period
+func (s *MerchantApiKeyService) RevokeKey(merchantID, id uint) error {
+	key, err := s.activeKey(merchantID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Model(key).Update("revoked_at", time.Now()).Error; err != nil {
+		return apperrors.ErrApiKeyUpdateFailed
+	}
+	return nil
+}
+
+// Authenticate checks a signed merchant API request and returns its key
+func (s *MerchantApiKeyService) Authenticate(keyID, timestamp, signature, method, path string, body []byte) (*models.MerchantApiKey, error) {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, apperrors.ErrSignatureExpired
+	}
+	now := time.Now()
+	skew := now.Sub(time.Unix(unix, 0))
+	if skew > s.cfg.SignatureTolerance || skew < -s.cfg.SignatureTolerance {
+		return nil, apperrors.ErrSignatureExpired
+	}
+
+	var key models.MerchantApiKey
+	if err := s.db.Where("key_id = ? AND revoked_at IS NULL", keyID).First(&key).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrInvalidApiKey
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	valid, err := s.signedWith(key.SealedSecret, signature, method, path, timestamp, body)
+	if err != nil {
+		return nil, err
+	}
+	if !valid && key.PreviousValidUntil != nil && key.PreviousValidUntil.After(now) {
+		if valid, err = s.signedWith(key.PreviousSealedSecret, signature, method, path, timestamp, body); err != nil {
+			return nil, err
+		}
+	}
+	if !valid {
+		return nil, apperrors.ErrInvalidSignature
+	}
+
+	if err := s.db.Model(&key).Update("last_used_at", now).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &key, nil
+}
+
+// Tolerance is how long a signature could be accepted, replays are remembered that long
+func (s *MerchantApiKeyService) Tolerance() time.Duration {
+	return s.cfg.SignatureTolerance
+}
+
+func (s *MerchantApiKeyService) activeKey(merchantID, id uint) (*models.MerchantApiKey, error) {
+	var key models.MerchantApiKey
+	if err := s.db.Where("id = ? AND merchant_id = ? AND revoked_at IS NULL", id, merchantID).First(&key).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrApiKeyNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &key, nil
+}
+
+func newKeyID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "mk_" + hex.EncodeToString(buf), nil
+}
+
+// signedWith checks the signature against a sealed secret
+func (s *MerchantApiKeyService) signedWith(sealed, signature, method, path, timestamp string, body []byte) (bool, error) {
+	signingKey, err := utils.OpenSecret(s.cfg.EncryptionKey, sealed)
+	if err != nil {
+		return false, apperrors.NewInternalError("Failed to read API key")
+	}
+	return utils.SignatureEqual(signature, utils.SignRequest(signingKey, method, path, timestamp, body)), nil
+}
+
+// newApiSecret returns the secret for the merchant and the same secret sealed for storage
+func (s *MerchantApiKeyService) newApiSecret() (string, string, error) {
+	raw, _, err := utils.NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	secret := "sk_" + raw
+	sealed, err := utils.SealSecret(s.cfg.EncryptionKey, secret)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, sealed, nil
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"fmt"
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MerchantPaymentService is what merchant backends do through the merchant API
+type MerchantPaymentService struct {
+	db    *config.Database
+	cfg   config.MerchantApiConfig
+	qrCfg config.QrConfig
+}
+
+func NewMerchantPaymentService(db *config.Database, cfg config.MerchantApiConfig, qrCfg config.QrConfig) *MerchantPaymentService {
+	return &MerchantPaymentService{db: db, cfg: cfg, qrCfg: qrCfg}
+}
+
+// CreateDynamicQR creates a one-off QR for an exact amount, paid into the main balance of
+// the merchant's owner. A zero ttl uses the configured default.
+func (s *MerchantPaymentService) CreateDynamicQR(merchantID uint, amount float64, reference string, ttl time.Duration) (*models.QrCode, error) {
+	if ttl <= 0 {
+		ttl = s.cfg.QrTTL
+	}
+	ttl = min(ttl, s.cfg.MaxQrTTL)
+
+	account, err := s.merchantAccount(merchantID)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(ttl)
+	qr := models.QrCode{
+		Type:              models.QrDynamic,
+		ReceiverAccountID: account.ID,
+		Amount:            roundMoney(amount),
+		URL:               fmt.Sprintf("goclone://pay?merchant=%d&ref=%s", merchantID, reference),
+		ExpiresAt:         &expiresAt,
+		MerchantID:        &merchantID,
+		Reference:         reference,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&qr).Error; err != nil {
+			return apperrors.ErrQRCreateFailed
+		}
+		return storeQrPayloadTx(tx, s.qrCfg, &qr)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &qr, nil
+}
+
+// GetPayment tells the merchant whether a QR it created was paid, and what was refunded
+func (s *MerchantPaymentService) GetPayment(merchantID, qrID uint) (*models.MerchantPayment, error) {
+	var qr models.QrCode
+	if err := s.db.Where("id = ? AND merchant_id = ?", qrID, merchantID).First(&qr).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrQRNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if qr.IsStatic() {
+		return nil, apperrors.ErrQRStatic
+	}
+	payment := &models.MerchantPayment{QrCode: qr, Status: models.MerchantPaymentPending}
+
+	var transaction models.Transaction
+	err := s.db.Where("qr_code_id = ? AND status = ?", qr.ID, models.TransactionCompleted).First(&transaction).Error
+	if err == gorm.ErrRecordNotFound {
+		if qr.Expired(time.Now()) {
+			payment.Status = models.MerchantPaymentExpired
+		}
+		return payment, nil
+	}
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	payment.Transaction = &transaction
+	payment.Status = models.MerchantPaymentPaid
+
+	if err := s.db.Where("transaction_id = ?", transaction.ID).Order("created_at").Find(&payment.Refunds).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	for _, refund := range payment.Refunds {
+		payment.RefundedAmount = roundMoney(payment.RefundedAmount + refund.Amount)
+	}
+	if payment.RefundedAmount >= transaction.Amount {
+		payment.Status = models.MerchantPaymentRefunded
+	} else if payment.RefundedAmount > 0 {
+		payment.Status = models.MerchantPaymentPartiallyRefunded
+	}
+	return payment, nil
+}
+
+// Refund sends amount of a payment to the merchant back to the payer, a zero amount refunds
+// what is left. A reference that was used before is refused so retries do not pay twice.
+func (s *MerchantPaymentService) Refund(merchantID, transactionID uint, amount float64, reason, reference string) (*models.MerchantRefund, error) {
+	account, err := s.merchantAccount(merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var refund models.MerchantRefund
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if reference != "" {
+			var count int64
+			if err := tx.Model(&models.MerchantRefund{}).Where("merchant_id = ? AND reference = ?", merchantID, reference).Count(&count).Error; err != nil {
+				return apperrors.ErrDatabaseError
+			}
+			if count > 0 {
+				return apperrors.ErrRefundReferenceExists
+			}
+		}
+
+		// locking the payment keeps two refunds from both fitting in what is left
+		var payment models.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, transactionID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperrors.ErrTransactionNotFound
+			}
+			return apperrors.ErrDatabaseError
+		}
+		if payment.ReceiverAccountID != account.ID || payment.Status != models.TransactionCompleted || payment.Type == models.Refund {
+			return apperrors.ErrRefundNotAllowed
+		}
+
+		var refunded float64
+		if err := tx.Model(&models.MerchantRefund{}).Where("transaction_id = ?", payment.ID).
+			Select("COALESCE(SUM(amount), 0)").Scan(&refunded).Error; err != nil {
+			return apperrors.ErrDatabaseError
+		}
+		left := roundMoney(payment.Amount - refunded)
+		if amount == 0 {
+			amount = left
+		}
+		amount = roundMoney(amount)
+		if amount <= 0 || amount > left {
+			return apperrors.ErrRefundExceedsPayment
+		}
+
+		description := "Refund"
+		if reason != "" {
+			description = "Refund: " + reason
+		}
+		refundTransaction := models.Transaction{
+			Amount:            amount,
+			SenderAccountID:   payment.ReceiverAccountID,
+			ReceiverAccountID: payment.SenderAccountID,
+			Type:              models.Refund,
+			Category:          payment.Category,
+			Status:            models.TransactionCompleted,
+			ServiceType:       payment.ServiceType,
+			QrCodeID:          payment.QrCodeID,
+			Description:       description,
+		}
+		if err := createTransactionTx(tx, &refundTransaction); err != nil {
+			return err
+		}
+
+		refund = models.MerchantRefund{
+			MerchantID:          merchantID,
+			TransactionID:       payment.ID,
+			RefundTransactionID: refundTransaction.ID,
+			Amount:              amount,
+			Reason:              reason,
+			Reference:           reference,
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			return apperrors.ErrRefundFailed
+		}
+		return publishEventTx(tx, models.EventPaymentRefunded, refund.ID, refund)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (s *MerchantPaymentService) merchantAccount(merchantID uint) (*models.Account, error) {
+	var merchant models.MerchantProfile
+	if err := s.db.First(&merchant, merchantID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrMerchantNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	var account models.Account
+	if err := mainBalance(s.db.DB, merchant.UserId, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
@@ -4,16 +4,19 @@ import (
 	"gopay-clone/config"
 	apperrors "gopay-clone/errors"
 	"gopay-clone/models"
+	"gopay-clone/utils"
+	"log"
 
 	"gorm.io/gorm"
 )
 
 type MerchantService struct {
-	db *config.Database
+	db    *config.Database
+	logos *logoFetcher
 }
 
-func NewMerchantService(db *config.Database) *MerchantService {
-	return &MerchantService{db: db}
+func NewMerchantService(db *config.Database, cfg config.QrConfig) *MerchantService {
+	return &MerchantService{db: db, logos: newLogoFetcher(cfg)}
 }
 
 func (s *MerchantService) CreateMerchant(merchant *models.MerchantProfile) error {
@@ -21,18 +24,30 @@ func (s *MerchantService) CreateMerchant(merchant *models.MerchantProfile) error
 	if err := s.db.Where("user_id = ?", merchant.UserId).First(&existingMerchant).Error; err == nil {
 		return apperrors.ErrMerchantExists
 	}
-	if err := s.db.Create(merchant).Error; err != nil {
+	logo, err := s.fetchLogo(merchant.MerchantLogoURL)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(merchant).Error; err != nil {
+			return err
+		}
+		return storeMerchantLogoTx(tx, merchant.ID, merchant.MerchantLogoURL, logo)
+	}); err != nil {
 		return apperrors.ErrMerchantCreateFailed
 	}
 	return nil
 }
 
-func (s *MerchantService) GetAllMerchants() ([]models.MerchantProfile, error) {
+func (s *MerchantService) GetAllMerchants(page *utils.PageParams) ([]models.MerchantProfile, *utils.PageMeta, error) {
 	var merchants []models.MerchantProfile
-	if err := s.db.Find(&merchants).Error; err != nil {
-		return nil, apperrors.ErrDatabaseError
+	if err := page.Apply(s.db.Model(&models.MerchantProfile{})).Find(&merchants).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
 	}
-	return merchants, nil
+	merchants, meta := utils.Page(merchants, page, func(m models.MerchantProfile) (uint, map[string]any) {
+		return m.ID, map[string]any{"created_at": m.CreatedAt, "merchant_name": m.MerchantName, "rating": m.Rating}
+	})
+	return merchants, meta, nil
 }
 
 func (s *MerchantService) GetMerchantByID(id uint) (*models.MerchantProfile, error) {
@@ -60,24 +75,68 @@ func (s *MerchantService) GetMerchantByUserID(id uint) (*models.MerchantProfile,
 	return &merchant, nil
 }
 
+// UpdateMerchant saves the changed fields, a new logo URL is fetched before anything is saved
 func (s *MerchantService) UpdateMerchant(id uint, merchant map[string]any) error {
-	result := s.db.Model(&models.MerchantProfile{}).Where("id = ?", id).Updates(merchant)
-	if result.Error != nil {
-		return apperrors.ErrMerchantUpdateFailed
+	var current models.MerchantProfile
+	if err := s.db.Select("id", "merchant_logo_url").First(&current, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperrors.ErrMerchantNotFound
+		}
+		return apperrors.ErrDatabaseError
 	}
-	if result.RowsAffected == 0 {
-		return apperrors.ErrMerchantNotFound
+	logoURL, changeLogo := merchant["MerchantLogoURL"].(string)
+	changeLogo = changeLogo && logoURL != current.MerchantLogoURL
+	var logo []byte
+	if changeLogo {
+		var err error
+		if logo, err = s.fetchLogo(logoURL); err != nil {
+			return err
+		}
 	}
-	return nil
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.MerchantProfile{}).Where("id = ?", id).Updates(merchant)
+		if result.Error != nil {
+			return apperrors.ErrMerchantUpdateFailed
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.ErrMerchantNotFound
+		}
+		if changeLogo {
+			if err := storeMerchantLogoTx(tx, id, logoURL, logo); err != nil {
+				return apperrors.ErrMerchantUpdateFailed
+			}
+		}
+		return nil
+	})
+}
+
+func (s *MerchantService) fetchLogo(logoURL string) ([]byte, error) {
+	if logoURL == "" {
+		return nil, nil
+	}
+	logo, err := s.logos.fetch(logoURL)
+	if err != nil {
+		log.Printf("merchant logo %q: %v", logoURL, err)
+		return nil, apperrors.ErrMerchantLogoInvalid
+	}
+	return logo, nil
 }
 
 func (s *MerchantService) DeleteMerchant(id uint) error {
-	result := s.db.Delete(&models.MerchantProfile{}, id)
-	if result.Error != nil {
+	var deleted int64
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.MerchantProfile{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return storeMerchantLogoTx(tx, id, "", nil)
+	}); err != nil {
 		return apperrors.ErrMerchantDeleteFailed
 	}
 
-	if result.RowsAffected == 0 {
+	if deleted == 0 {
 		return apperrors.ErrMerchantNotFound
 	}
 
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gopay-clone/config"
+	"gopay-clone/models"
+)
+
+// NotificationFanout turns domain events into notifications for everyone involved
+type NotificationFanout struct {
+	db            *config.Database
+	notifications *NotificationService
+	cfg           config.NotificationConfig
+}
+
+func NewNotificationFanout(db *config.Database, notifications *NotificationService, cfg config.NotificationConfig) *NotificationFanout {
+	return &NotificationFanout{db: db, notifications: notifications, cfg: cfg}
+}
+
+func (f *NotificationFanout) Subscribe(bus *EventBus) {
+	bus.Subscribe("notifications.transactions", f.onTransaction, models.EventTransactionCreated)
+	bus.Subscribe("notifications.orders", f.onOrder, models.EventOrderPlaced, models.EventOrderStatusChanged)
+	bus.Subscribe("notifications.rides", f.onRide, models.EventRideCompleted)
+}
+
+// onTransaction tells the receiver about money coming in and the sender about a low main balance
+func (f *NotificationFanout) onTransaction(ctx context.Context, event *models.OutboxEvent) error {
+	var data models.TransactionEvent
+	if err := event.Decode(&data); err != nil {
+		return err
+	}
+	var sender, receiver models.Account
+	if err := f.db.First(&sender, data.SenderAccountID).Error; err != nil {
+		return err
+	}
+	if err := f.db.First(&receiver, data.ReceiverAccountID).Error; err != nil {
+		return err
+	}
+
+	// moving money between your own accounts is not news
+	if sender.UserId != receiver.UserId {
+		var from models.User
+		if err := f.db.Select("name").First(&from, sender.UserId).Error; err != nil {
+			return err
+		}
+		title := "Transfer received"
+		switch data.Type {
+		case models.Payment:
+			title = "Payment received"
+		case models.Refund:
+			title = "Refund received"
+		}
+		body := fmt.Sprintf("You received %.2f from %s.", data.Amount, from.Name)
+		if err := f.notifications.notifyEvent(event.ID, receiver.UserId, models.NotificationTransferReceived, title, body); err != nil {
+			return err
+		}
+	}
+
+	// only when this transaction took the balance below the threshold, not on every payment after
+	threshold := f.cfg.LowBalanceThreshold
+	if sender.AccountType == models.MainBalance && data.SenderBalance < threshold && data.SenderBalance+data.Amount >= threshold {
+		body := fmt.Sprintf("Your balance is down to %.2f, top up to keep paying without interruptions.", data.SenderBalance)
+		return f.notifications.notifyEvent(event.ID, sender.UserId, models.NotificationLowBalance, "Low balance", body)
+	}
+	return nil
+}
+
+// onOrder tells the customer how the order is doing and the driver about a new delivery
+func (f *NotificationFanout) onOrder(ctx context.Context, event *models.OutboxEvent) error {
+	var data models.OrderEvent
+	if err := event.Decode(&data); err != nil {
+		return err
+	}
+
+	if event.Type == models.EventOrderStatusChanged {
+		title := fmt.Sprintf("Order #%d %s", data.OrderID, orderStatusLabel(data.Status))
+		body := fmt.Sprintf("Your order of %.2f is now %s.", data.TotalAmount, data.Status)
+		return f.notifications.notifyEvent(event.ID, data.UserID, models.NotificationOrderStatus, title, body)
+	}
+
+	var merchant models.MerchantProfile
+	if err := f.db.Select("merchant_name").First(&merchant, data.MerchantID).Error; err != nil {
+		return err
+	}
+	if data.DriverID == nil {
+		return f.notifications.notifyEvent(event.ID, data.UserID, models.NotificationOrderStatus,
+			fmt.Sprintf("Order #%d placed", data.OrderID), fmt.Sprintf("%s received your order.", merchant.MerchantName))
+	}
+
+	var driver models.DriverProfile
+	if err := f.db.Preload("User").First(&driver, *data.DriverID).Error; err != nil {
+		return err
+	}
+	if err := f.notifications.notifyEvent(event.ID, data.UserID, models.NotificationDriverAssigned,
+		fmt.Sprintf("Order #%d placed", data.OrderID),
+		fmt.Sprintf("%s received your order, %s (%s) will deliver it.", merchant.MerchantName, driver.User.Name, driver.VehiclePlate)); err != nil {
+		return err
+	}
+	return f.notifications.notifyEvent(event.ID, driver.UserId, models.NotificationDriverAssigned,
+		"New delivery", fmt.Sprintf("Pick up order #%d at %s.", data.OrderID, merchant.MerchantName))
+}
+
+func (f *NotificationFanout) onRide(ctx context.Context, event *models.OutboxEvent) error {
+	var data models.RideEvent
+	if err := event.Decode(&data); err != nil {
+		return err
+	}
+	return f.notifications.notifyEvent(event.ID, data.UserID, models.NotificationRide,
+		"Ride completed", fmt.Sprintf("Thanks for riding, your fare was %.2f.", data.Fare))
+}
+
+func orderStatusLabel(status models.OrderStatus) string {
+	switch status {
+	case models.OrderConfirmed:
+		return "confirmed"
+	case models.OrderPreparing:
+		return "is being cooked"
+	case models.OrderReady:
+		return "is ready"
+	case models.OrderDelivery:
+		return "is on the way"
+	case models.OrderCompleted:
+		return "delivered"
+	case models.OrderCancelled:
+		return "cancelled"
+	}
+	return "updated"
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pushTimeout bounds one push to all devices of a user, pushes run after the notification is stored
+const pushTimeout = 10 * time.Second
+
+type NotificationService struct {
+	db   *config.Database
+	push PushProvider
+}
+
+func NewNotificationService(db *config.Database, push PushProvider) *NotificationService {
+	return &NotificationService{db: db, push: push}
+}
+
+func (s *NotificationService) Notify(userID uint, notificationType models.NotificationType, title, body string) error {
+	notification := &models.Notification{
+		UserID: userID,
+		Type:   notificationType,
+		Title:  title,
+		Body:   body,
+	}
+	return s.create(notification)
+}
+
+// notifyEvent is Notify for the fan-out, an event handed over again creates nothing new
+func (s *NotificationService) notifyEvent(eventID, userID uint, notificationType models.NotificationType, title, body string) error {
+	notification := &models.Notification{
+		UserID:        userID,
+		Type:          notificationType,
+		Title:         title,
+		Body:          body,
+		SourceEventID: &eventID,
+	}
+	return s.create(notification)
+}
+
+func (s *NotificationService) create(notification *models.Notification) error {
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(notification)
+	if result.Error != nil {
+		return apperrors.ErrNotificationCreateFailed
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	// the notification is in the app either way, a slow or failing push should not hold up the caller
+	go s.pushToDevices(*notification)
+	return nil
+}
+
+func (s *NotificationService) pushToDevices(notification models.Notification) {
+	var devices []models.DeviceToken
+	if err := s.db.Where("user_id = ?", notification.UserID).Find(&devices).Error; err != nil {
+		log.Printf("push: loading devices of user %d: %v", notification.UserID, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+	message := PushMessage{
+		Title: notification.Title,
+		Body:  notification.Body,
+		Data:  map[string]string{"type": string(notification.Type)},
+	}
+	for _, device := range devices {
+		if err := s.push.Push(ctx, device, message); err != nil {
+			log.Printf("push: device %d of user %d: %v", device.ID, notification.UserID, err)
+		}
+	}
+}
+
+// GetNotifications lists the notifications of the user, newest first by default
+func (s *NotificationService) GetNotifications(userID uint, unreadOnly bool, page *utils.PageParams) ([]models.Notification, *utils.PageMeta, error) {
+	query := s.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	var notifications []models.Notification
+	if err := page.Apply(query).Find(&notifications).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	notifications, meta := utils.Page(notifications, page, func(n models.Notification) (uint, map[string]any) {
+		return n.ID, map[string]any{"created_at": n.CreatedAt}
+	})
+	return notifications, meta, nil
+}
+
+func (s *NotificationService) UnreadCount(userID uint) (*models.UnreadCount, error) {
+	var count models.UnreadCount
+	if err := s.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count.Unread).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &count, nil
+}
+
+// MarkRead marks one notification of the user read, marking it again keeps the first time
+func (s *NotificationService) MarkRead(userID, id uint) (*models.Notification, error) {
+	var notification models.Notification
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrNotificationNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := s.db.Model(&notification).Update("read_at", now).Error; err != nil {
+			return nil, apperrors.ErrNotificationUpdateFailed
+		}
+		notification.ReadAt = &now
+	}
+	return &notification, nil
+}
+
+// MarkAllRead marks every unread notification of the user read and returns how many there were
+func (s *NotificationService) MarkAllRead(userID uint) (*models.UnreadCount, error) {
+	result := s.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", time.Now())
+	if result.Error != nil {
+		return nil, apperrors.ErrNotificationUpdateFailed
+	}
+	return &models.UnreadCount{Unread: result.RowsAffected}, nil
+}
+
+// RegisterDevice stores a push token for the user, a token seen before moves over to this user
+func (s *NotificationService) RegisterDevice(userID uint, token string, platform models.DevicePlatform) (*models.DeviceToken, error) {
+	device := models.DeviceToken{UserID: userID, Token: token, Platform: platform, LastSeenAt: time.Now()}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "last_seen_at", "updated_at"}),
+	}).Create(&device).Error; err != nil {
+		return nil, apperrors.ErrDeviceTokenSaveFailed
+	}
+	if err := s.db.Where("token = ?", token).First(&device).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &device, nil
+}
+
+func (s *NotificationService) GetDevices(userID uint) ([]models.DeviceToken, error) {
+	var devices []models.DeviceToken
+	if err := s.db.Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&devices).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return devices, nil
+}
+
+// RemoveDevice stops pushes to a device, e.g. on logout
+func (s *NotificationService) RemoveDevice(userID, id uint) error {
+	result := s.db.Where("user_id = ?", userID).Delete(&models.DeviceToken{}, id)
+	if result.Error != nil {
+		return apperrors.ErrDeviceTokenSaveFailed
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrDeviceTokenNotFound
+	}
+	return nil
+}
@@ -4,8 +4,10 @@ import (
 	"gopay-clone/config"
 	apperrors "gopay-clone/errors"
 	"gopay-clone/models"
+	"gopay-clone/utils"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderService struct {
@@ -16,7 +18,9 @@ func NewOrderService(db *config.Database) *OrderService {
 	return &OrderService{db: db}
 }
 
-func (s *OrderService) CreateOrder(order *models.Order, items []models.OrderItem) error {
+// CreateOrder stores the order with its items, sends the driver on the way and takes the
+// payment in one db transaction, so a failed payment leaves no order behind
+func (s *OrderService) CreateOrder(order *models.Order, items []models.OrderItem, payment *models.Transaction) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(order).Error; err != nil {
 			return apperrors.ErrOrderCreateFailed
@@ -34,16 +38,45 @@ func (s *OrderService) CreateOrder(order *models.Order, items []models.OrderItem
 				return apperrors.ErrOrderCreateFailed
 			}
 		}
-		return nil
+		if order.DriverID != nil {
+			if err := tx.Model(&models.DriverProfile{}).Where("id = ?", *order.DriverID).Update("status", models.Sending).Error; err != nil {
+				return apperrors.ErrDriverStatusUpdateFailed
+			}
+		}
+
+		payment.ServiceID = &order.ID
+		if err := createTransactionTx(tx, payment); err != nil {
+			return err
+		}
+		order.TransactionID = &payment.ID
+		if err := tx.Model(order).Update("transaction_id", payment.ID).Error; err != nil {
+			return apperrors.ErrOrderCreateFailed
+		}
+		return publishEventTx(tx, models.EventOrderPlaced, order.ID, orderEvent(order, ""))
 	})
 }
 
-func (s *OrderService) GetAllOrdersByUser(id uint) ([]models.Order, error) {
+func (s *OrderService) GetAllOrdersByUser(id uint, page *utils.PageParams) ([]models.Order, *utils.PageMeta, error) {
 	var orders []models.Order
-	if err := s.db.Where("user_id = ?", id).Limit(20).Find(&orders).Error; err != nil {
-		return nil, apperrors.ErrDatabaseError
+	if err := page.Apply(s.db.Where("user_id = ?", id)).Find(&orders).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
 	}
-	return orders, nil
+	orders, meta := utils.Page(orders, page, func(o models.Order) (uint, map[string]any) {
+		return o.ID, map[string]any{"created_at": o.CreatedAt, "total_amount": o.TotalAmount}
+	})
+	return orders, meta, nil
+}
+
+// GetOrders lists orders of every user for the admin
+func (s *OrderService) GetOrders(page *utils.PageParams) ([]models.Order, *utils.PageMeta, error) {
+	var orders []models.Order
+	if err := page.Apply(s.db.Model(&models.Order{})).Find(&orders).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	orders, meta := utils.Page(orders, page, func(o models.Order) (uint, map[string]any) {
+		return o.ID, map[string]any{"created_at": o.CreatedAt, "total_amount": o.TotalAmount}
+	})
+	return orders, meta, nil
 }
 
 func (s *OrderService) GetOrderByID(id uint) (*models.Order, error) {
@@ -61,14 +94,21 @@ func (s *OrderService) GetOrderByID(id uint) (*models.Order, error) {
 }
 
 func (s *OrderService) UpdateOrderStatus(id uint, status string) error {
-	result := s.db.Model(&models.Order{}).Where("id = ?", id).Update("status", status)
-	if result.Error != nil {
-		return apperrors.ErrOrderStatusUpdateFailed
-	}
-	if result.RowsAffected == 0 {
-		return apperrors.ErrOrderNotFound
-	}
-	return nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperrors.ErrOrderNotFound
+			}
+			return apperrors.ErrDatabaseError
+		}
+		previous := order.Status
+		if err := tx.Model(&order).Update("status", status).Error; err != nil {
+			return apperrors.ErrOrderStatusUpdateFailed
+		}
+		order.Status = models.OrderStatus(status)
+		return publishEventTx(tx, models.EventOrderStatusChanged, order.ID, orderEvent(&order, previous))
+	})
 }
 
 func (s *OrderService) DeleteOrder(id uint) error {
@@ -81,3 +121,16 @@ func (s *OrderService) DeleteOrder(id uint) error {
 	}
 	return nil
 }
+
+func orderEvent(order *models.Order, previous models.OrderStatus) models.OrderEvent {
+	return models.OrderEvent{
+		OrderID:        order.ID,
+		UserID:         order.UserID,
+		MerchantID:     order.MerchantID,
+		DriverID:       order.DriverID,
+		Status:         order.Status,
+		PreviousStatus: previous,
+		TotalAmount:    order.TotalAmount,
+		DeliveryFee:    order.DeliveryFee,
+	}
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const otpLength = 6
+
+type OtpService struct {
+	db     *config.Database
+	cfg    config.OtpConfig
+	sender SMSSender
+}
+
+func NewOtpService(db *config.Database, cfg config.OtpConfig, sender SMSSender) *OtpService {
+	return &OtpService{db: db, cfg: cfg, sender: sender}
+}
+
+// RequiredOnRegister tells whether sign up needs a register code
+func (s *OtpService) RequiredOnRegister() bool {
+	return s.cfg.RequireOnRegister
+}
+
+// Send issues a new code for phone and purpose, any earlier code for the same pair stops working.
+// Login codes only go out when a user has verified the phone, the answer looks the same either
+// way so the endpoint can not be used to find out who is registered.
+func (s *OtpService) Send(ctx context.Context, phone string, purpose models.OtpPurpose) (*models.OtpChallenge, error) {
+	now := time.Now()
+	var last models.PhoneOtp
+	err := s.db.Where("phone = ? AND purpose = ?", phone, purpose).Order("created_at DESC").First(&last).Error
+	if err == nil && now.Sub(last.CreatedAt) < s.cfg.ResendCooldown {
+		return nil, apperrors.ErrOtpCooldown
+	}
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	// without a random source there is no code worth sending
+	code, err := utils.RandomDigits(otpLength)
+	if err != nil {
+		return nil, apperrors.ErrOtpCreateFailed
+	}
+	hashed, err := utils.HashPassword(code)
+	if err != nil {
+		return nil, apperrors.ErrOtpCreateFailed
+	}
+	otp := models.PhoneOtp{Phone: phone, Purpose: purpose, CodeHash: hashed, ExpiresAt: now.Add(s.cfg.TTL)}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PhoneOtp{}).
+			Where("phone = ? AND purpose = ? AND consumed_at IS NULL", phone, purpose).
+			Update("consumed_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&otp).Error
+	})
+	if err != nil {
+		return nil, apperrors.ErrOtpCreateFailed
+	}
+
+	deliver := true
+	if purpose == models.OtpLogin {
+		deliver, err = s.phoneVerified(phone)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if deliver {
+		message := fmt.Sprintf("Your GoClone %s code is %s. It expires in %d minutes, never share it.", purposeLabel(purpose), code, int(s.cfg.TTL.Minutes()))
+		if err := s.sender.Send(ctx, phone, message); err != nil {
+			return nil, apperrors.ErrOtpSendFailed
+		}
+	}
+
+	return &models.OtpChallenge{
+		Phone:       maskPhone(phone),
+		Purpose:     purpose,
+		ExpiresAt:   otp.ExpiresAt,
+		ResendAfter: now.Add(s.cfg.ResendCooldown),
+	}, nil
+}
+
+// SendToUser sends to the phone of a logged in user, only verifying the phone works before it is verified
+func (s *OtpService) SendToUser(ctx context.Context, userID uint, purpose models.OtpPurpose) (*models.OtpChallenge, error) {
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	if purpose == models.OtpVerifyPhone && user.PhoneVerifiedAt != nil {
+		return nil, apperrors.ErrPhoneAlreadyVerified
+	}
+	if purpose != models.OtpVerifyPhone && user.PhoneVerifiedAt == nil {
+		return nil, apperrors.ErrPhoneNotVerified
+	}
+	return s.Send(ctx, user.Phone, purpose)
+}
+
+// Verify spends the latest code for phone and purpose. Wrong codes are counted and stored
+// even though an error is returned, after MaxAttempts the code is burned.
+func (s *OtpService) Verify(phone string, purpose models.OtpPurpose, code string) error {
+	if code == "" {
+		return apperrors.ErrOtpRequired
+	}
+	var result error
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var otp models.PhoneOtp
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("phone = ? AND purpose = ? AND consumed_at IS NULL", phone, purpose).
+			Order("created_at DESC").
+			First(&otp).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				result = apperrors.ErrOtpExpired
+				return nil
+			}
+			return err
+		}
+
+		now := time.Now()
+		if otp.ExpiresAt.Before(now) {
+			result = apperrors.ErrOtpExpired
+			return tx.Model(&otp).Update("consumed_at", now).Error
+		}
+		if utils.CheckPassword(otp.CodeHash, code) {
+			return tx.Model(&otp).Update("consumed_at", now).Error
+		}
+
+		attempts := otp.Attempts + 1
+		updates := map[string]any{"attempts": attempts}
+		if attempts >= s.cfg.MaxAttempts {
+			updates["consumed_at"] = now
+			result = apperrors.ErrOtpTooManyAttempts
+		} else {
+			result = apperrors.NewOtpInvalidError(s.cfg.MaxAttempts - attempts)
+		}
+		return tx.Model(&otp).Updates(updates).Error
+	})
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return result
+}
+
+// VerifyUser checks a code sent with SendToUser
+func (s *OtpService) VerifyUser(userID uint, purpose models.OtpPurpose, code string) error {
+	user, err := s.user(userID)
+	if err != nil {
+		return err
+	}
+	if purpose != models.OtpVerifyPhone && user.PhoneVerifiedAt == nil {
+		return apperrors.ErrPhoneNotVerified
+	}
+	return s.Verify(user.Phone, purpose, code)
+}
+
+// StepUp asks for a code on high-risk actions once the user has a verified phone,
+// users without one can not receive a code so they pass as before
+func (s *OtpService) StepUp(userID uint, purpose models.OtpPurpose, code string) error {
+	user, err := s.user(userID)
+	if err != nil {
+		return err
+	}
+	if user.PhoneVerifiedAt == nil {
+		return nil
+	}
+	return s.Verify(user.Phone, purpose, code)
+}
+
+// VerifyPhone marks the phone of the user verified with a verify_phone code
+func (s *OtpService) VerifyPhone(userID uint, code string) error {
+	user, err := s.user(userID)
+	if err != nil {
+		return err
+	}
+	if user.PhoneVerifiedAt != nil {
+		return apperrors.ErrPhoneAlreadyVerified
+	}
+	taken, err := s.phoneVerified(user.Phone)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return apperrors.ErrPhoneTaken
+	}
+	if err := s.Verify(user.Phone, models.OtpVerifyPhone, code); err != nil {
+		return err
+	}
+	if err := s.db.Model(user).Update("phone_verified_at", time.Now()).Error; err != nil {
+		return apperrors.ErrUserUpdateFailed
+	}
+	return nil
+}
+
+func (s *OtpService) user(userID uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &user, nil
+}
+
+func (s *OtpService) phoneVerified(phone string) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.User{}).Where("phone = ? AND phone_verified_at IS NOT NULL", phone).Count(&count).Error; err != nil {
+		return false, apperrors.ErrDatabaseError
+	}
+	return count > 0, nil
+}
+
+func purposeLabel(purpose models.OtpPurpose) string {
+	switch purpose {
+	case models.OtpRegister:
+		return "registration"
+	case models.OtpLogin:
+		return "login"
+	case models.OtpPin:
+		return "PIN change"
+	case models.OtpNewDevice:
+		return "new device login"
+	}
+	return "verification"
+}
+
+// maskPhone keeps the last 3 digits so the user knows where the code went
+func maskPhone(phone string) string {
+	if len(phone) <= 3 {
+		return phone
+	}
+	masked := make([]byte, len(phone))
+	for i := range masked {
+		masked[i] = '*'
+	}
+	copy(masked[len(phone)-3:], phone[len(phone)-3:])
+	return string(masked)
+}
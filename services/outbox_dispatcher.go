@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gopay-clone/config"
+	"gopay-clone/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// outboxLease is how long a claimed event is left alone by other instances, an instance that
+// dies while dispatching hands the event over after it
+const outboxLease = 5 * time.Minute
+
+// OutboxDispatcher hands committed outbox events to the subscribers on the bus, retrying
+// events with a failed subscriber with exponential backoff
+type OutboxDispatcher struct {
+	db  *config.Database
+	bus *EventBus
+	cfg config.OutboxConfig
+}
+
+func NewOutboxDispatcher(db *config.Database, bus *EventBus, cfg config.OutboxConfig) *OutboxDispatcher {
+	return &OutboxDispatcher{db: db, bus: bus, cfg: cfg}
+}
+
+// Start polls until ctx is cancelled, it is meant to run in its own goroutine
+func (d *OutboxDispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	log.Printf("outbox dispatcher started, polling every %s", d.cfg.PollInterval)
+	for {
+		d.RunDue(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			log.Println("outbox dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue dispatches every event due at now, oldest first
+func (d *OutboxDispatcher) RunDue(ctx context.Context, now time.Time) {
+	var events []models.OutboxEvent
+	if err := d.db.Where("status = ? AND next_attempt_at <= ?", models.OutboxPending, now).
+		Order("id").Limit(d.cfg.BatchSize).Find(&events).Error; err != nil {
+		log.Printf("outbox: loading pending events: %v", err)
+		return
+	}
+	for i := range events {
+		if err := d.dispatch(ctx, &events[i]); err != nil {
+			log.Printf("outbox: event %d (%s): %v", events[i].ID, events[i].Type, err)
+		}
+	}
+}
+
+func (d *OutboxDispatcher) dispatch(ctx context.Context, event *models.OutboxEvent) error {
+	claimed, err := d.claim(event)
+	if err != nil || !claimed {
+		return err
+	}
+
+	var handled []string
+	if err := d.db.Model(&models.OutboxReceipt{}).Where("event_id = ?", event.ID).Pluck("subscriber", &handled).Error; err != nil {
+		return err
+	}
+	done := make(map[string]bool, len(handled))
+	for _, name := range handled {
+		done[name] = true
+	}
+
+	var failures []error
+	for _, sub := range d.bus.subscribersOf(event.Type) {
+		if done[sub.name] {
+			continue
+		}
+		if err := d.handle(ctx, sub, event); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", sub.name, err))
+			continue
+		}
+		receipt := models.OutboxReceipt{EventID: event.ID, Subscriber: sub.name}
+		if err := d.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&receipt).Error; err != nil {
+			// the subscriber sees the event again on the next attempt
+			failures = append(failures, fmt.Errorf("%s: saving receipt: %w", sub.name, err))
+		}
+	}
+
+	event.Attempts++
+	updates := map[string]any{"attempts": event.Attempts}
+	switch {
+	case len(failures) == 0:
+		updates["status"] = models.OutboxProcessed
+		updates["processed_at"] = time.Now()
+		updates["error"] = ""
+	case event.Attempts >= d.cfg.MaxAttempts:
+		updates["status"] = models.OutboxFailed
+		updates["error"] = errors.Join(failures...).Error()
+	default:
+		updates["next_attempt_at"] = time.Now().Add(d.backoff(event.Attempts))
+		updates["error"] = errors.Join(failures...).Error()
+	}
+	return d.db.Model(event).Updates(updates).Error
+}
+
+// handle runs one subscriber, a panic counts as a failure instead of stopping the dispatcher
+func (d *OutboxDispatcher) handle(ctx context.Context, sub subscriber, event *models.OutboxEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return sub.handle(ctx, event)
+}
+
+// claim moves the next attempt out of reach of other instances while this one dispatches
+func (d *OutboxDispatcher) claim(event *models.OutboxEvent) (bool, error) {
+	lease := time.Now().Add(outboxLease)
+	result := d.db.Model(&models.OutboxEvent{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", event.ID, models.OutboxPending, event.NextAttemptAt).
+		Update("next_attempt_at", lease)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// backoff is RetryDelay doubled for every attempt after the first, capped at RetryMaxDelay
+func (d *OutboxDispatcher) backoff(attempt int) time.Duration {
+	wait := d.cfg.RetryDelay
+	for i := 1; i < attempt && wait < d.cfg.RetryMaxDelay; i++ {
+		wait *= 2
+	}
+	return min(wait, d.cfg.RetryMaxDelay)
+}
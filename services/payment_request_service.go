@@ -0,0 +1,474 @@
+package services
+
+import (
+	"fmt"
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentRequestService struct {
+	db                  *config.Database
+	notificationService *NotificationService
+}
+
+func NewPaymentRequestService(db *config.Database, notificationService *NotificationService) *PaymentRequestService {
+	return &PaymentRequestService{db: db, notificationService: notificationService}
+}
+
+// CreateRequests asks every contact for the same amount, one request per contact
+func (s *PaymentRequestService) CreateRequests(requesterID uint, contactIDs []uint, amount float64, note string) ([]models.PaymentRequest, error) {
+	payers, err := s.resolveContacts(requesterID, contactIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]models.PaymentRequest, 0, len(payers))
+	for _, payerID := range payers {
+		requests = append(requests, models.PaymentRequest{
+			RequesterID: requesterID,
+			PayerID:     payerID,
+			Amount:      amount,
+			Note:        note,
+			Category:    models.TransferCat,
+			Status:      models.PaymentRequestPending,
+		})
+	}
+	if err := s.db.Create(&requests).Error; err != nil {
+		return nil, apperrors.ErrPaymentRequestCreateFailed
+	}
+
+	s.notifyPayers(requesterID, requests)
+	return requests, nil
+}
+
+// GetIncoming lists requests other users sent to payerID
+func (s *PaymentRequestService) GetIncoming(payerID uint, page *utils.PageParams) ([]models.PaymentRequest, *utils.PageMeta, error) {
+	return s.list(s.db.Preload("Requester").Where("payer_id = ?", payerID), page)
+}
+
+// GetOutgoing lists requests requesterID sent, split bill requests included
+func (s *PaymentRequestService) GetOutgoing(requesterID uint, page *utils.PageParams) ([]models.PaymentRequest, *utils.PageMeta, error) {
+	return s.list(s.db.Preload("Payer").Where("requester_id = ?", requesterID), page)
+}
+
+func (s *PaymentRequestService) list(q *gorm.DB, page *utils.PageParams) ([]models.PaymentRequest, *utils.PageMeta, error) {
+	var requests []models.PaymentRequest
+	if err := page.Apply(q).Find(&requests).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	requests, meta := utils.Page(requests, page, func(r models.PaymentRequest) (uint, map[string]any) {
+		return r.ID, map[string]any{"created_at": r.CreatedAt, "amount": r.Amount}
+	})
+	return requests, meta, nil
+}
+
+// GetRequest is visible to both the requester and the payer
+func (s *PaymentRequestService) GetRequest(userID, id uint) (*models.PaymentRequest, error) {
+	var request models.PaymentRequest
+	err := s.db.Preload("Requester").Preload("Payer").Preload("Transaction").
+		Where("requester_id = ? OR payer_id = ?", userID, userID).
+		First(&request, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrPaymentRequestNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &request, nil
+}
+
+// PayRequest transfers the requested amount from the payer to the requester's main balance.
+// The transfer and the status change happen in one db transaction so a request is never paid twice.
+// senderAccountID is optional, the payer's main balance is used when it is nil.
+func (s *PaymentRequestService) PayRequest(payerID, id uint, senderAccountID *uint) (*models.Transaction, error) {
+	var request models.PaymentRequest
+	var transaction *models.Transaction
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("payer_id = ?", payerID).First(&request, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperrors.ErrPaymentRequestNotFound
+			}
+			return apperrors.ErrDatabaseError
+		}
+		if request.Status != models.PaymentRequestPending {
+			return apperrors.ErrPaymentRequestNotPending
+		}
+
+		var sender models.Account
+		if senderAccountID != nil {
+			if err := tx.First(&sender, *senderAccountID).Error; err != nil {
+				return apperrors.ErrAccountNotFound
+			}
+			if sender.UserId != payerID {
+				return apperrors.ErrForbidden
+			}
+		} else if err := mainBalance(tx, payerID, &sender); err != nil {
+			return err
+		}
+		var receiver models.Account
+		if err := mainBalance(tx, request.RequesterID, &receiver); err != nil {
+			return err
+		}
+
+		description := "Payment request #" + fmt.Sprint(request.ID)
+		if request.Note != "" {
+			description += ": " + request.Note
+		}
+		transaction = &models.Transaction{
+			Amount:            request.Amount,
+			SenderAccountID:   sender.ID,
+			ReceiverAccountID: receiver.ID,
+			Type:              models.Transfer,
+			Category:          request.Category,
+			Status:            models.TransactionCompleted,
+			ServiceType:       models.ServiceNone,
+			Description:       description,
+		}
+		if err := createTransactionTx(tx, transaction); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&request).Updates(map[string]any{
+			"status":         models.PaymentRequestPaid,
+			"transaction_id": transaction.ID,
+			"responded_at":   now,
+		}).Error; err != nil {
+			return apperrors.ErrPaymentRequestUpdateFailed
+		}
+
+		if request.SplitBillID != nil {
+			return settleSplitBill(tx, *request.SplitBillID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(request.RequesterID, models.NotificationRequestPaid, "Payment request paid",
+		fmt.Sprintf("Your request #%d for %.2f was paid.", request.ID, request.Amount))
+	return transaction, nil
+}
+
+// DeclineRequest is done by the payer, the requester is told about it
+func (s *PaymentRequestService) DeclineRequest(payerID, id uint) error {
+	request, err := s.respond(id, "payer_id = ?", payerID, models.PaymentRequestDeclined)
+	if err != nil {
+		return err
+	}
+	s.notify(request.RequesterID, models.NotificationRequestDenied, "Payment request declined",
+		fmt.Sprintf("Your request #%d for %.2f was declined.", request.ID, request.Amount))
+	return nil
+}
+
+// CancelRequest is done by the requester when they no longer need the money
+func (s *PaymentRequestService) CancelRequest(requesterID, id uint) error {
+	_, err := s.respond(id, "requester_id = ?", requesterID, models.PaymentRequestCancelled)
+	return err
+}
+
+// respond moves a pending request to a final status without any money moving
+func (s *PaymentRequestService) respond(id uint, owner string, userID uint, status models.PaymentRequestStatus) (*models.PaymentRequest, error) {
+	var request models.PaymentRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(owner, userID).First(&request, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperrors.ErrPaymentRequestNotFound
+			}
+			return apperrors.ErrDatabaseError
+		}
+		if request.Status != models.PaymentRequestPending {
+			return apperrors.ErrPaymentRequestNotPending
+		}
+		if err := tx.Model(&request).Updates(map[string]any{
+			"status":       status,
+			"responded_at": time.Now(),
+		}).Error; err != nil {
+			return apperrors.ErrPaymentRequestUpdateFailed
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// CreateSplitBill divides bill.TotalAmount across the contacts in shares. When the bill
+// comes from an order the total, title and category are taken from the order, and an order
+// can only be split again once its earlier split was cancelled.
+// Even splits leave any cent remainder with the owner, custom splits give the owner
+// whatever the shares do not cover.
+func (s *PaymentRequestService) CreateSplitBill(bill *models.SplitBill, shares []models.SplitShare, includeOwner bool) error {
+	contactIDs := make([]uint, 0, len(shares))
+	for _, share := range shares {
+		contactIDs = append(contactIDs, share.ContactID)
+	}
+	payers, err := s.resolveContacts(bill.OwnerID, contactIDs)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if bill.OrderID != nil {
+			if err := splitOrderTx(tx, bill); err != nil {
+				return err
+			}
+		}
+		if bill.Category == "" {
+			bill.Category = models.Other
+		}
+
+		amounts, ownerShare, err := splitAmounts(bill.TotalAmount, bill.Mode, shares, includeOwner)
+		if err != nil {
+			return err
+		}
+		bill.OwnerShare = ownerShare
+		bill.Status = models.SplitBillOpen
+
+		for i, payerID := range payers {
+			bill.Requests = append(bill.Requests, models.PaymentRequest{
+				RequesterID: bill.OwnerID,
+				PayerID:     payerID,
+				Amount:      amounts[i],
+				Note:        bill.Title,
+				Category:    bill.Category,
+				Status:      models.PaymentRequestPending,
+			})
+		}
+		if err := tx.Create(bill).Error; err != nil {
+			return apperrors.ErrSplitBillCreateFailed
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyPayers(bill.OwnerID, bill.Requests)
+	return nil
+}
+
+// splitOrderTx fills the bill from the order it splits. The order row stays locked until
+// the bill is created, so two splits of the same order can't both pass the check.
+func splitOrderTx(tx *gorm.DB, bill *models.SplitBill) error {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, *bill.OrderID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperrors.ErrOrderNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	if order.UserID != bill.OwnerID || order.Status != models.OrderCompleted {
+		return apperrors.ErrOrderNotSplittable
+	}
+
+	var splits int64
+	if err := tx.Model(&models.SplitBill{}).
+		Where("order_id = ? AND status <> ?", order.ID, models.SplitBillCancelled).
+		Count(&splits).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if splits > 0 {
+		return apperrors.ErrOrderAlreadySplit
+	}
+
+	var merchant models.MerchantProfile
+	if err := tx.Select("merchant_name").First(&merchant, order.MerchantID).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	bill.TotalAmount = order.TotalAmount
+	bill.Category = models.Food
+	if bill.Title == "" {
+		bill.Title = fmt.Sprintf("Order #%d at %s", order.ID, merchant.MerchantName)
+	}
+	return nil
+}
+
+func (s *PaymentRequestService) GetSplitBills(ownerID uint, page *utils.PageParams) ([]models.SplitBill, *utils.PageMeta, error) {
+	var bills []models.SplitBill
+	if err := page.Apply(s.db.Preload("Requests.Payer").Where("owner_id = ?", ownerID)).Find(&bills).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	bills, meta := utils.Page(bills, page, func(b models.SplitBill) (uint, map[string]any) {
+		return b.ID, map[string]any{"created_at": b.CreatedAt, "total_amount": b.TotalAmount}
+	})
+	for i := range bills {
+		bills[i].Collected = collected(bills[i].Requests)
+	}
+	return bills, meta, nil
+}
+
+// GetSplitBill is visible to the owner and to everyone asked to pay a share
+func (s *PaymentRequestService) GetSplitBill(userID, id uint) (*models.SplitBill, error) {
+	var bill models.SplitBill
+	err := s.db.Preload("Requests.Payer").
+		Where("owner_id = ? OR id IN (?)", userID,
+			s.db.Model(&models.PaymentRequest{}).Select("split_bill_id").Where("payer_id = ?", userID)).
+		First(&bill, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrSplitBillNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	bill.Collected = collected(bill.Requests)
+	return &bill, nil
+}
+
+// CancelSplitBill cancels the bill and every request still pending, paid shares stay paid
+func (s *PaymentRequestService) CancelSplitBill(ownerID, id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var bill models.SplitBill
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("owner_id = ?", ownerID).First(&bill, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperrors.ErrSplitBillNotFound
+			}
+			return apperrors.ErrDatabaseError
+		}
+		if bill.Status != models.SplitBillOpen {
+			return apperrors.ErrSplitBillNotOpen
+		}
+		if err := tx.Model(&models.PaymentRequest{}).
+			Where("split_bill_id = ? AND status = ?", bill.ID, models.PaymentRequestPending).
+			Updates(map[string]any{"status": models.PaymentRequestCancelled, "responded_at": time.Now()}).Error; err != nil {
+			return apperrors.ErrPaymentRequestUpdateFailed
+		}
+		if err := tx.Model(&bill).Update("status", models.SplitBillCancelled).Error; err != nil {
+			return apperrors.ErrDatabaseError
+		}
+		return nil
+	})
+}
+
+// resolveContacts turns the owner's contact ids into user ids, in the same order
+func (s *PaymentRequestService) resolveContacts(ownerID uint, contactIDs []uint) ([]uint, error) {
+	var contacts []models.Contact
+	if err := s.db.Where("owner_id = ? AND id IN ?", ownerID, contactIDs).Find(&contacts).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	targets := make(map[uint]uint, len(contacts))
+	for _, c := range contacts {
+		targets[c.ID] = c.TargetID
+	}
+
+	payers := make([]uint, 0, len(contactIDs))
+	for _, id := range contactIDs {
+		target, ok := targets[id]
+		if !ok {
+			return nil, apperrors.ErrContactNotFound
+		}
+		payers = append(payers, target)
+	}
+	return payers, nil
+}
+
+func (s *PaymentRequestService) notifyPayers(requesterID uint, requests []models.PaymentRequest) {
+	var requester models.User
+	s.db.Select("name").First(&requester, requesterID)
+	for _, r := range requests {
+		body := fmt.Sprintf("%s asked you for %.2f.", requester.Name, r.Amount)
+		if r.Note != "" {
+			body += " Note: " + r.Note
+		}
+		s.notify(r.PayerID, models.NotificationPaymentRequest, "New payment request", body)
+	}
+}
+
+// notifications are best effort, a failed one should not undo a payment
+func (s *PaymentRequestService) notify(userID uint, notificationType models.NotificationType, title, body string) {
+	_ = s.notificationService.Notify(userID, notificationType, title, body)
+}
+
+// minSplitShare is the smallest amount a share can ask for, one cent
+const minSplitShare = 0.01
+
+// splitAmounts returns the amount each share pays and what is left for the owner. Every
+// share has to be at least a cent, a zero share would become a zero payment.
+func splitAmounts(total float64, mode models.SplitMode, shares []models.SplitShare, includeOwner bool) ([]float64, float64, error) {
+	amounts := make([]float64, len(shares))
+
+	if mode == models.SplitEven {
+		parts := len(shares)
+		if includeOwner {
+			parts++
+		}
+		// work in cents so the shares always add back up to the total
+		cents := int64(math.Round(total * 100))
+		each := cents / int64(parts)
+		if each < 1 {
+			return nil, 0, apperrors.ErrSplitShareTooSmall
+		}
+		for i := range amounts {
+			amounts[i] = float64(each) / 100
+		}
+		if !includeOwner {
+			// nobody else can absorb the remainder
+			amounts[0] = float64(each+cents%int64(parts)) / 100
+			return amounts, 0, nil
+		}
+		return amounts, roundMoney(float64(cents-each*int64(len(shares))) / 100), nil
+	}
+
+	var sum float64
+	for i, share := range shares {
+		amounts[i] = roundMoney(share.Amount)
+		if amounts[i] < minSplitShare {
+			return nil, 0, apperrors.ErrSplitShareTooSmall
+		}
+		sum += amounts[i]
+	}
+	ownerShare := roundMoney(total - sum)
+	if ownerShare < 0 || (!includeOwner && ownerShare != 0) {
+		return nil, 0, apperrors.ErrSplitSharesMismatch
+	}
+	return amounts, ownerShare, nil
+}
+
+// settleSplitBill marks the bill settled once every request in it is paid
+func settleSplitBill(tx *gorm.DB, splitBillID uint) error {
+	var unpaid int64
+	if err := tx.Model(&models.PaymentRequest{}).
+		Where("split_bill_id = ? AND status <> ?", splitBillID, models.PaymentRequestPaid).
+		Count(&unpaid).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if unpaid > 0 {
+		return nil
+	}
+	if err := tx.Model(&models.SplitBill{}).
+		Where("id = ? AND status = ?", splitBillID, models.SplitBillOpen).
+		Update("status", models.SplitBillSettled).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+func mainBalance(tx *gorm.DB, userID uint, account *models.Account) error {
+	if err := tx.Where("user_id = ? AND account_type = ?", userID, models.MainBalance).First(account).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperrors.ErrAccountNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+func collected(requests []models.PaymentRequest) float64 {
+	var total float64
+	for _, r := range requests {
+		if r.Status == models.PaymentRequestPaid {
+			total += r.Amount
+		}
+	}
+	return roundMoney(total)
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PinService struct {
+	db  *config.Database
+	cfg config.PinConfig
+}
+
+func NewPinService(db *config.Database, cfg config.PinConfig) *PinService {
+	return &PinService{db: db, cfg: cfg}
+}
+
+func (s *PinService) GetStatus(userID uint) (*models.PinStatus, error) {
+	status := &models.PinStatus{RequiredAbove: s.cfg.RequiredAbove, AttemptsLeft: s.cfg.MaxAttempts}
+
+	var pin models.PaymentPin
+	if err := s.db.Where("user_id = ?", userID).First(&pin).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return status, nil
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	status.IsSet = true
+	status.ChangedAt = &pin.UpdatedAt
+	status.AttemptsLeft = s.cfg.MaxAttempts - pin.FailedAttempts
+	if pin.LockedUntil != nil && pin.LockedUntil.After(time.Now()) {
+		status.LockedUntil = pin.LockedUntil
+		status.AttemptsLeft = 0
+	}
+	return status, nil
+}
+
+// SetPin stores the first PIN of a user, the account password confirms it is really them
+func (s *PinService) SetPin(userID uint, password, pin string) error {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return apperrors.ErrUserNotFound
+	}
+	if !utils.CheckPassword(user.Password, password) {
+		return apperrors.ErrInvalidPassword
+	}
+
+	var count int64
+	if err := s.db.Model(&models.PaymentPin{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if count > 0 {
+		return apperrors.ErrPinAlreadySet
+	}
+
+	hashed, err := utils.HashPassword(pin)
+	if err != nil {
+		return apperrors.ErrPinUpdateFailed
+	}
+	if err := s.db.Create(&models.PaymentPin{UserID: userID, PinHash: hashed}).Error; err != nil {
+		return apperrors.ErrPinUpdateFailed
+	}
+	return nil
+}
+
+// ChangePin needs the current PIN, wrong ones count towards the lockout like payments do
+func (s *PinService) ChangePin(userID uint, currentPin, newPin string) error {
+	if err := s.VerifyPin(userID, currentPin); err != nil {
+		return err
+	}
+	return s.replacePin(userID, newPin)
+}
+
+// ResetPin sets a new PIN without the current one, the caller has checked an OTP instead
+func (s *PinService) ResetPin(userID uint, pin string) error {
+	return s.replacePin(userID, pin)
+}
+
+// replacePin overwrites the PIN and clears any lockout, callers have verified the user
+func (s *PinService) replacePin(userID uint, pin string) error {
+	hashed, err := utils.HashPassword(pin)
+	if err != nil {
+		return apperrors.ErrPinUpdateFailed
+	}
+	result := s.db.Model(&models.PaymentPin{}).Where("user_id = ?", userID).Updates(map[string]any{
+		"pin_hash":        hashed,
+		"failed_attempts": 0,
+		"locked_until":    nil,
+	})
+	if result.Error != nil {
+		return apperrors.ErrPinUpdateFailed
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrPinNotSet
+	}
+	return nil
+}
+
+// Authorize checks the PIN when amount is above the configured threshold
+func (s *PinService) Authorize(userID uint, amount float64, pin string) error {
+	if amount <= s.cfg.RequiredAbove {
+		return nil
+	}
+	if pin == "" {
+		var count int64
+		if err := s.db.Model(&models.PaymentPin{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return apperrors.ErrDatabaseError
+		}
+		if count == 0 {
+			return apperrors.ErrPinNotSet
+		}
+		return apperrors.ErrPinRequired
+	}
+	return s.VerifyPin(userID, pin)
+}
+
+// VerifyPin compares the PIN and counts failures. After MaxAttempts wrong PINs in a row the
+// PIN is locked for the lockout period. The attempt is stored even though an error is returned.
+func (s *PinService) VerifyPin(userID uint, pin string) error {
+	var result error
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var stored models.PaymentPin
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&stored).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				result = apperrors.ErrPinNotSet
+				return nil
+			}
+			return apperrors.ErrDatabaseError
+		}
+
+		now := time.Now()
+		if stored.LockedUntil != nil && stored.LockedUntil.After(now) {
+			result = apperrors.ErrPinLocked
+			return nil
+		}
+
+		if utils.CheckPassword(stored.PinHash, pin) {
+			if stored.FailedAttempts == 0 && stored.LockedUntil == nil {
+				return nil
+			}
+			return tx.Model(&stored).Updates(map[string]any{"failed_attempts": 0, "locked_until": nil}).Error
+		}
+
+		attempts := stored.FailedAttempts + 1
+		updates := map[string]any{"failed_attempts": attempts}
+		if attempts >= s.cfg.MaxAttempts {
+			updates["failed_attempts"] = 0
+			updates["locked_until"] = now.Add(s.cfg.Lockout)
+			result = apperrors.ErrPinLocked
+		} else {
+			result = apperrors.NewPinInvalidError(s.cfg.MaxAttempts - attempts)
+		}
+		return tx.Model(&stored).Updates(updates).Error
+	})
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return result
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"fmt"
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PocketService struct {
+	db              *config.Database
+	scheduleService *ScheduledTransferService
+}
+
+func NewPocketService(db *config.Database, scheduleService *ScheduledTransferService) *PocketService {
+	return &PocketService{db: db, scheduleService: scheduleService}
+}
+
+func (s *PocketService) CreatePocket(pocket *models.Account) error {
+	pocket.AccountType = models.Pocket
+	pocket.Balance = 0
+	if err := s.db.Create(pocket).Error; err != nil {
+		return apperrors.ErrPocketCreateFailed
+	}
+	return nil
+}
+
+func (s *PocketService) GetPockets(userID uint) ([]models.PocketStatus, error) {
+	var pockets []models.Account
+	if err := s.db.Where("user_id = ? AND account_type = ?", userID, models.Pocket).Order("created_at ASC").Find(&pockets).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	now := time.Now()
+	statuses := make([]models.PocketStatus, 0, len(pockets))
+	for _, p := range pockets {
+		statuses = append(statuses, pocketStatus(p, now))
+	}
+	return statuses, nil
+}
+
+func (s *PocketService) GetPocket(userID, id uint) (*models.PocketStatus, error) {
+	pocket, err := s.findPocket(s.db.DB, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	status := pocketStatus(*pocket, time.Now())
+	return &status, nil
+}
+
+// UpdatePocket applies name, goal and lock changes. A lock that is still running can be
+// extended but not lifted or shortened.
+func (s *PocketService) UpdatePocket(userID, id uint, updates map[string]any) (*models.PocketStatus, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		pocket, err := s.findPocket(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		lockRunning := pocket.IsLocked && pocket.LockedUntil != nil && pocket.LockedUntil.After(now)
+		if lockRunning {
+			if locked, ok := updates["is_locked"]; ok && locked == false {
+				return apperrors.ErrPocketLockShortened
+			}
+			if until, ok := updates["locked_until"]; ok {
+				if t, isTime := until.(*time.Time); !isTime || t == nil || t.Before(*pocket.LockedUntil) {
+					return apperrors.ErrPocketLockShortened
+				}
+			}
+		}
+
+		if err := tx.Model(pocket).Updates(updates).Error; err != nil {
+			return apperrors.ErrPocketUpdateFailed
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPocket(userID, id)
+}
+
+// InternalTransfer moves money between two accounts of the same user, it never counts as
+// spending and does not notify anyone
+func (s *PocketService) InternalTransfer(userID, fromID, toID uint, amount float64, description string) (*models.Transaction, error) {
+	var transaction *models.Transaction
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var from, to models.Account
+		if err := tx.First(&from, fromID).Error; err != nil {
+			return apperrors.ErrAccountNotFound
+		}
+		if err := tx.First(&to, toID).Error; err != nil {
+			return apperrors.ErrAccountNotFound
+		}
+		if from.UserId != userID || to.UserId != userID {
+			return apperrors.ErrNotInternalTransfer
+		}
+
+		if description == "" {
+			description = fmt.Sprintf("Move from %s to %s", from.Name, to.Name)
+		}
+		transaction = &models.Transaction{
+			Amount:            amount,
+			SenderAccountID:   from.ID,
+			ReceiverAccountID: to.ID,
+			Type:              models.Transfer,
+			Category:          models.TransferCat,
+			Status:            models.TransactionCompleted,
+			ServiceType:       models.ServiceNone,
+			Description:       description,
+		}
+		return createTransactionTx(tx, transaction)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+func (s *PocketService) GetAutoSaveRules(userID, pocketID uint) ([]models.AutoSaveRule, error) {
+	if _, err := s.findPocket(s.db.DB, userID, pocketID); err != nil {
+		return nil, err
+	}
+	var rules []models.AutoSaveRule
+	if err := s.db.Preload("Schedule").Where("pocket_id = ? AND is_active = ?", pocketID, true).
+		Order("created_at ASC").Find(&rules).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return rules, nil
+}
+
+// AddRoundUpRule rounds every payment from the source account up to a multiple of
+// rule.RoundTo and saves the difference. Only one pocket can collect round-ups per account.
+func (s *PocketService) AddRoundUpRule(rule *models.AutoSaveRule) error {
+	if err := s.checkRuleAccounts(rule); err != nil {
+		return err
+	}
+	var count int64
+	if err := s.db.Model(&models.AutoSaveRule{}).
+		Where("source_account_id = ? AND type = ? AND is_active = ?", rule.SourceAccountID, models.AutoSaveRoundUp, true).
+		Count(&count).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if count > 0 {
+		return apperrors.ErrAutoSaveRoundUpExists
+	}
+
+	rule.Type = models.AutoSaveRoundUp
+	rule.IsActive = true
+	if err := s.db.Create(rule).Error; err != nil {
+		return apperrors.ErrAutoSaveRuleSaveFailed
+	}
+	return nil
+}
+
+// AddScheduledRule saves a fixed amount on a schedule, the scheduled transfer does the work
+func (s *PocketService) AddScheduledRule(rule *models.AutoSaveRule, schedule *models.ScheduledTransfer) error {
+	if err := s.checkRuleAccounts(rule); err != nil {
+		return err
+	}
+	var pocket models.Account
+	if err := s.db.First(&pocket, rule.PocketID).Error; err != nil {
+		return apperrors.ErrPocketNotFound
+	}
+
+	schedule.UserID = rule.UserID
+	schedule.SenderAccountID = rule.SourceAccountID
+	schedule.ReceiverAccountID = rule.PocketID
+	schedule.Category = models.TransferCat
+	schedule.Description = "Auto-save to " + pocket.Name
+	if err := s.scheduleService.CreateSchedule(schedule); err != nil {
+		return err
+	}
+
+	rule.Type = models.AutoSaveScheduled
+	rule.IsActive = true
+	rule.ScheduleID = &schedule.ID
+	rule.Schedule = schedule
+	if err := s.db.Omit("Schedule").Create(rule).Error; err != nil {
+		return apperrors.ErrAutoSaveRuleSaveFailed
+	}
+	return nil
+}
+
+// DeleteAutoSaveRule stops a rule, for scheduled rules the schedule is cancelled too
+func (s *PocketService) DeleteAutoSaveRule(userID, pocketID, ruleID uint) error {
+	var rule models.AutoSaveRule
+	if err := s.db.Where("user_id = ? AND pocket_id = ? AND is_active = ?", userID, pocketID, true).First(&rule, ruleID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperrors.ErrAutoSaveRuleNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	if rule.ScheduleID != nil {
+		if _, err := s.scheduleService.CancelSchedule(userID, *rule.ScheduleID); err != nil && err != apperrors.ErrScheduleNotActive {
+			return err
+		}
+	}
+	if err := s.db.Model(&rule).Update("is_active", false).Error; err != nil {
+		return apperrors.ErrAutoSaveRuleSaveFailed
+	}
+	return nil
+}
+
+func (s *PocketService) checkRuleAccounts(rule *models.AutoSaveRule) error {
+	if _, err := s.findPocket(s.db.DB, rule.UserID, rule.PocketID); err != nil {
+		return err
+	}
+	var source models.Account
+	if err := s.db.First(&source, rule.SourceAccountID).Error; err != nil {
+		return apperrors.ErrAccountNotFound
+	}
+	if source.UserId != rule.UserID {
+		return apperrors.ErrForbidden
+	}
+	if source.ID == rule.PocketID {
+		return apperrors.ErrSameAccount
+	}
+	return nil
+}
+
+func (s *PocketService) findPocket(db *gorm.DB, userID, id uint) (*models.Account, error) {
+	var pocket models.Account
+	if err := db.Where("user_id = ? AND account_type = ?", userID, models.Pocket).First(&pocket, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrPocketNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &pocket, nil
+}
+
+func pocketStatus(pocket models.Account, now time.Time) models.PocketStatus {
+	status := models.PocketStatus{Account: pocket}
+	if pocket.TargetAmount == nil || *pocket.TargetAmount <= 0 {
+		return status
+	}
+	target := *pocket.TargetAmount
+
+	status.Remaining = roundMoney(max(target-pocket.Balance, 0))
+	status.ProgressPercent = roundMoney(min(pocket.Balance/target*100, 100))
+	status.Reached = pocket.Balance >= target
+	if pocket.TargetDate != nil {
+		days := max(int(math.Ceil(pocket.TargetDate.Sub(now).Hours()/24)), 0)
+		status.DaysLeft = &days
+		if !status.Reached {
+			months := max(float64(days)/30, 1)
+			status.SuggestedMonthly = roundMoney(status.Remaining / months)
+		}
+	}
+	return status
+}
+
+// checkSpendable stops locked accounts from paying anyone but their owner, and from
+// paying at all before the lock ends
+func checkSpendable(sender, receiver *models.Account) error {
+	if !sender.IsLocked {
+		return nil
+	}
+	if receiver.UserId != sender.UserId {
+		return apperrors.ErrPocketLocked
+	}
+	if sender.LockedUntil != nil && time.Now().Before(*sender.LockedUntil) {
+		return apperrors.ErrPocketLocked
+	}
+	return nil
+}
+
+// applyRoundUpTx saves the round-up of a payment into the pocket of the sender's round-up
+// rule. It runs inside the payment's db transaction; when the balance can not cover the
+// round-up nothing is saved and the payment still goes through.
+func applyRoundUpTx(tx *gorm.DB, transaction *models.Transaction, sender, receiver *models.Account) error {
+	// moving money between your own accounts is not spending
+	if receiver.UserId == sender.UserId {
+		return nil
+	}
+
+	var rule models.AutoSaveRule
+	err := tx.Where("source_account_id = ? AND type = ? AND is_active = ?", sender.ID, models.AutoSaveRoundUp, true).First(&rule).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if rule.RoundTo <= 0 {
+		return nil
+	}
+
+	extra := roundMoney(math.Ceil(transaction.Amount/rule.RoundTo)*rule.RoundTo - transaction.Amount)
+	if extra <= 0 || sender.Balance < extra {
+		return nil
+	}
+
+	saving := &models.Transaction{
+		Amount:              extra,
+		SenderAccountID:     sender.ID,
+		ReceiverAccountID:   rule.PocketID,
+		Type:                models.Transfer,
+		Category:            models.TransferCat,
+		Status:              models.TransactionCompleted,
+		ServiceType:         models.ServiceNone,
+		Description:         fmt.Sprintf("Round-up of transaction #%d", transaction.ID),
+		ParentTransactionID: &transaction.ID,
+	}
+	if err := createTransactionTx(tx, saving); err != nil {
+		return err
+	}
+	return tx.Model(&rule).Update("last_saved_at", time.Now()).Error
+}
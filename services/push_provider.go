@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"gopay-clone/config"
+	"gopay-clone/models"
+	"log"
+)
+
+// PushMessage is what a push provider shows on the device
+type PushMessage struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// PushProvider delivers push messages to device tokens, a real provider (FCM, APNs...) only has to implement Push
+type PushProvider interface {
+	Push(ctx context.Context, device models.DeviceToken, message PushMessage) error
+}
+
+// NewPushProvider picks the provider from PUSH_DRIVER, both built-in ones are meant for development
+func NewPushProvider(cfg config.NotificationConfig) PushProvider {
+	if cfg.PushDriver == "none" {
+		return NoopPushProvider{}
+	}
+	return LogPushProvider{}
+}
+
+// LogPushProvider writes push messages to the application log
+type LogPushProvider struct{}
+
+func (LogPushProvider) Push(ctx context.Context, device models.DeviceToken, message PushMessage) error {
+	log.Printf("push to %s device of user %d: %s: %s", device.Platform, device.UserID, message.Title, message.Body)
+	return nil
+}
+
+// NoopPushProvider drops push messages, notifications are still kept in the app
+type NoopPushProvider struct{}
+
+func (NoopPushProvider) Push(ctx context.Context, device models.DeviceToken, message PushMessage) error {
+	return nil
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"bytes"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"image"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// RenderImage draws the payload of the QR and returns the image with its content type. A logo
+// that can't be fetched is left out instead of failing the image.
+func (s *QRService) RenderImage(qr *models.QrCode, opts models.QrImageOptions) ([]byte, string, error) {
+	if opts.Size == 0 {
+		opts.Size = s.cfg.ImageSize
+	}
+	if opts.Size < s.cfg.ImageMinSize || opts.Size > s.cfg.ImageMaxSize {
+		return nil, "", apperrors.ErrQRImageSize
+	}
+	if opts.Level == "" {
+		opts.Level = "M"
+	}
+
+	content, err := s.qrContent(qr)
+	if err != nil {
+		return nil, "", err
+	}
+	var logo image.Image
+	if opts.Logo {
+		// the logo hides modules in the middle, only the highest level restores them
+		opts.Level = "H"
+		logo = s.merchantLogo(qr)
+	}
+
+	var body []byte
+	contentType := "image/png"
+	if opts.Format == "svg" {
+		contentType = "image/svg+xml"
+		body, err = utils.QRImageSVG(content, opts.Level, opts.Size, logo)
+	} else {
+		body, err = utils.QRImagePNG(content, opts.Level, opts.Size, logo)
+	}
+	if err != nil {
+		return nil, "", apperrors.ErrQRRenderFailed
+	}
+	return body, contentType, nil
+}
+
+// Standee renders a printable PDF of a static QR of the merchant, with the merchant logo when there is one
+func (s *QRService) Standee(merchantID, qrID uint) ([]byte, error) {
+	qr, err := s.merchantQR(merchantID, qrID)
+	if err != nil {
+		return nil, err
+	}
+	if !qr.IsStatic() {
+		return nil, apperrors.ErrQRNotStatic
+	}
+	if qr.DisabledAt != nil {
+		return nil, apperrors.ErrQRDisabled
+	}
+
+	var merchant models.MerchantProfile
+	if err := s.db.First(&merchant, merchantID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrMerchantNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	img, _, err := s.RenderImage(qr, models.QrImageOptions{Format: "png", Size: s.cfg.ImageMaxSize, Logo: true})
+	if err != nil {
+		return nil, err
+	}
+	body, err := utils.QRStandeePDF(&models.QrStandee{
+		MerchantName: merchant.MerchantName,
+		Location:     merchant.Location,
+		QrCodeID:     qr.ID,
+		Image:        img,
+	})
+	if err != nil {
+		return nil, apperrors.ErrQRRenderFailed
+	}
+	return body, nil
+}
+
+// qrContent is the EMVCo payload, QRs created before payloads existed get theirs now
+func (s *QRService) qrContent(qr *models.QrCode) (string, error) {
+	if qr.Payload != "" {
+		return qr.Payload, nil
+	}
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return storeQrPayloadTx(tx, s.cfg, qr)
+	}); err != nil {
+		return "", err
+	}
+	return qr.Payload, nil
+}
+
+// merchantLogo is the stored logo of the merchant the QR pays, nil when there is none or it can't be used
+func (s *QRService) merchantLogo(qr *models.QrCode) image.Image {
+	merchantID := s.db.Model(&models.MerchantProfile{}).Select("id")
+	if qr.MerchantID != nil {
+		merchantID = merchantID.Where("id = ?", *qr.MerchantID)
+	} else {
+		// a personal QR of a merchant owner still pays the merchant's main balance
+		merchantID = merchantID.Where("user_id = (?)", s.db.Model(&models.Account{}).Select("user_id").Where("id = ?", qr.ReceiverAccountID))
+	}
+	var stored models.MerchantLogo
+	if err := s.db.Where("merchant_id = (?)", merchantID).First(&stored).Error; err != nil {
+		return nil
+	}
+
+	logo, _, err := image.Decode(bytes.NewReader(stored.Image))
+	if err != nil {
+		log.Printf("qr %d: merchant %d logo: %v", qr.ID, stored.MerchantID, err)
+		return nil
+	}
+	return logo
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// EMVCo merchant-presented QR field ids
+const (
+	emvPayloadFormat   = "00"
+	emvInitiation      = "01"
+	emvMerchantAccount = "26" // first of the merchant account templates 26-51
+	emvCategoryCode    = "52"
+	emvCurrency        = "53"
+	emvAmount          = "54"
+	emvCountry         = "58"
+	emvMerchantName    = "59"
+	emvMerchantCity    = "60"
+	emvAdditionalData  = "62"
+
+	// inside our merchant account template
+	emvAccountGUI = "00"
+	emvAccountID  = "01"
+	emvAccountQR  = "02"
+
+	// inside the additional data template
+	emvBillNumber    = "01"
+	emvTerminalLabel = "07"
+
+	emvStatic  = "11"
+	emvDynamic = "12"
+)
+
+// qrPayloadTx builds the EMVCo payload of a stored QR. Merchant QRs carry the merchant name and
+// location, other QRs the receiver's name. Static QRs leave the amount to the payer.
+func qrPayloadTx(tx *gorm.DB, cfg config.QrConfig, qr *models.QrCode) (string, error) {
+	name, city, err := qrReceiverTx(tx, cfg, qr)
+	if err != nil {
+		return "", err
+	}
+
+	initiation := emvDynamic
+	if qr.IsStatic() {
+		initiation = emvStatic
+	}
+	account, err := utils.EncodeTLV([]utils.TLV{
+		{ID: emvAccountGUI, Value: cfg.GUI},
+		{ID: emvAccountID, Value: strconv.FormatUint(uint64(qr.ReceiverAccountID), 10)},
+		{ID: emvAccountQR, Value: strconv.FormatUint(uint64(qr.ID), 10)},
+	})
+	if err != nil {
+		return "", apperrors.ErrQRCreateFailed
+	}
+
+	fields := []utils.TLV{
+		{ID: emvPayloadFormat, Value: "01"},
+		{ID: emvInitiation, Value: initiation},
+		{ID: emvMerchantAccount, Value: account},
+		{ID: emvCategoryCode, Value: cfg.MerchantCategoryCode},
+		{ID: emvCurrency, Value: cfg.CurrencyCode},
+	}
+	if initiation == emvDynamic {
+		fields = append(fields, utils.TLV{ID: emvAmount, Value: strconv.FormatFloat(qr.Amount, 'f', -1, 64)})
+	}
+	fields = append(fields,
+		utils.TLV{ID: emvCountry, Value: cfg.CountryCode},
+		utils.TLV{ID: emvMerchantName, Value: emvText(name, 25)},
+		utils.TLV{ID: emvMerchantCity, Value: emvText(city, 15)},
+	)
+	var additional []utils.TLV
+	if reference := emvText(qr.Reference, 25); reference != "" {
+		additional = append(additional, utils.TLV{ID: emvBillNumber, Value: reference})
+	}
+	if label := emvText(qr.Label, 25); label != "" {
+		additional = append(additional, utils.TLV{ID: emvTerminalLabel, Value: label})
+	}
+	if len(additional) > 0 {
+		value, err := utils.EncodeTLV(additional)
+		if err != nil {
+			return "", apperrors.ErrQRCreateFailed
+		}
+		fields = append(fields, utils.TLV{ID: emvAdditionalData, Value: value})
+	}
+
+	payload, err := utils.EncodeTLV(fields)
+	if err != nil {
+		return "", apperrors.ErrQRCreateFailed
+	}
+	return utils.AppendCRC(payload), nil
+}
+
+// storeQrPayloadTx generates the payload of a just created QR, it needs the id of the QR
+func storeQrPayloadTx(tx *gorm.DB, cfg config.QrConfig, qr *models.QrCode) error {
+	payload, err := qrPayloadTx(tx, cfg, qr)
+	if err != nil {
+		return err
+	}
+	qr.Payload = payload
+	if err := tx.Model(qr).Update("payload", payload).Error; err != nil {
+		return apperrors.ErrQRCreateFailed
+	}
+	return nil
+}
+
+// qrReceiverTx is the name and city shown to the payer
+func qrReceiverTx(tx *gorm.DB, cfg config.QrConfig, qr *models.QrCode) (string, string, error) {
+	if qr.MerchantID != nil {
+		var merchant models.MerchantProfile
+		if err := tx.Select("merchant_name", "location").First(&merchant, *qr.MerchantID).Error; err != nil {
+			return "", "", apperrors.ErrMerchantNotFound
+		}
+		city := merchant.Location
+		if emvText(city, 15) == "" {
+			city = cfg.DefaultCity
+		}
+		return merchant.MerchantName, city, nil
+	}
+	var account models.Account
+	if err := tx.Preload("User").First(&account, qr.ReceiverAccountID).Error; err != nil {
+		return "", "", apperrors.ErrAccountNotFound
+	}
+	return account.User.Name, cfg.DefaultCity, nil
+}
+
+// parseQrPayload checks a scanned payload and returns its top level fields and our merchant account template
+func parseQrPayload(cfg config.QrConfig, raw string) (map[string]string, map[string]string, error) {
+	raw = strings.TrimSpace(raw)
+	if !utils.CheckCRC(raw) {
+		return nil, nil, apperrors.ErrQRChecksumMismatch
+	}
+	fields, err := utils.ParseTLV(raw)
+	if err != nil {
+		return nil, nil, apperrors.ErrInvalidQRPayload
+	}
+	top := tlvMap(fields)
+	if top[emvPayloadFormat] != "01" {
+		return nil, nil, apperrors.ErrInvalidQRPayload
+	}
+
+	// the payload may list several providers, ours is the template with our GUI
+	for id := 26; id <= 51; id++ {
+		template, ok := top[strconv.Itoa(id)]
+		if !ok {
+			continue
+		}
+		sub, err := utils.ParseTLV(template)
+		if err != nil {
+			return nil, nil, apperrors.ErrInvalidQRPayload
+		}
+		if account := tlvMap(sub); account[emvAccountGUI] == cfg.GUI {
+			return top, account, nil
+		}
+	}
+	return nil, nil, apperrors.ErrQRUnsupported
+}
+
+func tlvMap(fields []utils.TLV) map[string]string {
+	m := make(map[string]string, len(fields))
+	for _, f := range fields {
+		m[f.ID] = f.Value
+	}
+	return m
+}
+
+// emvText keeps the printable ASCII EMVCo allows in names and cuts it to n characters
+func emvText(s string, n int) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= 0x20 && r <= 0x7E {
+			b.WriteRune(r)
+		}
+	}
+	text := strings.Join(strings.Fields(b.String()), " ")
+	if len(text) > n {
+		text = strings.TrimSpace(text[:n])
+	}
+	return text
+}
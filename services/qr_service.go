@@ -4,17 +4,20 @@ import (
 	"gopay-clone/config"
 	apperrors "gopay-clone/errors"
 	"gopay-clone/models"
+	"strconv"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type QRService struct {
-	db *config.Database
+	db  *config.Database
+	cfg config.QrConfig
 }
 
-func NewQRService(db *config.Database) *QRService {
-	return &QRService{db: db}
+func NewQRService(db *config.Database, cfg config.QrConfig) *QRService {
+	return &QRService{db: db, cfg: cfg}
 }
 
 func (s *QRService) CreateQR(qr *models.QrCode) error {
@@ -27,11 +30,65 @@ func (s *QRService) CreateQR(qr *models.QrCode) error {
 	}
 
 	qr.ReceiverAccount = account
-	if err := s.db.Create(qr).Error; err != nil {
-		return apperrors.ErrQRCreateFailed
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(qr).Error; err != nil {
+			return apperrors.ErrQRCreateFailed
+		}
+		return storeQrPayloadTx(tx, s.cfg, qr)
+	})
+}
+
+// ParsePayload decodes a scanned payload for the payer app and resolves it to the stored QR,
+// the stored QR is what gets paid so a payload that disagrees with it is refused
+func (s *QRService) ParsePayload(raw string) (*models.QrPayloadInfo, error) {
+	top, account, err := parseQrPayload(s.cfg, raw)
+	if err != nil {
+		return nil, err
+	}
+	accountID, err := strconv.ParseUint(account[emvAccountID], 10, 64)
+	if err != nil {
+		return nil, apperrors.ErrInvalidQRPayload
+	}
+	qrID, err := strconv.ParseUint(account[emvAccountQR], 10, 64)
+	if err != nil {
+		return nil, apperrors.ErrInvalidQRPayload
+	}
+
+	var qr models.QrCode
+	if err := s.db.Preload("ReceiverAccount.User").First(&qr, qrID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrQRNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	static := top[emvInitiation] == emvStatic
+	if qr.ReceiverAccountID != uint(accountID) || static != qr.IsStatic() {
+		return nil, apperrors.ErrQRPayloadMismatch
+	}
+	if amount, ok := top[emvAmount]; ok {
+		if parsed, err := strconv.ParseFloat(amount, 64); err != nil || parsed != qr.Amount {
+			return nil, apperrors.ErrQRPayloadMismatch
+		}
 	}
 
-	return nil
+	info := &models.QrPayloadInfo{
+		Static:            static,
+		MerchantName:      top[emvMerchantName],
+		MerchantCity:      top[emvMerchantCity],
+		CategoryCode:      top[emvCategoryCode],
+		Currency:          top[emvCurrency],
+		Reference:         qr.Reference,
+		QrCodeID:          qr.ID,
+		ReceiverAccountID: qr.ReceiverAccountID,
+		ReceiverName:      qr.ReceiverAccount.User.Name,
+		MerchantID:        qr.MerchantID,
+		Label:             qr.Label,
+		Payable:           qr.DisabledAt == nil && !qr.IsUsed && !qr.Expired(time.Now()),
+	}
+	if !static {
+		info.Amount = &qr.Amount
+	}
+	return info, nil
 }
 
 func (s *QRService) GetQRById(id uint) (*models.QrCode, error) {
@@ -48,67 +105,98 @@ func (s *QRService) GetQRById(id uint) (*models.QrCode, error) {
 	return &qr, nil
 }
 
-func (s *QRService) ScanQR(qr *models.QrCode, senderAccountId uint) error {
-	if qr.ExpiresAt.Before(time.Now()) {
-		return apperrors.ErrQRExpired
+// PaymentAmount is what paying the QR costs. The payer enters the amount of a static QR, a
+// dynamic QR is paid for its own amount.
+func (s *QRService) PaymentAmount(qr *models.QrCode, entered float64) (float64, error) {
+	if !qr.IsStatic() {
+		if entered != 0 && entered != qr.Amount {
+			return 0, apperrors.ErrQRAmountFixed
+		}
+		return qr.Amount, nil
+	}
+	amount := roundMoney(entered)
+	if amount <= 0 {
+		return 0, apperrors.ErrQRAmountRequired
+	}
+	return amount, nil
+}
+
+// ScanQR pays the QR from the sender account and returns the created transaction, entered is
+// the amount the payer typed in for a static QR
+func (s *QRService) ScanQR(qr *models.QrCode, senderAccountId uint, entered float64) (*models.Transaction, error) {
+	if qr.Expired(time.Now()) {
+		return nil, apperrors.ErrQRExpired
 	}
 
 	if qr.IsUsed {
-		return apperrors.ErrQRAlreadyUsed
+		return nil, apperrors.ErrQRAlreadyUsed
 	}
 
-	var sender models.Account
-	var receiver models.Account
+	if qr.DisabledAt != nil {
+		return nil, apperrors.ErrQRDisabled
+	}
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.First(&sender, senderAccountId).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return apperrors.ErrAccountNotFound
-			}
-			return apperrors.NewInternalError("Failed to fetch sender account")
-		}
+	amount, err := s.PaymentAmount(qr, entered)
+	if err != nil {
+		return nil, err
+	}
 
-		if err := tx.First(&receiver, qr.ReceiverAccountID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return apperrors.ErrAccountNotFound
-			}
-			return apperrors.NewInternalError("Failed to fetch receiver account")
-		}
+	createdTransaction := models.Transaction{
+		Amount:            amount,
+		SenderAccountID:   senderAccountId,
+		ReceiverAccountID: qr.ReceiverAccountID,
+		QrCodeID:          &qr.ID,
+		Status:            models.TransactionCompleted,
+	}
 
-		if sender.Balance < qr.Amount {
-			return apperrors.ErrInsufficientBalance
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// two scans of the same dynamic code must not both pay, payments of a static code only
+		// have to wait for it being disabled
+		strength := "UPDATE"
+		if qr.IsStatic() {
+			strength = "SHARE"
 		}
-
-		sender.Balance -= qr.Amount
-		receiver.Balance += qr.Amount
-		qr.IsUsed = true
-
-		if err := tx.Save(&sender).Error; err != nil {
-			return apperrors.ErrTransactionFailed
+		var locked models.QrCode
+		if err := tx.Clauses(clause.Locking{Strength: strength}).First(&locked, qr.ID).Error; err != nil {
+			return apperrors.ErrQRNotFound
 		}
-		if err := tx.Save(&receiver).Error; err != nil {
-			return apperrors.ErrTransactionFailed
+		if locked.IsUsed {
+			return apperrors.ErrQRAlreadyUsed
 		}
-
-		createdTransaction := models.Transaction{
-			Amount:            qr.Amount,
-			SenderAccountID:   sender.ID,
-			SenderAccount:     sender,
-			ReceiverAccountID: receiver.ID,
-			ReceiverAccount:   receiver,
-			QrCodeID:          &qr.ID,
-			QrCode:            qr,
-			Status:            models.TransactionCompleted,
+		if locked.DisabledAt != nil {
+			return apperrors.ErrQRDisabled
 		}
 
-		if err := tx.Create(&createdTransaction).Error; err != nil {
-			return apperrors.ErrTransactionFailed
+		if err := createTransactionTx(tx, &createdTransaction); err != nil {
+			return err
 		}
 
-		if err := tx.Save(qr).Error; err != nil {
-			return apperrors.ErrTransactionFailed
+		if !qr.IsStatic() {
+			qr.IsUsed = true
+			if err := tx.Save(qr).Error; err != nil {
+				return apperrors.ErrTransactionFailed
+			}
 		}
 
-		return nil
+		return publishEventTx(tx, models.EventPaymentCompleted, locked.ID, models.PaymentEvent{
+			QrCodeID:          locked.ID,
+			MerchantID:        locked.MerchantID,
+			Reference:         locked.Reference,
+			TransactionID:     createdTransaction.ID,
+			SenderAccountID:   createdTransaction.SenderAccountID,
+			ReceiverAccountID: createdTransaction.ReceiverAccountID,
+			Amount:            createdTransaction.Amount,
+			PaidAt:            createdTransaction.CreatedAt,
+		})
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	// respond with both accounts like before
+	if err := s.db.Preload("SenderAccount").Preload("ReceiverAccount").First(&createdTransaction, createdTransaction.ID).Error; err != nil {
+		return nil, apperrors.NewInternalError("Failed to fetch transaction")
+	}
+	createdTransaction.QrCode = qr
+	return &createdTransaction, nil
 }
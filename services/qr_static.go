@@ -0,0 +1,165 @@
+package services
+
+import (
+	"fmt"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreateStaticQR creates a reusable QR of the merchant paid into the main balance of its owner,
+// every scan is a payment of its own for the amount the payer enters
+func (s *QRService) CreateStaticQR(merchantID uint, label string) (*models.QrCodeSummary, error) {
+	var merchant models.MerchantProfile
+	if err := s.db.First(&merchant, merchantID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrMerchantNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	var count int64
+	if err := s.db.Model(&models.QrCode{}).
+		Where("merchant_id = ? AND type = ? AND disabled_at IS NULL", merchantID, models.QrStatic).
+		Count(&count).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if int(count) >= s.cfg.MaxStaticQrs {
+		return nil, apperrors.ErrStaticQRLimit
+	}
+
+	var account models.Account
+	if err := mainBalance(s.db.DB, merchant.UserId, &account); err != nil {
+		return nil, err
+	}
+	qr := models.QrCode{
+		Type:              models.QrStatic,
+		ReceiverAccountID: account.ID,
+		URL:               fmt.Sprintf("goclone://pay?merchant=%d", merchantID),
+		MerchantID:        &merchantID,
+		Label:             label,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&qr).Error; err != nil {
+			return apperrors.ErrQRCreateFailed
+		}
+		return storeQrPayloadTx(tx, s.cfg, &qr)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.QrCodeSummary{QrCode: qr}, nil
+}
+
+// GetStaticQRs lists the static QRs of the merchant, disabled ones included, with their totals
+func (s *QRService) GetStaticQRs(merchantID uint) ([]models.QrCodeSummary, error) {
+	var qrs []models.QrCode
+	if err := s.db.Where("merchant_id = ? AND type = ?", merchantID, models.QrStatic).
+		Order("created_at DESC").Find(&qrs).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return s.withTotals(qrs)
+}
+
+// GetMerchantQR returns a QR of the merchant with its totals, static or not
+func (s *QRService) GetMerchantQR(merchantID, qrID uint) (*models.QrCodeSummary, error) {
+	qr, err := s.merchantQR(merchantID, qrID)
+	if err != nil {
+		return nil, err
+	}
+	withTotals, err := s.withTotals([]models.QrCode{*qr})
+	if err != nil {
+		return nil, err
+	}
+	return &withTotals[0], nil
+}
+
+// DisableStaticQR stops a printed QR from being paid, e.g. when the standee is lost
+func (s *QRService) DisableStaticQR(merchantID, qrID uint) error {
+	qr, err := s.merchantQR(merchantID, qrID)
+	if err != nil {
+		return err
+	}
+	if !qr.IsStatic() {
+		return apperrors.ErrQRNotStatic
+	}
+	if qr.DisabledAt != nil {
+		return nil
+	}
+	if err := s.db.Model(qr).Update("disabled_at", time.Now()).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+// GetQRPayments is the payment history of a QR of the merchant, refunds of those payments included
+func (s *QRService) GetQRPayments(merchantID, qrID uint, page *utils.PageParams) ([]models.Transaction, *utils.PageMeta, error) {
+	qr, err := s.merchantQR(merchantID, qrID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var transactions []models.Transaction
+	query := s.db.Where("qr_code_id = ?", qr.ID).
+		Where("(type <> ? AND receiver_account_id = ?) OR (type = ? AND sender_account_id = ?)", models.Refund, qr.ReceiverAccountID, models.Refund, qr.ReceiverAccountID)
+	if err := page.Apply(query).Find(&transactions).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	transactions, meta := utils.Page(transactions, page, func(t models.Transaction) (uint, map[string]any) {
+		return t.ID, map[string]any{"created_at": t.CreatedAt, "amount": t.Amount}
+	})
+	return transactions, meta, nil
+}
+
+func (s *QRService) merchantQR(merchantID, qrID uint) (*models.QrCode, error) {
+	var qr models.QrCode
+	if err := s.db.Where("id = ? AND merchant_id = ?", qrID, merchantID).First(&qr).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrQRNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &qr, nil
+}
+
+// withTotals sums the completed payments and refunds of every QR in one query, refunds carry
+// the QR of the payment they return. Only payments into the QR's account and refunds out of it count.
+func (s *QRService) withTotals(qrs []models.QrCode) ([]models.QrCodeSummary, error) {
+	result := make([]models.QrCodeSummary, len(qrs))
+	if len(qrs) == 0 {
+		return result, nil
+	}
+	ids := make([]uint, len(qrs))
+	for i, qr := range qrs {
+		ids[i] = qr.ID
+	}
+
+	var rows []struct {
+		QrCodeID uint
+		models.QrPaymentTotals
+	}
+	if err := s.db.Model(&models.Transaction{}).
+		Select(`transactions.qr_code_id,
+			COALESCE(SUM(CASE WHEN transactions.type <> ? THEN 1 ELSE 0 END), 0) AS payments,
+			COALESCE(SUM(CASE WHEN transactions.type <> ? THEN transactions.amount ELSE 0 END), 0) AS total_amount,
+			COALESCE(SUM(CASE WHEN transactions.type = ? THEN transactions.amount ELSE 0 END), 0) AS refunded_amount,
+			MAX(CASE WHEN transactions.type <> ? THEN transactions.created_at END) AS last_paid_at`, models.Refund, models.Refund, models.Refund, models.Refund).
+		Joins("JOIN qr_codes ON qr_codes.id = transactions.qr_code_id").
+		Where("transactions.qr_code_id IN ? AND transactions.status = ?", ids, models.TransactionCompleted).
+		Where("(transactions.type <> ? AND transactions.receiver_account_id = qr_codes.receiver_account_id) OR (transactions.type = ? AND transactions.sender_account_id = qr_codes.receiver_account_id)", models.Refund, models.Refund).
+		Group("transactions.qr_code_id").
+		Scan(&rows).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	totals := make(map[uint]models.QrPaymentTotals, len(rows))
+	for _, row := range rows {
+		row.TotalAmount = roundMoney(row.TotalAmount)
+		row.RefundedAmount = roundMoney(row.RefundedAmount)
+		totals[row.QrCodeID] = row.QrPaymentTotals
+	}
+	for i, qr := range qrs {
+		result[i] = models.QrCodeSummary{QrCode: qr, Totals: totals[qr.ID]}
+	}
+	return result, nil
+}
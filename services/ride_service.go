@@ -0,0 +1,72 @@
+package services
+
+import (
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// rideTransitions are the statuses a ride may move to from each status, completed and
+// cancelled rides stay where they are
+var rideTransitions = map[models.RideStatus][]models.RideStatus{
+	models.RideAccepted: {models.RidePickup, models.RideCancelled},
+	models.RidePickup:   {models.RideOngoing, models.RideCancelled},
+	models.RideOngoing:  {models.RideCompleted},
+}
+
+type RideService struct {
+	db *config.Database
+}
+
+func NewRideService(db *config.Database) *RideService {
+	return &RideService{db: db}
+}
+
+// UpdateRideStatus moves a ride of the driver along, a completed ride writes ride.completed
+// in the same db transaction
+func (s *RideService) UpdateRideStatus(rideID, driverID uint, status models.RideStatus) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var ride models.Ride
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ride, rideID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperrors.ErrRideNotFound
+			}
+			return apperrors.ErrDatabaseError
+		}
+		// another driver's ride is reported as missing, like an order of another user
+		if ride.DriverID == nil || *ride.DriverID != driverID {
+			return apperrors.ErrRideNotFound
+		}
+		if !rideCanMove(ride.Status, status) {
+			return apperrors.ErrRideStatusTransition
+		}
+
+		if err := tx.Model(&ride).Update("status", status).Error; err != nil {
+			return apperrors.ErrRideStatusUpdateFailed
+		}
+		ride.Status = status
+		if status != models.RideCompleted {
+			return nil
+		}
+		return publishEventTx(tx, models.EventRideCompleted, ride.ID, models.RideEvent{
+			RideID:        ride.ID,
+			UserID:        ride.UserID,
+			DriverID:      ride.DriverID,
+			Status:        ride.Status,
+			Fare:          ride.Fare,
+			TransactionID: ride.TransactionID,
+		})
+	})
+}
+
+func rideCanMove(from, to models.RideStatus) bool {
+	for _, next := range rideTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScheduledTransferService struct {
+	db *config.Database
+}
+
+func NewScheduledTransferService(db *config.Database) *ScheduledTransferService {
+	return &ScheduledTransferService{db: db}
+}
+
+// CreateSchedule checks both accounts and points NextRunAt at the first occurrence
+func (s *ScheduledTransferService) CreateSchedule(schedule *models.ScheduledTransfer) error {
+	var sender, receiver models.Account
+	if err := s.db.First(&sender, schedule.SenderAccountID).Error; err != nil {
+		return apperrors.ErrAccountNotFound
+	}
+	if sender.UserId != schedule.UserID {
+		return apperrors.ErrForbidden
+	}
+	if err := s.db.First(&receiver, schedule.ReceiverAccountID).Error; err != nil {
+		return apperrors.ErrAccountNotFound
+	}
+	if sender.ID == receiver.ID {
+		return apperrors.ErrSameAccount
+	}
+
+	if schedule.Interval < 1 {
+		schedule.Interval = 1
+	}
+	if schedule.Category == "" {
+		schedule.Category = models.TransferCat
+	}
+	schedule.Status = models.ScheduleActive
+	schedule.Occurrence = 0
+	schedule.NextRunAt = schedule.StartAt
+
+	if err := s.db.Create(schedule).Error; err != nil {
+		return apperrors.ErrScheduleCreateFailed
+	}
+	return nil
+}
+
+func (s *ScheduledTransferService) GetSchedules(userID uint, page *utils.PageParams) ([]models.ScheduledTransfer, *utils.PageMeta, error) {
+	var schedules []models.ScheduledTransfer
+	if err := page.Apply(s.db.Where("user_id = ?", userID)).Find(&schedules).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	schedules, meta := utils.Page(schedules, page, func(st models.ScheduledTransfer) (uint, map[string]any) {
+		return st.ID, map[string]any{"created_at": st.CreatedAt, "next_run_at": st.NextRunAt, "amount": st.Amount}
+	})
+	return schedules, meta, nil
+}
+
+func (s *ScheduledTransferService) GetSchedule(userID, id uint) (*models.ScheduledTransfer, error) {
+	var schedule models.ScheduledTransfer
+	if err := s.db.Where("user_id = ?", userID).First(&schedule, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrScheduleNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &schedule, nil
+}
+
+// GetRuns lists every attempt of a schedule, newest first
+func (s *ScheduledTransferService) GetRuns(userID, id uint, page *utils.PageParams) ([]models.ScheduledTransferRun, *utils.PageMeta, error) {
+	if _, err := s.GetSchedule(userID, id); err != nil {
+		return nil, nil, err
+	}
+	var runs []models.ScheduledTransferRun
+	if err := page.Apply(s.db.Where("schedule_id = ?", id)).Find(&runs).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	runs, meta := utils.Page(runs, page, func(r models.ScheduledTransferRun) (uint, map[string]any) {
+		return r.ID, map[string]any{"created_at": r.CreatedAt, "scheduled_for": r.ScheduledFor}
+	})
+	return runs, meta, nil
+}
+
+func (s *ScheduledTransferService) PauseSchedule(userID, id uint) (*models.ScheduledTransfer, error) {
+	return s.changeStatus(userID, id, func(schedule *models.ScheduledTransfer) error {
+		if schedule.Status != models.ScheduleActive {
+			return apperrors.ErrScheduleNotActive
+		}
+		schedule.Status = models.SchedulePaused
+		schedule.RetryAt = nil
+		schedule.Attempts = 0
+		return nil
+	})
+}
+
+// ResumeSchedule skips the occurrences that passed while the schedule was paused,
+// a one off transfer whose date passed runs on the next scheduler tick
+func (s *ScheduledTransferService) ResumeSchedule(userID, id uint) (*models.ScheduledTransfer, error) {
+	return s.changeStatus(userID, id, func(schedule *models.ScheduledTransfer) error {
+		if schedule.Status != models.SchedulePaused {
+			return apperrors.ErrScheduleNotPaused
+		}
+		if schedule.Frequency != models.ScheduleOnce {
+			if !advanceSchedule(schedule, time.Now()) {
+				return apperrors.ErrScheduleFinished
+			}
+		}
+		schedule.Status = models.ScheduleActive
+		return nil
+	})
+}
+
+func (s *ScheduledTransferService) CancelSchedule(userID, id uint) (*models.ScheduledTransfer, error) {
+	return s.changeStatus(userID, id, func(schedule *models.ScheduledTransfer) error {
+		if schedule.Status != models.ScheduleActive && schedule.Status != models.SchedulePaused {
+			return apperrors.ErrScheduleNotActive
+		}
+		schedule.Status = models.ScheduleCancelled
+		schedule.RetryAt = nil
+		return nil
+	})
+}
+
+// changeStatus locks the row so a user action never races the scheduler
+func (s *ScheduledTransferService) changeStatus(userID, id uint, change func(*models.ScheduledTransfer) error) (*models.ScheduledTransfer, error) {
+	var schedule models.ScheduledTransfer
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&schedule, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperrors.ErrScheduleNotFound
+			}
+			return apperrors.ErrDatabaseError
+		}
+		if err := change(&schedule); err != nil {
+			return err
+		}
+		if err := tx.Save(&schedule).Error; err != nil {
+			return apperrors.ErrScheduleUpdateFailed
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// DueSchedules returns active schedules whose occurrence or retry is due
+func (s *ScheduledTransferService) DueSchedules(now time.Time, limit int) ([]models.ScheduledTransfer, error) {
+	var schedules []models.ScheduledTransfer
+	err := s.db.Where("status = ?", models.ScheduleActive).
+		Where("COALESCE(retry_at, next_run_at) <= ?", now).
+		Order("COALESCE(retry_at, next_run_at) ASC").
+		Limit(limit).
+		Find(&schedules).Error
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return schedules, nil
+}
+
+// lockDueScheduleTx locks the schedule for its run, nil when it is not due anymore, was paused
+// or another scheduler holds it. The lock is kept until the run is recorded.
+func lockDueScheduleTx(tx *gorm.DB, id uint, now time.Time) (*models.ScheduledTransfer, error) {
+	var schedule models.ScheduledTransfer
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND COALESCE(retry_at, next_run_at) <= ?", models.ScheduleActive, now).
+		First(&schedule, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &schedule, nil
+}
+
+// finishRunTx stores the outcome of a run together with the schedule's new state
+func finishRunTx(tx *gorm.DB, schedule *models.ScheduledTransfer, run *models.ScheduledTransferRun) error {
+	if err := tx.Save(run).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if err := tx.Model(schedule).
+		Select("status", "occurrence", "next_run_at", "retry_at", "attempts", "success_count", "last_run_at").
+		Updates(schedule).Error; err != nil {
+		return apperrors.ErrScheduleUpdateFailed
+	}
+	return nil
+}
+
+// occurrenceAt is the time of occurrence n counted from StartAt in the schedule's timezone.
+// Monthly schedules started on the 31st run on the last day of shorter months.
+func occurrenceAt(schedule *models.ScheduledTransfer, n int) time.Time {
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	start := schedule.StartAt.In(loc)
+	step := n * schedule.Interval
+
+	switch schedule.Frequency {
+	case models.ScheduleDaily:
+		return start.AddDate(0, 0, step)
+	case models.ScheduleWeekly:
+		return start.AddDate(0, 0, 7*step)
+	case models.ScheduleMonthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(step), 1,
+			start.Hour(), start.Minute(), start.Second(), 0, loc)
+		lastDay := first.AddDate(0, 1, -1).Day()
+		return first.AddDate(0, 0, min(start.Day(), lastDay)-1)
+	}
+	return start
+}
+
+// advanceSchedule moves to the first occurrence after now, skipping missed ones rather
+// than paying them all at once. It marks the schedule completed and returns false when
+// no occurrence is left.
+func advanceSchedule(schedule *models.ScheduledTransfer, now time.Time) bool {
+	schedule.RetryAt = nil
+	schedule.Attempts = 0
+	if schedule.Frequency == models.ScheduleOnce {
+		schedule.Status = models.ScheduleCompleted
+		return false
+	}
+
+	next := schedule.Occurrence + 1
+	at := occurrenceAt(schedule, next)
+	for !at.After(now) {
+		next++
+		at = occurrenceAt(schedule, next)
+	}
+	if (schedule.MaxRuns != nil && next >= *schedule.MaxRuns) || (schedule.EndAt != nil && at.After(*schedule.EndAt)) {
+		schedule.Status = models.ScheduleCompleted
+		return false
+	}
+	schedule.Occurrence = next
+	schedule.NextRunAt = at
+	return true
+}
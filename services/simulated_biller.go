@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"hash/fnv"
+	"strings"
+	"time"
+)
+
+// SimulatedBiller answers like a biller would without calling anyone, for local use and demos.
+// Customer numbers ending in 0000 do not exist, ending in 9999 have nothing to pay and
+// ending in 13 are rejected when paying so the refund path can be tried.
+type SimulatedBiller struct{}
+
+func NewSimulatedBiller() *SimulatedBiller {
+	return &SimulatedBiller{}
+}
+
+var simulatedCustomerNames = []string{
+	"Budi Santoso", "Siti Rahayu", "Agus Wijaya", "Dewi Lestari", "Rudi Hartono", "Putri Ananda", "Andi Saputra", "Rina Marlina",
+}
+
+func (b *SimulatedBiller) Inquire(ctx context.Context, biller *models.Biller, customerNumber string, amount float64) (*BillerInquiry, error) {
+	if strings.HasSuffix(customerNumber, "0000") {
+		return nil, apperrors.ErrBillCustomerNotFound
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(biller.Code + customerNumber))
+	seed := h.Sum32()
+
+	inquiry := &BillerInquiry{
+		CustomerName: simulatedCustomerNames[seed%uint32(len(simulatedCustomerNames))],
+		Reference:    fmt.Sprintf("SIMINQ%d%06d", time.Now().Unix(), seed%1000000),
+	}
+	if biller.Prepaid {
+		inquiry.Amount = amount
+		return inquiry, nil
+	}
+
+	if strings.HasSuffix(customerNumber, "9999") {
+		return nil, apperrors.ErrBillAlreadyPaid
+	}
+	// the same customer owes the same amount for the whole month, between 50.000 and 750.000
+	month := time.Now().Format("2006-01")
+	h.Write([]byte(month))
+	inquiry.Period = month
+	inquiry.Amount = float64(50000 + (h.Sum32()%1400)*500)
+	return inquiry, nil
+}
+
+func (b *SimulatedBiller) Pay(ctx context.Context, biller *models.Biller, inquiry *models.BillInquiry) (*BillerReceipt, error) {
+	if strings.HasSuffix(inquiry.CustomerNumber, "13") {
+		return nil, apperrors.ErrBillerUnavailable
+	}
+
+	number, err := utils.RandomDigits(8)
+	if err != nil {
+		return nil, apperrors.ErrBillerUnavailable
+	}
+	receipt := &BillerReceipt{ReceiptNumber: fmt.Sprintf("SIM%s%s", time.Now().Format("20060102"), number)}
+	if biller.Prepaid && biller.Category == models.BillerElectricity {
+		// electricity tokens are 20 digits shown in groups of four
+		digits, err := utils.RandomDigits(20)
+		if err != nil {
+			return nil, apperrors.ErrBillerUnavailable
+		}
+		groups := make([]string, 0, 5)
+		for i := 0; i < len(digits); i += 4 {
+			groups = append(groups, digits[i:i+4])
+		}
+		receipt.Token = strings.Join(groups, "-")
+	}
+	return receipt, nil
+}
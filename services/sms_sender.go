@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gopay-clone/config"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// SMSSender delivers text messages, a real gateway only has to implement Send
+type SMSSender interface {
+	Send(ctx context.Context, phone, message string) error
+}
+
+// NewSMSSender picks the sender from SMS_DRIVER, both built-in ones are meant for development
+func NewSMSSender(cfg config.OtpConfig) SMSSender {
+	if cfg.SMSDriver == "file" {
+		return &FileSMSSender{path: cfg.SMSFile}
+	}
+	return LogSMSSender{}
+}
+
+// LogSMSSender writes messages to the application log
+type LogSMSSender struct{}
+
+func (LogSMSSender) Send(ctx context.Context, phone, message string) error {
+	log.Printf("sms to %s: %s", phone, message)
+	return nil
+}
+
+// FileSMSSender appends one line per message to a file, handy to read codes in tests
+type FileSMSSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (s *FileSMSSender) Send(ctx context.Context, phone, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), phone, message)
+	return err
+}
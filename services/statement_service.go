@@ -0,0 +1,132 @@
+package services
+
+import (
+	"database/sql"
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type StatementService struct {
+	db *config.Database
+}
+
+func NewStatementService(db *config.Database) *StatementService {
+	return &StatementService{db: db}
+}
+
+// GenerateStatement builds the statement of an account between from and to (inclusive).
+// The opening balance is the balance the account was created with plus every movement
+// before from, the statement is then checked against the current balance. Everything is
+// read in one repeatable read transaction so the balance and the movements come from the
+// same snapshot.
+func (s *StatementService) GenerateStatement(accountId uint, from, to time.Time) (*models.Statement, error) {
+	var statement *models.Statement
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var account models.Account
+		if err := tx.Preload("User").First(&account, accountId).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperrors.ErrAccountNotFound
+			}
+			return apperrors.ErrDatabaseError
+		}
+
+		before, err := statementNet(statementMovements(tx, accountId).Where("created_at < ?", from), accountId)
+		if err != nil {
+			return err
+		}
+		after, err := statementNet(statementMovements(tx, accountId).Where("created_at > ?", to), accountId)
+		if err != nil {
+			return err
+		}
+
+		var transactions []models.Transaction
+		if err := statementMovements(tx, accountId).
+			Preload("SenderAccount.User").
+			Preload("ReceiverAccount.User").
+			Where("created_at >= ? AND created_at <= ?", from, to).
+			Order("created_at ASC, id ASC").
+			Find(&transactions).Error; err != nil {
+			return apperrors.ErrDatabaseError
+		}
+		items, err := toHistoryItems(tx, accountId, transactions)
+		if err != nil {
+			return err
+		}
+
+		statement = &models.Statement{
+			AccountID:      account.ID,
+			AccountName:    account.Name,
+			AccountType:    account.AccountType,
+			HolderName:     account.User.Name,
+			From:           from,
+			To:             to,
+			OpeningBalance: roundMoney(account.InitialBalance + before),
+			NetAfterPeriod: after,
+			CurrentBalance: account.Balance,
+			GeneratedAt:    time.Now(),
+			Lines:          make([]models.StatementLine, 0, len(items)),
+		}
+
+		running := statement.OpeningBalance
+		for _, item := range items {
+			running = roundMoney(running + item.SignedAmount)
+			if item.Direction == models.DirectionIn {
+				statement.TotalIn += item.Amount
+			} else {
+				statement.TotalOut += item.Amount
+			}
+			statement.Lines = append(statement.Lines, models.StatementLine{
+				Date:          item.CreatedAt,
+				TransactionID: item.ID,
+				Description:   item.Description,
+				Counterparty:  item.CounterpartyName,
+				Type:          item.Type,
+				Category:      item.Category,
+				Direction:     item.Direction,
+				Amount:        item.SignedAmount,
+				Balance:       running,
+			})
+		}
+		statement.TotalIn = roundMoney(statement.TotalIn)
+		statement.TotalOut = roundMoney(statement.TotalOut)
+		statement.ClosingBalance = running
+		statement.Discrepancy = roundMoney(account.Balance - (statement.ClosingBalance + statement.NetAfterPeriod))
+		statement.Reconciled = statement.Discrepancy == 0
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+// balances move as soon as a transaction row is created, pending ones included, failed rows
+// never touched a balance or had it given back. Only a hold that is still pending has not
+// reached its receiver yet.
+func statementMovements(tx *gorm.DB, accountId uint) *gorm.DB {
+	return tx.Model(&models.Transaction{}).
+		Where("(sender_account_id = ? OR receiver_account_id = ?)", accountId, accountId).
+		Where("status <> ?", models.TransactionFailed).
+		Where("NOT (is_hold AND status = ? AND receiver_account_id = ?)", models.TransactionPending, accountId)
+}
+
+// statementNet is what came in minus what went out over the movements of query
+func statementNet(query *gorm.DB, accountId uint) (float64, error) {
+	var net struct{ In, Out float64 }
+	if err := query.
+		Select(`COALESCE(SUM(CASE WHEN receiver_account_id = ? THEN amount ELSE 0 END), 0) AS "in",
+			COALESCE(SUM(CASE WHEN sender_account_id = ? THEN amount ELSE 0 END), 0) AS "out"`, accountId, accountId).
+		Scan(&net).Error; err != nil {
+		return 0, apperrors.ErrDatabaseError
+	}
+	return roundMoney(net.In - net.Out), nil
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gopay-clone/models"
+)
+
+func TestStatementCountsPendingFoodOrder(t *testing.T) {
+	db := testDatabase(t)
+	suffix := time.Now().UnixNano()
+
+	customer := models.User{Name: "Customer", Email: fmt.Sprintf("customer-%d@test.local", suffix)}
+	owner := models.User{Name: "Merchant", Email: fmt.Sprintf("merchant-%d@test.local", suffix)}
+	for _, u := range []*models.User{&customer, &owner} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	wallet := models.Account{Name: "Main", UserId: customer.ID, AccountType: models.MainBalance, Balance: 100000}
+	till := models.Account{Name: "Main", UserId: owner.ID, AccountType: models.MainBalance, Balance: 5000}
+	accounts := NewAccountService(db)
+	for _, a := range []*models.Account{&wallet, &till} {
+		if err := accounts.CreateAccount(a); err != nil {
+			t.Fatalf("create account: %v", err)
+		}
+	}
+	merchant := models.MerchantProfile{UserId: owner.ID, MerchantName: "Warung", Location: "Jakarta", Description: "test"}
+	if err := db.Create(&merchant).Error; err != nil {
+		t.Fatalf("create merchant: %v", err)
+	}
+
+	from := time.Now().Add(-time.Hour)
+	order := &models.Order{UserID: customer.ID, MerchantID: merchant.ID, TotalAmount: 42000, DeliveryAddress: "Jl. Test 1"}
+	payment := &models.Transaction{
+		Amount:            order.TotalAmount,
+		SenderAccountID:   wallet.ID,
+		ReceiverAccountID: till.ID,
+		Category:          models.Food,
+		Type:              models.Payment,
+		Status:            models.TransactionPending,
+		ServiceType:       models.ServiceFood,
+	}
+	if err := NewOrderService(db).CreateOrder(order, nil, payment); err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	statements := NewStatementService(db)
+	check := func(when string) {
+		t.Helper()
+		statement, err := statements.GenerateStatement(till.ID, from, time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatalf("%s: generate statement: %v", when, err)
+		}
+		if statement.OpeningBalance != 5000 {
+			t.Errorf("%s: opening balance = %v, want 5000", when, statement.OpeningBalance)
+		}
+		if statement.ClosingBalance != 47000 || statement.CurrentBalance != 47000 {
+			t.Errorf("%s: closing %v, current %v, want 47000", when, statement.ClosingBalance, statement.CurrentBalance)
+		}
+		if len(statement.Lines) != 1 || statement.Lines[0].TransactionID != payment.ID {
+			t.Errorf("%s: lines = %+v, want the order payment", when, statement.Lines)
+		}
+		if !statement.Reconciled {
+			t.Errorf("%s: statement off by %v", when, statement.Discrepancy)
+		}
+	}
+
+	// the merchant is paid when the order is placed, before the order completes
+	check("pending order")
+	if err := db.Model(payment).Update("status", models.TransactionCompleted).Error; err != nil {
+		t.Fatalf("complete payment: %v", err)
+	}
+	check("completed order")
+
+	// money that moves without a transaction shows up instead of being absorbed
+	if err := db.Model(&till).Update("balance", 47500).Error; err != nil {
+		t.Fatalf("change balance: %v", err)
+	}
+	statement, err := statements.GenerateStatement(till.ID, from, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("generate statement: %v", err)
+	}
+	if statement.Reconciled || statement.Discrepancy != 500 {
+		t.Errorf("reconciled = %v, discrepancy = %v, want false and 500", statement.Reconciled, statement.Discrepancy)
+	}
+}
@@ -1,11 +1,15 @@
 package services
 
 import (
+	"context"
 	"gopay-clone/config"
 	apperrors "gopay-clone/errors"
 	"gopay-clone/models"
+	"gopay-clone/utils"
+	"slices"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TransactionService struct {
@@ -17,46 +21,301 @@ func NewTransactionService(db *config.Database) *TransactionService {
 }
 
 func (s *TransactionService) CreateTransaction(transaction *models.Transaction) error {
-	var sender models.Account
-	var receiver models.Account
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.First(&sender, transaction.SenderAccountID).Error; err != nil {
-			return apperrors.ErrAccountNotFound
-		}
-		if err := tx.First(&receiver, transaction.ReceiverAccountID).Error; err != nil {
-			return apperrors.ErrAccountNotFound
-		}
-		if sender.Balance < transaction.Amount {
-			return apperrors.ErrInsufficientBalance
+		return createTransactionTx(tx, transaction)
+	})
+}
+
+// createTransactionTx moves the balance and records the transaction inside an open
+// db transaction, so other services can pay and update their own rows atomically
+func createTransactionTx(tx *gorm.DB, transaction *models.Transaction) error {
+	return moveTransactionTx(tx, transaction, true)
+}
+
+// holdTransactionTx takes the money of a pending transaction from the sender without paying
+// the receiver yet. settleHoldTx pays the receiver, releaseHoldTx gives the money back, which
+// can't run short because the money never left the hold.
+func holdTransactionTx(tx *gorm.DB, transaction *models.Transaction) error {
+	transaction.Status = models.TransactionPending
+	transaction.IsHold = true
+	return moveTransactionTx(tx, transaction, false)
+}
+
+func moveTransactionTx(tx *gorm.DB, transaction *models.Transaction, credit bool) error {
+	ids := []uint{transaction.SenderAccountID, transaction.ReceiverAccountID}
+	// the round-up pocket is written in this transaction too, it has to be locked in the same order
+	var pocketID uint
+	if err := tx.Model(&models.AutoSaveRule{}).Select("pocket_id").
+		Where("source_account_id = ? AND type = ? AND is_active = ?", transaction.SenderAccountID, models.AutoSaveRoundUp, true).
+		Limit(1).Scan(&pocketID).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if pocketID != 0 {
+		ids = append(ids, pocketID)
+	}
+	accounts, err := lockAccountsTx(tx, ids...)
+	if err != nil {
+		return err
+	}
+	sender := *accounts[transaction.SenderAccountID]
+	receiver := *accounts[transaction.ReceiverAccountID]
+	if sender.Balance < transaction.Amount {
+		return apperrors.ErrInsufficientBalance
+	}
+	if sender.ID == receiver.ID {
+		return apperrors.ErrSameAccount
+	}
+	if err := checkSpendable(&sender, &receiver); err != nil {
+		return err
+	}
+	if err := checkNotFrozen(tx, &sender, &receiver); err != nil {
+		return err
+	}
+
+	sender.Balance -= transaction.Amount
+	if err := tx.Save(&sender).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if credit {
+		receiver.Balance += transaction.Amount
+		if err := tx.Save(&receiver).Error; err != nil {
+			return apperrors.ErrDatabaseError
 		}
-		if sender.ID == receiver.ID {
-			return apperrors.ErrSameAccount
+	}
+
+	if err := tx.Create(transaction).Error; err != nil {
+		return apperrors.ErrTransactionFailed
+	}
+	if err := publishEventTx(tx, models.EventTransactionCreated, transaction.ID, models.TransactionEvent{
+		TransactionID:     transaction.ID,
+		Type:              transaction.Type,
+		Status:            transaction.Status,
+		Category:          transaction.Category,
+		Amount:            transaction.Amount,
+		SenderAccountID:   transaction.SenderAccountID,
+		ReceiverAccountID: transaction.ReceiverAccountID,
+		SenderBalance:     sender.Balance,
+	}); err != nil {
+		return err
+	}
+
+	return applyRoundUpTx(tx, transaction, &sender, &receiver)
+}
+
+// lockAccountsTx locks the accounts FOR UPDATE in ascending id order, whatever the direction
+// of the payment, so two payments between the same accounts can't deadlock each other
+func lockAccountsTx(tx *gorm.DB, ids ...uint) (map[uint]*models.Account, error) {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	accounts := make(map[uint]*models.Account, len(sorted))
+	for _, id := range sorted {
+		var account models.Account
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, apperrors.ErrAccountNotFound
+			}
+			return nil, apperrors.ErrDatabaseError
 		}
+		accounts[id] = &account
+	}
+	return accounts, nil
+}
 
-		sender.Balance -= transaction.Amount
-		receiver.Balance += transaction.Amount
+// settleHoldTx pays the receiver of a held transaction and completes it
+func settleHoldTx(tx *gorm.DB, transaction *models.Transaction) error {
+	if err := tx.Model(&models.Account{}).Where("id = ?", transaction.ReceiverAccountID).
+		Update("balance", gorm.Expr("balance + ?", transaction.Amount)).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if err := tx.Model(transaction).Update("status", models.TransactionCompleted).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
 
-		if err := tx.Save(&sender).Error; err != nil {
-			return apperrors.ErrDatabaseError
+// releaseHoldTx gives the money of a held transaction back to the sender and marks it failed.
+// The round-up saved for it goes back as well when the pocket still has it, otherwise it
+// stays in the pocket, which is the sender's own money either way.
+func releaseHoldTx(tx *gorm.DB, transaction *models.Transaction) error {
+	var savings []models.Transaction
+	if err := tx.Where("parent_transaction_id = ? AND status = ?", transaction.ID, models.TransactionCompleted).
+		Find(&savings).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	ids := []uint{transaction.SenderAccountID}
+	for _, saving := range savings {
+		ids = append(ids, saving.SenderAccountID, saving.ReceiverAccountID)
+	}
+	accounts, err := lockAccountsTx(tx, ids...)
+	if err != nil {
+		return err
+	}
+
+	accounts[transaction.SenderAccountID].Balance += transaction.Amount
+	if err := tx.Model(transaction).Update("status", models.TransactionFailed).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	for i := range savings {
+		saving := &savings[i]
+		pocket := accounts[saving.ReceiverAccountID]
+		if pocket.Balance < saving.Amount {
+			continue
 		}
-		if err := tx.Save(&receiver).Error; err != nil {
+		pocket.Balance -= saving.Amount
+		accounts[saving.SenderAccountID].Balance += saving.Amount
+		if err := tx.Model(saving).Update("status", models.TransactionFailed).Error; err != nil {
 			return apperrors.ErrDatabaseError
 		}
+	}
 
-		if err := tx.Create(transaction).Error; err != nil {
-			return apperrors.ErrTransactionFailed
+	for _, account := range accounts {
+		if err := tx.Model(account).Update("balance", account.Balance).Error; err != nil {
+			return apperrors.ErrDatabaseError
 		}
+	}
+	return nil
+}
+
+// checkNotFrozen blocks payments from and to users an admin has frozen
+func checkNotFrozen(tx *gorm.DB, sender, receiver *models.Account) error {
+	var frozen int64
+	if err := tx.Model(&models.User{}).
+		Where("id IN ? AND frozen_at IS NOT NULL", []uint{sender.UserId, receiver.UserId}).
+		Count(&frozen).Error; err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if frozen > 0 {
+		return apperrors.ErrUserFrozen
+	}
+	return nil
+}
 
-		return nil
+// GetTransactions lists transactions of every user for the admin
+func (s *TransactionService) GetTransactions(page *utils.PageParams) ([]models.Transaction, *utils.PageMeta, error) {
+	var transactions []models.Transaction
+	if err := page.Apply(s.db.Preload("SenderAccount").Preload("ReceiverAccount")).Find(&transactions).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	transactions, meta := utils.Page(transactions, page, func(t models.Transaction) (uint, map[string]any) {
+		return t.ID, map[string]any{"created_at": t.CreatedAt, "amount": t.Amount}
 	})
+	return transactions, meta, nil
 }
 
-func (s *TransactionService) GetTransactionsByAccount(accountId uint) ([]models.Transaction, error) {
+// GetTransactionsByAccount returns the sent and received transactions of an account,
+// direction narrows it down to "in" or "out", empty means both
+func (s *TransactionService) GetTransactionsByAccount(accountId uint, direction models.TransactionDirection, page *utils.PageParams) ([]models.TransactionHistoryItem, *utils.PageMeta, error) {
 	var transactions []models.Transaction
-	if err := s.db.Where("sender_account_id = ?", accountId).Order("created_at DESC").Find(&transactions).Error; err != nil {
-		return nil, apperrors.ErrTransactionNotFound
+	query := accountScope(s.db.Preload("SenderAccount.User").Preload("ReceiverAccount.User"), accountId, direction)
+	if err := page.Apply(query).Find(&transactions).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
 	}
-	return transactions, nil
+	transactions, meta := utils.Page(transactions, page, func(t models.Transaction) (uint, map[string]any) {
+		return t.ID, map[string]any{"created_at": t.CreatedAt, "amount": t.Amount}
+	})
+
+	items, err := toHistoryItems(s.db.DB, accountId, transactions)
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, meta, nil
+}
+
+// GetTransactionSummary totals the money in and out of an account per day, week or month.
+// Only completed transactions count unless the caller filters on status.
+func (s *TransactionService) GetTransactionSummary(accountId uint, period string, page *utils.PageParams) (*models.TransactionSummary, error) {
+	query := s.db.Model(&models.Transaction{}).
+		Select(`date_trunc(?, created_at) AS period,
+			COALESCE(SUM(CASE WHEN receiver_account_id = ? THEN amount ELSE 0 END), 0) AS total_in,
+			COALESCE(SUM(CASE WHEN sender_account_id = ? THEN amount ELSE 0 END), 0) AS total_out,
+			COUNT(*) AS count`, period, accountId, accountId)
+	query = accountScope(query, accountId, "")
+	if _, ok := page.Filters["status"]; !ok {
+		query = query.Where("status = ?", models.TransactionCompleted)
+	}
+
+	var periods []models.TransactionPeriodSummary
+	if err := page.ApplyFilters(query).Group("period").Order("period ASC").Scan(&periods).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	summary := &models.TransactionSummary{AccountID: accountId, Period: period, Periods: periods}
+	for i := range summary.Periods {
+		p := &summary.Periods[i]
+		p.Net = p.TotalIn - p.TotalOut
+		summary.TotalIn += p.TotalIn
+		summary.TotalOut += p.TotalOut
+	}
+	summary.Net = summary.TotalIn - summary.TotalOut
+	return summary, nil
+}
+
+func accountScope(q *gorm.DB, accountId uint, direction models.TransactionDirection) *gorm.DB {
+	switch direction {
+	case models.DirectionIn:
+		return q.Where("receiver_account_id = ?", accountId)
+	case models.DirectionOut:
+		return q.Where("sender_account_id = ?", accountId)
+	default:
+		return q.Where("(sender_account_id = ? OR receiver_account_id = ?)", accountId, accountId)
+	}
+}
+
+// toHistoryItems expects SenderAccount.User and ReceiverAccount.User to be preloaded
+func toHistoryItems(db *gorm.DB, accountId uint, transactions []models.Transaction) ([]models.TransactionHistoryItem, error) {
+	names, err := counterpartyNames(db, accountId, transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.TransactionHistoryItem, 0, len(transactions))
+	for _, t := range transactions {
+		item := models.TransactionHistoryItem{Transaction: t}
+		if t.ReceiverAccountID == accountId {
+			item.Direction = models.DirectionIn
+			item.SignedAmount = t.Amount
+			item.CounterpartyAccountID = t.SenderAccountID
+			item.CounterpartyName = names[t.SenderAccount.UserId]
+		} else {
+			item.Direction = models.DirectionOut
+			item.SignedAmount = -t.Amount
+			item.CounterpartyAccountID = t.ReceiverAccountID
+			item.CounterpartyName = names[t.ReceiverAccount.UserId]
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// counterpartyNames maps the other side's user id to a display name,
+// merchants show their merchant name instead of the owner's name
+func counterpartyNames(db *gorm.DB, accountId uint, transactions []models.Transaction) (map[uint]string, error) {
+	names := map[uint]string{}
+	var merchantUserIDs []uint
+	for _, t := range transactions {
+		other := t.ReceiverAccount.User
+		if t.ReceiverAccountID == accountId {
+			other = t.SenderAccount.User
+		}
+		names[other.ID] = other.Name
+		if other.Type == models.Merchant {
+			merchantUserIDs = append(merchantUserIDs, other.ID)
+		}
+	}
+	if len(merchantUserIDs) == 0 {
+		return names, nil
+	}
+
+	var merchants []models.MerchantProfile
+	if err := db.Where("user_id IN ?", merchantUserIDs).Find(&merchants).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	for _, m := range merchants {
+		names[m.UserId] = m.MerchantName
+	}
+	return names, nil
 }
 
 func (s *TransactionService) GetTransactionById(id uint) (*models.Transaction, error) {
@@ -94,3 +353,17 @@ func (s *TransactionService) UpdateTransactionWhenFoodOrderCompleted(orderId uin
 	}
 	return nil
 }
+
+// Subscribe settles the pending payment of a food order once the order is completed
+func (s *TransactionService) Subscribe(bus *EventBus) {
+	bus.Subscribe("transactions.settle_food_order", func(ctx context.Context, event *models.OutboxEvent) error {
+		var data models.OrderEvent
+		if err := event.Decode(&data); err != nil {
+			return err
+		}
+		if data.Status != models.OrderCompleted {
+			return nil
+		}
+		return s.UpdateTransactionWhenFoodOrderCompleted(data.OrderID)
+	}, models.EventOrderStatusChanged)
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TransferScheduler executes due scheduled transfers in the background
+type TransferScheduler struct {
+	db                  *config.Database
+	scheduleService     *ScheduledTransferService
+	budgetService       *BudgetService
+	notificationService *NotificationService
+	cfg                 config.SchedulerConfig
+}
+
+func NewTransferScheduler(db *config.Database, cfg config.SchedulerConfig, push PushProvider) *TransferScheduler {
+	notificationService := NewNotificationService(db, push)
+	return &TransferScheduler{
+		db:                  db,
+		scheduleService:     NewScheduledTransferService(db),
+		budgetService:       NewBudgetService(db, notificationService),
+		notificationService: notificationService,
+		cfg:                 cfg,
+	}
+}
+
+// Start polls until ctx is cancelled, it is meant to run in its own goroutine
+func (s *TransferScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	log.Printf("transfer scheduler started, polling every %s", s.cfg.PollInterval)
+	for {
+		s.RunDue(time.Now())
+		select {
+		case <-ctx.Done():
+			log.Println("transfer scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue executes every schedule due at now, one batch at a time
+func (s *TransferScheduler) RunDue(now time.Time) {
+	schedules, err := s.scheduleService.DueSchedules(now, s.cfg.BatchSize)
+	if err != nil {
+		log.Printf("scheduler: loading due schedules: %v", err)
+		return
+	}
+	for _, schedule := range schedules {
+		if err := s.execute(schedule.ID, now); err != nil {
+			log.Printf("scheduler: schedule %d: %v", schedule.ID, err)
+		}
+	}
+}
+
+// execute pays one due occurrence. The transfer, the run and the schedule's new state are
+// written in one db transaction, so a crash or db error leaves the schedule due as it was.
+func (s *TransferScheduler) execute(id uint, now time.Time) error {
+	var (
+		schedule    *models.ScheduledTransfer
+		transaction *models.Transaction
+		failure     string
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		schedule, err = lockDueScheduleTx(tx, id, now)
+		if err != nil || schedule == nil {
+			return err
+		}
+		run := &models.ScheduledTransferRun{
+			ScheduleID:   schedule.ID,
+			ScheduledFor: schedule.NextRunAt,
+			Attempt:      schedule.Attempts + 1,
+		}
+
+		transaction = &models.Transaction{
+			Amount:            schedule.Amount,
+			SenderAccountID:   schedule.SenderAccountID,
+			ReceiverAccountID: schedule.ReceiverAccountID,
+			Type:              models.Transfer,
+			Category:          schedule.Category,
+			Status:            models.TransactionCompleted,
+			ServiceType:       models.ServiceNone,
+			Description:       scheduleDescription(schedule),
+		}
+		// a savepoint, a failed transfer is rolled back while the run is still recorded
+		transferErr := tx.Transaction(func(tx *gorm.DB) error {
+			return createTransactionTx(tx, transaction)
+		})
+
+		finished := time.Now()
+		run.FinishedAt = &finished
+		schedule.LastRunAt = &finished
+		schedule.Attempts = run.Attempt
+
+		switch {
+		case transferErr == nil:
+			run.Status = models.ScheduleRunSuccess
+			run.TransactionID = &transaction.ID
+			schedule.SuccessCount++
+			advanceSchedule(schedule, now)
+
+		case terminalTransferError(transferErr):
+			run.Status = models.ScheduleRunFailed
+			run.Error = transferErr.Error()
+			schedule.Status = models.ScheduleFailed
+			schedule.RetryAt = nil
+			failure = fmt.Sprintf("Your scheduled transfer of %.2f was stopped: %s.", schedule.Amount, transferErr.Error())
+
+		case run.Attempt < s.cfg.RetryAttempts:
+			run.Status = models.ScheduleRunRetrying
+			run.Error = transferErr.Error()
+			retryAt := finished.Add(s.cfg.RetryDelay)
+			schedule.RetryAt = &retryAt
+
+		default:
+			// out of attempts, this occurrence is skipped but later ones still run
+			run.Status = models.ScheduleRunFailed
+			run.Error = transferErr.Error()
+			advanceSchedule(schedule, now)
+			reason := transferErr.Error()
+			if errors.Is(transferErr, apperrors.ErrInsufficientBalance) {
+				reason = "the balance was too low"
+			}
+			failure = fmt.Sprintf("Your scheduled transfer of %.2f was skipped after %d attempts because %s.",
+				schedule.Amount, run.Attempt, strings.ToLower(reason))
+		}
+		if transferErr != nil {
+			transaction = nil
+		}
+		return finishRunTx(tx, schedule, run)
+	})
+	if err != nil || schedule == nil {
+		return err
+	}
+
+	if failure != "" {
+		s.notifyFailure(schedule, failure)
+	}
+	if transaction != nil {
+		if err := s.budgetService.NotifyAfterPayment(transaction); err != nil {
+			log.Printf("scheduler: budget notification for schedule %d: %v", schedule.ID, err)
+		}
+	}
+	return nil
+}
+
+// terminalTransferError is true when no retry can make the transfer succeed, e.g. an account
+// is gone. A low balance or a db error may pass.
+func terminalTransferError(err error) bool {
+	return errors.Is(err, apperrors.ErrAccountNotFound) || errors.Is(err, apperrors.ErrSameAccount)
+}
+
+func (s *TransferScheduler) notifyFailure(schedule *models.ScheduledTransfer, body string) {
+	if err := s.notificationService.Notify(schedule.UserID, models.NotificationScheduleFailed, "Scheduled transfer failed", body); err != nil {
+		log.Printf("scheduler: failure notification for schedule %d: %v", schedule.ID, err)
+	}
+}
+
+func scheduleDescription(schedule *models.ScheduledTransfer) string {
+	if schedule.Description != "" {
+		return schedule.Description
+	}
+	return fmt.Sprintf("Scheduled transfer #%d", schedule.ID)
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// codes of one step before and after are accepted for clock drift
+const totpSkew = 1
+
+type TwoFactorService struct {
+	db  *config.Database
+	cfg config.TwoFactorConfig
+}
+
+func NewTwoFactorService(db *config.Database, cfg config.TwoFactorConfig) *TwoFactorService {
+	return &TwoFactorService{db: db, cfg: cfg}
+}
+
+func (s *TwoFactorService) Status(userID uint) (*models.TwoFactorStatus, error) {
+	status := &models.TwoFactorStatus{}
+	tf, err := s.find(s.db.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil || tf.EnabledAt == nil {
+		return status, nil
+	}
+	status.Enabled = true
+	status.EnabledAt = tf.EnabledAt
+
+	var remaining int64
+	if err := s.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&remaining).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	status.RecoveryCodesRemaining = int(remaining)
+	return status, nil
+}
+
+// Enabled tells login whether a code is needed after the password
+func (s *TwoFactorService) Enabled(userID uint) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.TwoFactor{}).Where("user_id = ? AND enabled_at IS NOT NULL", userID).Count(&count).Error; err != nil {
+		return false, apperrors.ErrDatabaseError
+	}
+	return count > 0, nil
+}
+
+// Enrol creates a new secret for the authenticator app, an unfinished enrolment is replaced
+func (s *TwoFactorService) Enrol(userID uint) (*models.TwoFactorEnrolment, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	secret, err := utils.NewTOTPSecret()
+	if err != nil {
+		return nil, apperrors.ErrTwoFactorUpdateFailed
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		tf, err := s.find(tx, userID)
+		if err != nil {
+			return err
+		}
+		if tf == nil {
+			return tx.Create(&models.TwoFactor{UserID: userID, Secret: secret}).Error
+		}
+		if tf.EnabledAt != nil {
+			return apperrors.ErrTwoFactorAlreadyEnabled
+		}
+		return tx.Model(tf).Updates(map[string]any{"secret": secret, "last_used_step": 0}).Error
+	})
+	if err != nil {
+		if _, ok := apperrors.IsAppError(err); ok {
+			return nil, err
+		}
+		return nil, apperrors.ErrTwoFactorUpdateFailed
+	}
+	return &models.TwoFactorEnrolment{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPURI(s.cfg.Issuer, user.Email, secret),
+	}, nil
+}
+
+// Enable turns 2FA on with the first code from the app and hands out the recovery codes
+func (s *TwoFactorService) Enable(userID uint, code string) (*models.RecoveryCodes, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		tf, err := s.find(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+		if err != nil {
+			return err
+		}
+		if tf == nil {
+			return apperrors.ErrTwoFactorNotEnrolled
+		}
+		if tf.EnabledAt != nil {
+			return apperrors.ErrTwoFactorAlreadyEnabled
+		}
+		// recovery codes do not exist yet, only the app can prove the enrolment worked
+		step, ok := utils.MatchTOTP(tf.Secret, code, time.Now(), totpSkew)
+		if !ok {
+			return apperrors.ErrInvalidTwoFactorCode
+		}
+		if err := tx.Model(tf).Updates(map[string]any{"enabled_at": time.Now(), "last_used_step": step}).Error; err != nil {
+			return apperrors.ErrTwoFactorUpdateFailed
+		}
+		codes, err = s.replaceRecoveryCodesTx(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.RecoveryCodes{Codes: codes}, nil
+}
+
+// Disable needs the password and a code, a stolen session alone can not switch 2FA off
+func (s *TwoFactorService) Disable(userID uint, password, code string) error {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return apperrors.ErrUserNotFound
+	}
+	if !utils.CheckPassword(user.Password, password) {
+		return apperrors.ErrInvalidPassword
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		tf, err := s.enabledTx(tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := verifyCodeTx(tx, tf, code); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return apperrors.ErrTwoFactorUpdateFailed
+		}
+		if err := tx.Delete(tf).Error; err != nil {
+			return apperrors.ErrTwoFactorUpdateFailed
+		}
+		return nil
+	})
+}
+
+// RegenerateRecoveryCodes replaces every recovery code, used or not
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, code string) (*models.RecoveryCodes, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		tf, err := s.enabledTx(tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := verifyCodeTx(tx, tf, code); err != nil {
+			return err
+		}
+		codes, err = s.replaceRecoveryCodesTx(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.RecoveryCodes{Codes: codes}, nil
+}
+
+// StartChallenge is called after a correct password when 2FA is on
+func (s *TwoFactorService) StartChallenge(userID uint) (*models.TwoFactorChallenge, error) {
+	raw, hash, err := utils.NewOpaqueToken()
+	if err != nil {
+		return nil, apperrors.ErrTokenCreation
+	}
+	challenge := models.LoginChallenge{UserID: userID, TokenHash: hash, ExpiresAt: time.Now().Add(s.cfg.ChallengeTTL)}
+	if err := s.db.Create(&challenge).Error; err != nil {
+		return nil, apperrors.ErrSessionCreateFailed
+	}
+	return &models.TwoFactorChallenge{ChallengeToken: raw, ExpiresAt: challenge.ExpiresAt}, nil
+}
+
+// CompleteChallenge checks the code for a login challenge and returns the user to start the
+// session for. Wrong codes are counted and stored even though an error is returned.
+func (s *TwoFactorService) CompleteChallenge(rawToken, code string) (*models.User, error) {
+	var user *models.User
+	var result error
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var challenge models.LoginChallenge
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", utils.HashToken(rawToken)).
+			First(&challenge).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				result = apperrors.ErrInvalidLoginChallenge
+				return nil
+			}
+			return err
+		}
+		now := time.Now()
+		if challenge.UsedAt != nil || challenge.ExpiresAt.Before(now) {
+			result = apperrors.ErrInvalidLoginChallenge
+			return nil
+		}
+
+		tf, err := s.enabledTx(tx, challenge.UserID)
+		if err != nil {
+			return err
+		}
+		if err := verifyCodeTx(tx, tf, code); err != nil {
+			if err != apperrors.ErrInvalidTwoFactorCode {
+				return err
+			}
+			attempts := challenge.Attempts + 1
+			updates := map[string]any{"attempts": attempts}
+			result = apperrors.ErrInvalidTwoFactorCode
+			if attempts >= s.cfg.MaxAttempts {
+				updates["used_at"] = now
+				result = apperrors.ErrInvalidLoginChallenge
+			}
+			return tx.Model(&challenge).Updates(updates).Error
+		}
+
+		if err := tx.Model(&challenge).Update("used_at", now).Error; err != nil {
+			return err
+		}
+		var found models.User
+		if err := tx.First(&found, challenge.UserID).Error; err != nil {
+			return err
+		}
+		user = &found
+		return nil
+	})
+	if err != nil {
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			return nil, appErr
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if result != nil {
+		return nil, result
+	}
+	return user, nil
+}
+
+func (s *TwoFactorService) find(tx *gorm.DB, userID uint) (*models.TwoFactor, error) {
+	var tf models.TwoFactor
+	if err := tx.Where("user_id = ?", userID).First(&tf).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &tf, nil
+}
+
+func (s *TwoFactorService) enabledTx(tx *gorm.DB, userID uint) (*models.TwoFactor, error) {
+	tf, err := s.find(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil || tf.EnabledAt == nil {
+		return nil, apperrors.ErrTwoFactorNotEnabled
+	}
+	return tf, nil
+}
+
+func (s *TwoFactorService) replaceRecoveryCodesTx(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, apperrors.ErrTwoFactorUpdateFailed
+	}
+	codes := make([]string, s.cfg.RecoveryCodes)
+	rows := make([]models.RecoveryCode, s.cfg.RecoveryCodes)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, apperrors.ErrTwoFactorUpdateFailed
+		}
+		codes[i] = code
+		rows[i] = models.RecoveryCode{UserID: userID, CodeHash: utils.HashToken(normalizeRecoveryCode(code))}
+	}
+	if len(rows) > 0 {
+		if err := tx.Create(&rows).Error; err != nil {
+			return nil, apperrors.ErrTwoFactorUpdateFailed
+		}
+	}
+	return codes, nil
+}
+
+// verifyCodeTx accepts a code from the app once per time step, or an unused recovery code
+func verifyCodeTx(tx *gorm.DB, tf *models.TwoFactor, code string) error {
+	if step, ok := utils.MatchTOTP(tf.Secret, code, time.Now(), totpSkew); ok {
+		if step <= tf.LastUsedStep {
+			return apperrors.ErrInvalidTwoFactorCode
+		}
+		if err := tx.Model(tf).Update("last_used_step", step).Error; err != nil {
+			return apperrors.ErrTwoFactorUpdateFailed
+		}
+		return nil
+	}
+
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", tf.UserID, utils.HashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return apperrors.ErrTwoFactorUpdateFailed
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// newRecoveryCode returns 10 base32 characters split in two, e.g. k3j9d-x8q2m
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	raw := strings.ToLower(base32.StdEncoding.EncodeToString(buf))[:10]
+	return raw[:5] + "-" + raw[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -5,6 +5,7 @@ import (
 	apperrors "gopay-clone/errors"
 	"gopay-clone/models"
 	"gopay-clone/utils"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -23,6 +24,12 @@ func (s *UserService) CreateUser(user *models.User) error {
 	if err := s.db.Where("email = ?", user.Email).First(&existingUser).Error; err == nil {
 		return apperrors.ErrUserExists
 	}
+	// a verified phone logs its user in, so it can only belong to one of them
+	if user.PhoneVerifiedAt != nil {
+		if err := s.db.Where("phone = ? AND phone_verified_at IS NOT NULL", user.Phone).First(&existingUser).Error; err == nil {
+			return apperrors.ErrPhoneTaken
+		}
+	}
 
 	if err := s.db.Create(user).Error; err != nil {
 		return apperrors.NewInternalError("Failed to create user")
@@ -51,12 +58,15 @@ func (s *UserService) CreateUser(user *models.User) error {
 	return nil
 }
 
-func (s *UserService) GetUsers() ([]models.User, error) {
+func (s *UserService) GetUsers(page *utils.PageParams) ([]models.User, *utils.PageMeta, error) {
 	var users []models.User
-	if err := s.db.Find(&users).Error; err != nil {
-		return nil, apperrors.NewInternalError("Failed to fetch users")
+	if err := page.Apply(s.db.Model(&models.User{})).Find(&users).Error; err != nil {
+		return nil, nil, apperrors.NewInternalError("Failed to fetch users")
 	}
-	return users, nil
+	users, meta := utils.Page(users, page, func(u models.User) (uint, map[string]any) {
+		return u.ID, map[string]any{"created_at": u.CreatedAt, "name": u.Name}
+	})
+	return users, meta, nil
 }
 
 func (s *UserService) GetUserById(id uint) (*models.User, error) {
@@ -94,23 +104,65 @@ func (s *UserService) DeleteUser(id uint) error {
 	return nil
 }
 
-func (s *UserService) Login(user *models.LoggedinUser) (string, error) {
+// FreezeUser stops all money movement in and out of the user's accounts, logging in still works
+func (s *UserService) FreezeUser(id uint, reason string) error {
+	result := s.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]any{
+		"frozen_at":     time.Now(),
+		"frozen_reason": reason,
+	})
+	if result.Error != nil {
+		return apperrors.ErrUserUpdateFailed
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *UserService) UnfreezeUser(id uint) error {
+	user, err := s.GetUserById(id)
+	if err != nil {
+		return err
+	}
+	if user.FrozenAt == nil {
+		return apperrors.ErrUserNotFrozen
+	}
+	if err := s.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]any{"frozen_at": nil, "frozen_reason": ""}).Error; err != nil {
+		return apperrors.ErrUserUpdateFailed
+	}
+	return nil
+}
+
+func (s *UserService) GetUserByVerifiedPhone(phone string) (*models.User, error) {
+	var user models.User
+	if err := s.db.Where("phone = ? AND phone_verified_at IS NOT NULL", phone).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &user, nil
+}
+
+// dummyPasswordHash is compared against when the email is unknown, so a miss takes as
+// long as a wrong password and the timing does not give away who is registered
+var dummyPasswordHash, _ = utils.HashPassword("not-a-real-password")
+
+// Login checks the credentials, the caller starts the session. Unknown emails and wrong
+// passwords fail the same way.
+func (s *UserService) Login(user *models.LoggedinUser) (*models.User, error) {
 	var foundUser models.User
 	if err := s.db.Where("email = ?", user.Email).First(&foundUser).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return "", apperrors.ErrEmailNotFound
+			utils.CheckPassword(dummyPasswordHash, user.Password)
+			return nil, apperrors.ErrInvalidCredentials
 		}
-		return "", apperrors.NewInternalError("Database error during login")
+		return nil, apperrors.NewInternalError("Database error during login")
 	}
 
 	if !utils.CheckPassword(foundUser.Password, user.Password) {
-		return "", apperrors.ErrInvalidPassword
-	}
-
-	tokenString, err := utils.CreateToken(foundUser)
-	if err != nil {
-		return "", apperrors.ErrTokenCreation
+		return nil, apperrors.ErrInvalidCredentials
 	}
 
-	return tokenString, nil
+	return &foundUser, nil
 }
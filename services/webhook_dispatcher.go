@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"gopay-clone/config"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// headers of a webhook delivery, see utils.SignWebhook
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookIDHeader        = "X-Webhook-Id"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookDispatcher posts queued webhook deliveries in the background and retries
+// failed ones with exponential backoff
+type WebhookDispatcher struct {
+	db     *config.Database
+	cfg    config.WebhookConfig
+	client *http.Client
+}
+
+func NewWebhookDispatcher(db *config.Database, cfg config.WebhookConfig) *WebhookDispatcher {
+	client := &http.Client{
+		Timeout: cfg.Timeout,
+		// a redirect could point the signed payload anywhere, the endpoint URL has to be the final one
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return &WebhookDispatcher{db: db, cfg: cfg, client: client}
+}
+
+// Start polls until ctx is cancelled, it is meant to run in its own goroutine
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	log.Printf("webhook dispatcher started, polling every %s", d.cfg.PollInterval)
+	for {
+		d.RunDue(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			log.Println("webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue sends every delivery due at now, one batch at a time
+func (d *WebhookDispatcher) RunDue(ctx context.Context, now time.Time) {
+	var deliveries []models.WebhookDelivery
+	if err := d.db.Where("status = ? AND next_attempt_at <= ?", models.WebhookPending, now).
+		Order("next_attempt_at").Limit(d.cfg.BatchSize).Find(&deliveries).Error; err != nil {
+		log.Printf("webhooks: loading due deliveries: %v", err)
+		return
+	}
+	for i := range deliveries {
+		if err := d.deliver(ctx, &deliveries[i]); err != nil {
+			log.Printf("webhooks: delivery %d: %v", deliveries[i].ID, err)
+		}
+	}
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	claimed, err := d.claim(delivery)
+	if err != nil || !claimed {
+		return err
+	}
+
+	attempt := models.WebhookAttempt{DeliveryID: delivery.ID, Attempt: delivery.Attempts + 1}
+	var endpoint models.WebhookEndpoint
+	err = d.db.First(&endpoint, delivery.EndpointID).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return d.finish(delivery, &attempt, models.WebhookFailed, "endpoint was deleted")
+	case err != nil:
+		return err
+	case endpoint.DisabledAt != nil:
+		return d.finish(delivery, &attempt, models.WebhookFailed, "endpoint is disabled")
+	}
+
+	started := time.Now()
+	status, body, sendErr := d.post(ctx, &endpoint, delivery)
+	attempt.DurationMs = time.Since(started).Milliseconds()
+	attempt.ResponseStatus = status
+	attempt.ResponseBody = body
+
+	switch {
+	case sendErr == nil && status >= 200 && status < 300:
+		return d.finish(delivery, &attempt, models.WebhookDelivered, "")
+	case sendErr == nil:
+		sendErr = fmt.Errorf("endpoint answered %d", status)
+	}
+	if attempt.Attempt >= d.cfg.MaxAttempts {
+		return d.finish(delivery, &attempt, models.WebhookFailed, sendErr.Error())
+	}
+	delivery.NextAttemptAt = time.Now().Add(d.backoff(attempt.Attempt))
+	return d.finish(delivery, &attempt, models.WebhookPending, sendErr.Error())
+}
+
+// claim moves the next attempt out of reach of other instances while this one sends
+func (d *WebhookDispatcher) claim(delivery *models.WebhookDelivery) (bool, error) {
+	lease := time.Now().Add(2 * d.cfg.Timeout)
+	result := d.db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.WebhookPending, delivery.NextAttemptAt).
+		Update("next_attempt_at", lease)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	delivery.NextAttemptAt = lease
+	return result.RowsAffected == 1, nil
+}
+
+func (d *WebhookDispatcher) post(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) (int, string, error) {
+	secret, err := utils.OpenSecret(d.cfg.EncryptionKey, endpoint.SealedSecret)
+	if err != nil {
+		return 0, "", fmt.Errorf("open endpoint secret: %w", err)
+	}
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GoClone-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, string(delivery.Event))
+	req.Header.Set(WebhookIDHeader, delivery.EventID)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, utils.SignWebhook(secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	// only the start of the answer goes to the log
+	answer, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return resp.StatusCode, string(answer), nil
+}
+
+// finish stores the attempt and the state of the delivery after it
+func (d *WebhookDispatcher) finish(delivery *models.WebhookDelivery, attempt *models.WebhookAttempt, status models.WebhookDeliveryStatus, reason string) error {
+	attempt.Error = reason
+	updates := map[string]any{
+		"status":          status,
+		"attempts":        attempt.Attempt,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"response_status": attempt.ResponseStatus,
+		"error":           reason,
+	}
+	if status == models.WebhookDelivered {
+		updates["delivered_at"] = time.Now()
+	}
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(delivery).Updates(updates).Error
+	})
+}
+
+// backoff is BackoffBase doubled for every attempt after the first, capped at BackoffMax
+func (d *WebhookDispatcher) backoff(attempt int) time.Duration {
+	wait := d.cfg.BackoffBase
+	for i := 1; i < attempt && wait < d.cfg.BackoffMax; i++ {
+		wait *= 2
+	}
+	return min(wait, d.cfg.BackoffMax)
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gopay-clone/config"
+	apperrors "gopay-clone/errors"
+	"gopay-clone/models"
+	"gopay-clone/utils"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookService struct {
+	db  *config.Database
+	cfg config.WebhookConfig
+}
+
+func NewWebhookService(db *config.Database, cfg config.WebhookConfig) *WebhookService {
+	return &WebhookService{db: db, cfg: cfg}
+}
+
+func (s *WebhookService) GetEndpoints(merchantID uint) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	if err := s.db.Where("merchant_id = ?", merchantID).Order("created_at").Find(&endpoints).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return endpoints, nil
+}
+
+// CreateEndpoint registers a URL for the events, the secret is only returned here
+func (s *WebhookService) CreateEndpoint(merchantID uint, rawURL string, events []models.WebhookEvent) (*models.WebhookEndpointCredentials, error) {
+	if err := s.checkURL(rawURL); err != nil {
+		return nil, err
+	}
+	var count int64
+	if err := s.db.Model(&models.WebhookEndpoint{}).Where("merchant_id = ?", merchantID).Count(&count).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if int(count) >= s.cfg.MaxEndpoints {
+		return nil, apperrors.ErrWebhookLimit
+	}
+
+	raw, _, err := utils.NewOpaqueToken()
+	if err != nil {
+		return nil, apperrors.ErrWebhookCreateFailed
+	}
+	secret := "whsec_" + raw
+	sealed, err := utils.SealSecret(s.cfg.EncryptionKey, secret)
+	if err != nil {
+		return nil, apperrors.ErrWebhookCreateFailed
+	}
+	endpoint := models.WebhookEndpoint{
+		MerchantID:   merchantID,
+		URL:          rawURL,
+		Events:       joinEvents(events),
+		SealedSecret: sealed,
+	}
+	if err := s.db.Create(&endpoint).Error; err != nil {
+		return nil, apperrors.ErrWebhookCreateFailed
+	}
+	return &models.WebhookEndpointCredentials{Endpoint: endpoint, Secret: secret}, nil
+}
+
+// UpdateEndpoint changes the URL, the events or whether the endpoint is enabled, nil leaves a field as it is
+func (s *WebhookService) UpdateEndpoint(merchantID, id uint, rawURL *string, events []models.WebhookEvent, enabled *bool) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.endpoint(merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if rawURL != nil {
+		if err := s.checkURL(*rawURL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = *rawURL
+	}
+	if events != nil {
+		endpoint.Events = joinEvents(events)
+	}
+	if enabled != nil {
+		if *enabled {
+			endpoint.DisabledAt = nil
+		} else if endpoint.DisabledAt == nil {
+			now := time.Now()
+			endpoint.DisabledAt = &now
+		}
+	}
+	if err := s.db.Save(endpoint).Error; err != nil {
+		return nil, apperrors.ErrWebhookUpdateFailed
+	}
+	return endpoint, nil
+}
+
+// DeleteEndpoint removes the endpoint, its delivery log stays and pending deliveries fail
+func (s *WebhookService) DeleteEndpoint(merchantID, id uint) error {
+	result := s.db.Where("merchant_id = ?", merchantID).Delete(&models.WebhookEndpoint{}, id)
+	if result.Error != nil {
+		return apperrors.ErrWebhookUpdateFailed
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (s *WebhookService) GetDeliveries(merchantID uint, page *utils.PageParams) ([]models.WebhookDelivery, *utils.PageMeta, error) {
+	var deliveries []models.WebhookDelivery
+	if err := page.Apply(s.db.Where("merchant_id = ?", merchantID)).Find(&deliveries).Error; err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	deliveries, meta := utils.Page(deliveries, page, func(d models.WebhookDelivery) (uint, map[string]any) {
+		return d.ID, map[string]any{"created_at": d.CreatedAt}
+	})
+	return deliveries, meta, nil
+}
+
+// GetDelivery returns the delivery with every attempt made
+func (s *WebhookService) GetDelivery(merchantID, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := s.db.Preload("Log", func(db *gorm.DB) *gorm.DB { return db.Order("attempt") }).
+		Where("id = ? AND merchant_id = ?", id, merchantID).First(&delivery).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrWebhookDeliveryNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &delivery, nil
+}
+
+// Redeliver queues the event of a finished delivery again with a fresh set of attempts
+func (s *WebhookService) Redeliver(merchantID, id uint) (*models.WebhookDelivery, error) {
+	original, err := s.GetDelivery(merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if original.Status == models.WebhookPending {
+		return nil, apperrors.ErrWebhookRedeliveryPending
+	}
+	if _, err := s.endpoint(merchantID, original.EndpointID); err != nil {
+		return nil, err
+	}
+	delivery := models.WebhookDelivery{
+		EndpointID:    original.EndpointID,
+		MerchantID:    merchantID,
+		EventID:       original.EventID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        models.WebhookPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := s.db.Create(&delivery).Error; err != nil {
+		return nil, apperrors.ErrWebhookEnqueueFailed
+	}
+	return &delivery, nil
+}
+
+func (s *WebhookService) endpoint(merchantID, id uint) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := s.db.Where("id = ? AND merchant_id = ?", id, merchantID).First(&endpoint).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.ErrWebhookNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &endpoint, nil
+}
+
+func (s *WebhookService) checkURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return apperrors.ErrInvalidInput
+	}
+	if u.Scheme == "https" || (u.Scheme == "http" && s.cfg.AllowHTTP) {
+		return nil
+	}
+	return apperrors.ErrWebhookInsecureURL
+}
+
+// Subscribe turns the domain events of merchants into webhook deliveries
+func (s *WebhookService) Subscribe(bus *EventBus) {
+	bus.Subscribe("webhooks", s.handleEvent,
+		models.EventOrderPlaced, models.EventOrderStatusChanged, models.EventPaymentCompleted, models.EventPaymentRefunded)
+}
+
+func (s *WebhookService) handleEvent(ctx context.Context, event *models.OutboxEvent) error {
+	var merchantID uint
+	var webhookEvent models.WebhookEvent
+	var data any
+	switch event.Type {
+	case models.EventOrderPlaced, models.EventOrderStatusChanged:
+		var order models.OrderEvent
+		if err := event.Decode(&order); err != nil {
+			return err
+		}
+		merchantID = order.MerchantID
+		webhookEvent = models.WebhookOrderCreated
+		if event.Type == models.EventOrderStatusChanged {
+			webhookEvent = models.WebhookOrderStatusChanged
+		}
+		data = models.WebhookOrderData{
+			OrderID:        order.OrderID,
+			Status:         order.Status,
+			PreviousStatus: order.PreviousStatus,
+			TotalAmount:    order.TotalAmount,
+			DeliveryFee:    order.DeliveryFee,
+		}
+	case models.EventPaymentCompleted:
+		var payment models.PaymentEvent
+		if err := event.Decode(&payment); err != nil {
+			return err
+		}
+		// only merchant QRs belong to a merchant
+		if payment.MerchantID == nil {
+			return nil
+		}
+		merchantID = *payment.MerchantID
+		webhookEvent = models.WebhookPaymentCompleted
+		data = models.WebhookPaymentData{
+			QrCodeID:      payment.QrCodeID,
+			Reference:     payment.Reference,
+			TransactionID: payment.TransactionID,
+			Amount:        payment.Amount,
+			PaidAt:        payment.PaidAt,
+		}
+	case models.EventPaymentRefunded:
+		var refund models.MerchantRefund
+		if err := event.Decode(&refund); err != nil {
+			return err
+		}
+		merchantID = refund.MerchantID
+		webhookEvent = models.WebhookPaymentRefunded
+		data = refund
+	default:
+		return nil
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return enqueueWebhookTx(tx, merchantID, webhookEvent, fmt.Sprintf("evt_%d", event.ID), event.CreatedAt, data)
+	})
+}
+
+// enqueueWebhookTx queues the event for every enabled endpoint of the merchant subscribed to it.
+// The event id comes from the outbox event, an event handed over again is not queued twice.
+func enqueueWebhookTx(tx *gorm.DB, merchantID uint, event models.WebhookEvent, eventID string, createdAt time.Time, data any) error {
+	var queued int64
+	if err := tx.Model(&models.WebhookDelivery{}).Where("event_id = ?", eventID).Count(&queued).Error; err != nil {
+		return apperrors.ErrWebhookEnqueueFailed
+	}
+	if queued > 0 {
+		return nil
+	}
+
+	var endpoints []models.WebhookEndpoint
+	if err := tx.Where("merchant_id = ? AND disabled_at IS NULL", merchantID).Find(&endpoints).Error; err != nil {
+		return apperrors.ErrWebhookEnqueueFailed
+	}
+	var subscribed []models.WebhookEndpoint
+	for _, endpoint := range endpoints {
+		if endpoint.Subscribed(event) {
+			subscribed = append(subscribed, endpoint)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(models.WebhookPayload{ID: eventID, Event: event, CreatedAt: createdAt, Data: data})
+	if err != nil {
+		return apperrors.ErrWebhookEnqueueFailed
+	}
+	for _, endpoint := range subscribed {
+		delivery := models.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			MerchantID:    merchantID,
+			EventID:       eventID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        models.WebhookPending,
+			NextAttemptAt: time.Now(),
+		}
+		if err := tx.Create(&delivery).Error; err != nil {
+			return apperrors.ErrWebhookEnqueueFailed
+		}
+	}
+	return nil
+}
+
+func joinEvents(events []models.WebhookEvent) string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = string(event)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TLV is one data object of an EMVCo merchant-presented QR payload: a two digit id,
+// a two digit length and the value
+type TLV struct {
+	ID    string
+	Value string
+}
+
+// EncodeTLV joins the fields in order, values longer than 99 characters can not be encoded
+func EncodeTLV(fields []TLV) (string, error) {
+	var b strings.Builder
+	for _, f := range fields {
+		if len(f.ID) != 2 || len(f.Value) > 99 {
+			return "", fmt.Errorf("field %s can not be encoded", f.ID)
+		}
+		fmt.Fprintf(&b, "%s%02d%s", f.ID, len(f.Value), f.Value)
+	}
+	return b.String(), nil
+}
+
+// ParseTLV splits a payload or template into its fields. Ids and lengths have to be exactly
+// two ASCII digits, a sign or space in either is refused.
+func ParseTLV(s string) ([]TLV, error) {
+	var fields []TLV
+	for len(s) > 0 {
+		if len(s) < 4 {
+			return nil, errors.New("truncated field header")
+		}
+		id := s[:2]
+		if _, ok := twoDigits(id); !ok {
+			return nil, fmt.Errorf("invalid field id %q", id)
+		}
+		n, ok := twoDigits(s[2:4])
+		if !ok || len(s) < 4+n {
+			return nil, fmt.Errorf("invalid length of field %s", id)
+		}
+		fields = append(fields, TLV{ID: id, Value: s[4 : 4+n]})
+		s = s[4+n:]
+	}
+	return fields, nil
+}
+
+func twoDigits(s string) (int, bool) {
+	if len(s) != 2 || s[0] < '0' || s[0] > '9' || s[1] < '0' || s[1] > '9' {
+		return 0, false
+	}
+	return int(s[0]-'0')*10 + int(s[1]-'0'), true
+}
+
+// CRC16 is CRC-16/CCITT-FALSE (polynomial 0x1021, initial value 0xFFFF), the checksum of field 63
+func CRC16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// AppendCRC adds field 63, the checksum covers the payload including the "6304" of the field itself
+func AppendCRC(payload string) string {
+	payload += "6304"
+	return payload + fmt.Sprintf("%04X", CRC16([]byte(payload)))
+}
+
+// CheckCRC tells whether the payload ends in a field 63 that matches the rest
+func CheckCRC(payload string) bool {
+	if len(payload) < 8 || payload[len(payload)-8:len(payload)-4] != "6304" {
+		return false
+	}
+	want := fmt.Sprintf("%04X", CRC16([]byte(payload[:len(payload)-4])))
+	return strings.EqualFold(payload[len(payload)-4:], want)
+}
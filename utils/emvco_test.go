@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTLV(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    []TLV
+		wantErr bool
+	}{
+		{name: "fields", payload: "000201010212", want: []TLV{{ID: "00", Value: "01"}, {ID: "01", Value: "12"}}},
+		{name: "empty value", payload: "0000", want: []TLV{{ID: "00", Value: ""}}},
+		{name: "truncated header", payload: "00020101", wantErr: true},
+		{name: "truncated value", payload: "0005abc", wantErr: true},
+		{name: "negative length", payload: "00-16304175C", wantErr: true},
+		{name: "plus length", payload: "00+5abcde", wantErr: true},
+		{name: "spaced length", payload: "00 5abcde", wantErr: true},
+		{name: "signed id", payload: "-1020101", wantErr: true},
+		{name: "letter id", payload: "A0020101", wantErr: true},
+		{name: "overlong length", payload: "0099abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTLV(tt.payload)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseTLV(%q) = %+v, want an error", tt.payload, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTLV(%q): %v", tt.payload, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTLV(%q) = %+v, want %+v", tt.payload, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCRC(t *testing.T) {
+	valid := AppendCRC("000201010211")
+	tests := []struct {
+		name    string
+		payload string
+		want    bool
+	}{
+		{name: "valid", payload: valid, want: true},
+		{name: "lower case checksum", payload: strings.ToLower(valid), want: true},
+		{name: "changed checksum", payload: valid[:len(valid)-1] + flipHex(valid[len(valid)-1]), want: false},
+		{name: "changed payload", payload: "000201010212" + valid[12:], want: false},
+		{name: "missing crc field", payload: "000201010211", want: false},
+		{name: "too short", payload: "6304", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckCRC(tt.payload); got != tt.want {
+				t.Errorf("CheckCRC(%q) = %v, want %v", tt.payload, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTLVAfterCRC(t *testing.T) {
+	// a payload with a valid checksum still has to be refused when a length is signed
+	payload := AppendCRC("00-1")
+	if !CheckCRC(payload) {
+		t.Fatalf("CheckCRC(%q) = false", payload)
+	}
+	if _, err := ParseTLV(payload); err == nil {
+		t.Fatalf("ParseTLV(%q) succeeded", payload)
+	}
+}
+
+func flipHex(c byte) string {
+	if c == '0' {
+		return "1"
+	}
+	return "0"
+}
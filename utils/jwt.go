@@ -9,8 +9,8 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-// Function to create JWT tokens with claims
-func CreateToken(user models.User) (string, error) {
+// Function to create JWT tokens with claims, sid ties the token to a session so it can be revoked
+func CreateToken(user models.User, sessionID uint, expiresAt time.Time) (string, error) {
 	secret := os.Getenv("JWT_SECRET")
 	var secretKey = []byte(secret)
 
@@ -19,8 +19,9 @@ func CreateToken(user models.User) (string, error) {
 		"user_id":   user.ID,    // Subject (user identifier)
 		"email":     user.Email, // Subject (user identifier)
 		"user_type": user.Type,
-		"exp":       time.Now().Add(time.Hour).Unix(), // Expiration time
-		"iat":       time.Now().Unix(),                // Issued at
+		"sid":       sessionID,
+		"exp":       expiresAt.Unix(),  // Expiration time
+		"iat":       time.Now().Unix(), // Issued at
 	})
 	tokenString, err := claims.SignedString(secretKey)
 	if err != nil {
@@ -41,3 +42,13 @@ func GetIDAndRoleFromJWT(c echo.Context) (int, string) {
 	claims := user.Claims.(jwt.MapClaims)
 	return int(uint(claims["user_id"].(float64))), claims["user_type"].(string)
 }
+
+// ClaimSessionID returns 0 for tokens issued before sessions existed
+func ClaimSessionID(c echo.Context) uint {
+	user, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return 0
+	}
+	sid, _ := user.Claims.(jwt.MapClaims)["sid"].(float64)
+	return uint(sid)
+}
@@ -0,0 +1,301 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type SortKind int
+
+const (
+	SortTime SortKind = iota
+	SortNumber
+	SortString
+)
+
+// SortField maps a public sort key (?sort_by=) to the column it orders by. Then is an
+// optional text column that orders rows with the same value, e.g. names within a category.
+type SortField struct {
+	Column string
+	Kind   SortKind
+	Then   string
+}
+
+// PageOptions describes what a single list endpoint allows the client to do
+type PageOptions struct {
+	Sorts       map[string]SortField // allowed ?sort_by= values
+	DefaultSort string
+	DefaultAsc  bool              // lists like menus read better A-Z
+	Filters     map[string]string // query param -> column, e.g. "status" -> "status"
+	DateColumn  string            // column used by ?from= and ?to=, defaults to created_at
+}
+
+// PageParams is the parsed pagination/filter state for one request
+type PageParams struct {
+	Limit   int
+	Sort    SortField
+	Desc    bool
+	From    *time.Time
+	To      *time.Time
+	Filters map[string][]string // column -> accepted values
+	cursor  *pageCursor
+	dateCol string
+}
+
+// PageMeta is returned next to list data so clients can fetch the following page
+type PageMeta struct {
+	Limit      int    `json:"limit" example:"20"`
+	HasMore    bool   `json:"has_more" example:"true"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJ2IjoiMjAyNS0wMS0wMVQwMDowMDowMFoiLCJpZCI6MTJ9"`
+	SortBy     string `json:"sort_by,omitempty" example:"created_at"`
+	SortDir    string `json:"sort_dir,omitempty" example:"desc"`
+}
+
+// pageCursor is encoded as base64 json so clients treat it as opaque
+type pageCursor struct {
+	Value any    `json:"v"`
+	Then  string `json:"t,omitempty"`
+	ID    uint   `json:"id"`
+}
+
+var defaultSorts = map[string]SortField{
+	"created_at": {Column: "created_at", Kind: SortTime},
+}
+
+// ParsePageParams reads limit, cursor, sort_by, sort_dir, from, to and the
+// endpoint specific filters from the query string
+func ParsePageParams(c echo.Context, opts PageOptions) (*PageParams, error) {
+	sorts := opts.Sorts
+	if sorts == nil {
+		sorts = defaultSorts
+	}
+	defaultSort := opts.DefaultSort
+	if defaultSort == "" {
+		defaultSort = "created_at"
+	}
+
+	params := &PageParams{
+		Limit:   DefaultPageSize,
+		Desc:    true,
+		Filters: map[string][]string{},
+		dateCol: opts.DateColumn,
+	}
+	if params.dateCol == "" {
+		params.dateCol = "created_at"
+	}
+
+	if raw := c.QueryParam("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return nil, errors.New("limit must be a positive number")
+		}
+		if limit > MaxPageSize {
+			limit = MaxPageSize
+		}
+		params.Limit = limit
+	}
+
+	sortBy := c.QueryParam("sort_by")
+	if sortBy == "" {
+		sortBy = defaultSort
+	}
+	sort, ok := sorts[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("cannot sort by %s", sortBy)
+	}
+	params.Sort = sort
+
+	switch strings.ToLower(c.QueryParam("sort_dir")) {
+	case "":
+		params.Desc = !opts.DefaultAsc
+	case "desc":
+		params.Desc = true
+	case "asc":
+		params.Desc = false
+	default:
+		return nil, errors.New("sort_dir must be asc or desc")
+	}
+
+	if raw := c.QueryParam("from"); raw != "" {
+		from, err := parseDateParam(raw, false)
+		if err != nil {
+			return nil, errors.New("from must be RFC3339 or YYYY-MM-DD")
+		}
+		params.From = &from
+	}
+	if raw := c.QueryParam("to"); raw != "" {
+		to, err := parseDateParam(raw, true)
+		if err != nil {
+			return nil, errors.New("to must be RFC3339 or YYYY-MM-DD")
+		}
+		params.To = &to
+	}
+	if params.From != nil && params.To != nil && params.To.Before(*params.From) {
+		return nil, errors.New("to must be after from")
+	}
+
+	for param, column := range opts.Filters {
+		raw := c.QueryParam(param)
+		if raw == "" {
+			continue
+		}
+		var values []string
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		if len(values) > 0 {
+			params.Filters[column] = values
+		}
+	}
+
+	if raw := c.QueryParam("cursor"); raw != "" {
+		cursor, err := decodeCursor(raw, sort.Kind)
+		if err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+		params.cursor = cursor
+	}
+
+	return params, nil
+}
+
+// date only values for ?to= are inclusive, so they are moved to the end of that day
+func parseDateParam(raw string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// Apply adds the filters, keyset condition, ordering and limit to a query.
+// One extra row is fetched so Page can tell whether there is a next page.
+func (p *PageParams) Apply(q *gorm.DB) *gorm.DB {
+	q = p.ApplyFilters(q)
+
+	dir, op := "ASC", ">"
+	if p.Desc {
+		dir, op = "DESC", "<"
+	}
+	col, then := p.Sort.Column, p.Sort.Then
+	if p.cursor != nil && then != "" {
+		q = q.Where(
+			fmt.Sprintf("((%s %s ?) OR (%s = ? AND %s %s ?) OR (%s = ? AND %s = ? AND id %s ?))", col, op, col, then, op, col, then, op),
+			p.cursor.Value, p.cursor.Value, p.cursor.Then, p.cursor.Value, p.cursor.Then, p.cursor.ID,
+		)
+	} else if p.cursor != nil {
+		q = q.Where(
+			fmt.Sprintf("((%s %s ?) OR (%s = ? AND id %s ?))", col, op, col, op),
+			p.cursor.Value, p.cursor.Value, p.cursor.ID,
+		)
+	}
+	q = q.Order(col + " " + dir)
+	if then != "" {
+		q = q.Order(then + " " + dir)
+	}
+	return q.Order("id " + dir).Limit(p.Limit + 1)
+}
+
+// ApplyFilters only adds the filters and the from/to condition, for aggregate
+// queries that should respect the same window without being paginated
+func (p *PageParams) ApplyFilters(q *gorm.DB) *gorm.DB {
+	for column, values := range p.Filters {
+		if len(values) == 1 {
+			q = q.Where(column+" = ?", values[0])
+		} else {
+			q = q.Where(column+" IN ?", values)
+		}
+	}
+	if p.From != nil {
+		q = q.Where(p.dateCol+" >= ?", *p.From)
+	}
+	if p.To != nil {
+		q = q.Where(p.dateCol+" <= ?", *p.To)
+	}
+	return q
+}
+
+// Page trims the extra row fetched by Apply and builds the cursor for the next page.
+// keyOf returns the id of an item and the values of the columns it can be sorted by.
+func Page[T any](items []T, p *PageParams, keyOf func(T) (uint, map[string]any)) ([]T, *PageMeta) {
+	meta := &PageMeta{Limit: p.Limit, SortBy: p.Sort.Column, SortDir: "asc"}
+	if p.Desc {
+		meta.SortDir = "desc"
+	}
+	if len(items) <= p.Limit {
+		return items, meta
+	}
+
+	items = items[:p.Limit]
+	id, values := keyOf(items[len(items)-1])
+	meta.HasMore = true
+	cursor := pageCursor{Value: values[p.Sort.Column], ID: id}
+	if p.Sort.Then != "" {
+		cursor.Then = fmt.Sprint(values[p.Sort.Then])
+	}
+	meta.NextCursor = encodeCursor(cursor)
+	return items, meta
+}
+
+func encodeCursor(cursor pageCursor) string {
+	if t, ok := cursor.Value.(time.Time); ok {
+		cursor.Value = t.UTC().Format(time.RFC3339Nano)
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(raw string, kind SortKind) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+
+	// json turns everything into string/float64, convert back to what the column expects
+	switch kind {
+	case SortTime:
+		s, ok := cursor.Value.(string)
+		if !ok {
+			return nil, errors.New("cursor value is not a time")
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, err
+		}
+		cursor.Value = t
+	case SortNumber:
+		if _, ok := cursor.Value.(float64); !ok {
+			return nil, errors.New("cursor value is not a number")
+		}
+	case SortString:
+		if _, ok := cursor.Value.(string); !ok {
+			return nil, errors.New("cursor value is not a string")
+		}
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+type menuRow struct {
+	id       uint
+	category string
+	name     string
+}
+
+var menuSorts = PageOptions{
+	Sorts:       map[string]SortField{"category": {Column: "category", Kind: SortString, Then: "name"}},
+	DefaultSort: "category",
+	DefaultAsc:  true,
+}
+
+func parsePage(t *testing.T, query string) *PageParams {
+	t.Helper()
+	c := echo.New().NewContext(httptest.NewRequest("GET", "/?"+query, nil), httptest.NewRecorder())
+	page, err := ParsePageParams(c, menuSorts)
+	if err != nil {
+		t.Fatalf("ParsePageParams(%q): %v", query, err)
+	}
+	return page
+}
+
+func TestPageCursorKeepsTieBreak(t *testing.T) {
+	page := parsePage(t, "limit=2")
+	if page.Desc || page.Sort.Then != "name" {
+		t.Fatalf("default sort = %+v desc %v, want category then name ascending", page.Sort, page.Desc)
+	}
+
+	rows := []menuRow{{1, "dessert", "Cake"}, {2, "dessert", "Pudding"}, {3, "drink", "Tea"}}
+	_, meta := Page(rows, page, func(r menuRow) (uint, map[string]any) {
+		return r.id, map[string]any{"category": r.category, "name": r.name}
+	})
+	if !meta.HasMore || meta.NextCursor == "" {
+		t.Fatalf("meta = %+v, want a next page", meta)
+	}
+
+	next := parsePage(t, "limit=2&cursor="+meta.NextCursor)
+	if next.cursor.Value != "dessert" || next.cursor.Then != "Pudding" || next.cursor.ID != 2 {
+		t.Errorf("cursor = %+v, want dessert, Pudding, 2", next.cursor)
+	}
+}
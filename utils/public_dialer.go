@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// sharedAddressSpace is the carrier grade NAT range (RFC 6598), net.IP has no check for it
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// PublicIP reports whether ip is routable on the internet, loopback, private, link-local
+// (169.254.169.254 is the cloud metadata service), multicast and unspecified addresses are not
+func PublicIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	addr, ok := netip.AddrFromSlice(ip)
+	return ok && !sharedAddressSpace.Contains(addr.Unmap())
+}
+
+// PublicDialer only connects to public addresses. The check runs on the resolved address of
+// every connection, so neither DNS nor a redirect can point a request inside the network.
+func PublicDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !PublicIP(net.ParseIP(host)) {
+				return fmt.Errorf("%s is not a public address", host)
+			}
+			return nil
+		},
+	}
+}
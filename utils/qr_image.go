@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// a logo covers at most this share of the QR edge, with level H the covered modules are recovered
+const qrLogoShare = 5
+
+// logos embedded in an SVG are scaled down to this edge in pixels, the SVG would carry the whole file otherwise
+const qrSVGLogoEdge = 256
+
+var qrLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// QRImagePNG renders content as a size x size PNG at error correction level L, M, Q or H, the logo is optional and drawn on a white
+// pad in the centre
+func QRImagePNG(content, level string, size int, logo image.Image) ([]byte, error) {
+	code, err := qrcode.New(content, qrLevels[level])
+	if err != nil {
+		return nil, err
+	}
+	img := code.Image(size)
+	if logo != nil {
+		canvas := image.NewRGBA(img.Bounds())
+		draw.Draw(canvas, canvas.Bounds(), img, image.Point{}, draw.Src)
+		edge := canvas.Bounds().Dx() / qrLogoShare
+		pad := edge / 10
+		origin := (canvas.Bounds().Dx() - edge) / 2
+		draw.Draw(canvas, image.Rect(origin-pad, origin-pad, origin+edge+pad, origin+edge+pad), image.White, image.Point{}, draw.Src)
+		fitted := fitImage(logo, edge)
+		offset := image.Pt(origin+(edge-fitted.Bounds().Dx())/2, origin+(edge-fitted.Bounds().Dy())/2)
+		draw.Draw(canvas, fitted.Bounds().Add(offset), fitted, image.Point{}, draw.Over)
+		img = canvas
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// QRImageSVG renders content as an SVG of size x size, one unit per module so it scales without
+// blurring. The logo is embedded as a PNG.
+func QRImageSVG(content, level string, size int, logo image.Image) ([]byte, error) {
+	code, err := qrcode.New(content, qrLevels[level])
+	if err != nil {
+		return nil, err
+	}
+	bitmap := code.Bitmap()
+	modules := len(bitmap)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, modules, modules)
+	for y, row := range bitmap {
+		// one rectangle per run of dark modules keeps the path short
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			run := 1
+			for x+run < len(row) && row[x+run] {
+				run++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", x, y, run, run)
+			x += run - 1
+		}
+	}
+	buf.WriteString(`"/>`)
+
+	if logo != nil {
+		if b := logo.Bounds(); b.Dx() > qrSVGLogoEdge || b.Dy() > qrSVGLogoEdge {
+			logo = fitImage(logo, qrSVGLogoEdge)
+		}
+		var logoPNG bytes.Buffer
+		if err := png.Encode(&logoPNG, logo); err != nil {
+			return nil, err
+		}
+		edge := float64(modules) / qrLogoShare
+		pad := edge / 10
+		origin := (float64(modules) - edge) / 2
+		fmt.Fprintf(&buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="#fff"/>`, origin-pad, origin-pad, edge+2*pad, edge+2*pad)
+		fmt.Fprintf(&buf, `<image x="%.2f" y="%.2f" width="%.2f" height="%.2f" preserveAspectRatio="xMidYMid meet" href="data:image/png;base64,%s"/>`,
+			origin, origin, edge, edge, base64.StdEncoding.EncodeToString(logoPNG.Bytes()))
+	}
+	buf.WriteString("</svg>")
+	return buf.Bytes(), nil
+}
+
+// fitImage scales src down to fit in an edge x edge box keeping its aspect ratio, nearest
+// neighbour is good enough for a logo this small
+func fitImage(src image.Image, edge int) image.Image {
+	b := src.Bounds()
+	w, h := edge, edge
+	if b.Dx() > b.Dy() {
+		h = edge * b.Dy() / b.Dx()
+	} else if b.Dy() > b.Dx() {
+		w = edge * b.Dx() / b.Dy()
+	}
+	if w < 1 || h < 1 {
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(x, y, color.RGBAModel.Convert(src.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h)))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"gopay-clone/models"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// QRStandeePDF lays a static QR out on an A5 page to print and put on the counter
+func QRStandeePDF(st *models.QrStandee) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A5", "")
+	pdf.SetTitle(fmt.Sprintf("QR standee %s", st.MerchantName), true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+	pageWidth, pageHeight := pdf.GetPageSize()
+
+	pdf.SetFont("Helvetica", "B", 26)
+	pdf.SetY(18)
+	pdf.CellFormat(0, 12, "Scan to pay", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, tr(truncate(st.MerchantName, 32)), "", 1, "C", false, 0, "")
+	if st.Location != "" {
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.CellFormat(0, 6, tr(truncate(st.Location, 56)), "", 1, "C", false, 0, "")
+	}
+
+	const qrEdge = 100.0
+	name := fmt.Sprintf("qr-%d", st.QrCodeID)
+	options := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader(name, options, bytes.NewReader(st.Image))
+	pdf.ImageOptions(name, (pageWidth-qrEdge)/2, 56, qrEdge, qrEdge, false, options, 0, "")
+	pdf.Rect((pageWidth-qrEdge)/2-2, 54, qrEdge+4, qrEdge+4, "D")
+
+	pdf.SetY(166)
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.MultiCell(0, 6, "Open the app, tap Scan, then enter the amount to pay", "", "C", false)
+
+	pdf.SetY(pageHeight - 14)
+	pdf.SetFont("Helvetica", "I", 8)
+	pdf.CellFormat(0, 4, fmt.Sprintf("QR #%d", st.QrCodeID), "", 1, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
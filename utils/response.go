@@ -11,6 +11,7 @@ type APIResponse struct {
 	Success bool         `json:"success" example:"false"`
 	Message string       `json:"message" example:"message"`
 	Data    any          `json:"data,omitempty"`
+	Meta    *PageMeta    `json:"meta,omitempty"`
 	Error   *ErrorDetail `json:"error,omitempty"`
 }
 type APIErrorResponse struct {
@@ -24,6 +25,12 @@ type APISuccessResponse struct {
 	Message string `json:"message" example:"Operation successful"`
 	Data    any    `json:"data"`
 }
+type APIPaginatedResponse struct {
+	Success bool      `json:"success" example:"true"`
+	Message string    `json:"message" example:"Operation successful"`
+	Data    any       `json:"data"`
+	Meta    *PageMeta `json:"meta"`
+}
 type ErrorNotFound struct {
 	Code    string `json:"code,omitempty" example:"404"`
 	Message string `json:"message" example:"not found message"`
@@ -50,6 +57,16 @@ func SuccessResponse(c echo.Context, statusCode int, message string, data any) e
 	})
 }
 
+// same envelope as SuccessResponse with the page meta for list endpoints
+func PaginatedResponse(c echo.Context, statusCode int, message string, data any, meta *PageMeta) error {
+	return c.JSON(statusCode, APIResponse{
+		Success: true,
+		Message: message,
+		Data:    data,
+		Meta:    meta,
+	})
+}
+
 // enhanced error response that handles AppError
 func ErrorResponse(c echo.Context, statusCode int, message string, err error) error {
 	response := APIResponse{
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// SignRequest is the HMAC-SHA256 merchant backends send in X-Merchant-Signature. The signed
+// string is the method, the path with query, the unix timestamp and the SHA-256 of the body,
+// joined by newlines.
+func SignRequest(key, method, path, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	payload := strings.Join([]string{strings.ToUpper(method), path, timestamp, hex.EncodeToString(bodyHash[:])}, "\n")
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignWebhook is the HMAC-SHA256 of a webhook delivery sent in X-Webhook-Signature, over the
+// unix timestamp and the raw body joined by a dot
+func SignWebhook(key, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func SignatureEqual(a, b string) bool {
+	return hmac.Equal([]byte(a), []byte(b))
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"gopay-clone/models"
+	"strconv"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const statementDateFormat = "2006-01-02 15:04"
+
+// StatementCSV writes a summary block followed by one row per movement
+func StatementCSV(st *models.Statement) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"Account", st.AccountName},
+		{"Account ID", strconv.Itoa(int(st.AccountID))},
+		{"Holder", st.HolderName},
+		{"Period", st.From.Format("2006-01-02") + " - " + st.To.Format("2006-01-02")},
+		{"Opening balance", money(st.OpeningBalance)},
+		{"Total in", money(st.TotalIn)},
+		{"Total out", money(st.TotalOut)},
+		{"Closing balance", money(st.ClosingBalance)},
+		{"Current balance", money(st.CurrentBalance)},
+		{"Reconciled", reconciled(st)},
+		{},
+		{"Date", "Transaction ID", "Type", "Category", "Counterparty", "Description", "Amount", "Balance"},
+	}
+	for _, line := range st.Lines {
+		rows = append(rows, []string{
+			line.Date.Format(statementDateFormat),
+			strconv.Itoa(int(line.TransactionID)),
+			string(line.Type),
+			string(line.Category),
+			line.Counterparty,
+			line.Description,
+			money(line.Amount),
+			money(line.Balance),
+		})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// StatementPDF renders a single table statement on A4 pages
+func StatementPDF(st *models.Statement) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("Statement %s", st.AccountName), true)
+	// core fonts are cp1252, translate names and descriptions before drawing them
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, "Account Statement", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	summary := [][2]string{
+		{"Holder", st.HolderName},
+		{"Account", fmt.Sprintf("%s (#%d, %s)", st.AccountName, st.AccountID, st.AccountType)},
+		{"Period", st.From.Format("02 Jan 2006") + " - " + st.To.Format("02 Jan 2006")},
+		{"Opening balance", money(st.OpeningBalance)},
+		{"Total in", money(st.TotalIn)},
+		{"Total out", money(st.TotalOut)},
+		{"Closing balance", money(st.ClosingBalance)},
+	}
+	for _, row := range summary {
+		pdf.CellFormat(40, 6, row[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, tr(row[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	headers := []string{"Date", "Counterparty", "Description", "Amount", "Balance"}
+	widths := []float64{32, 45, 53, 30, 30}
+	drawHeader := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		for i, h := range headers {
+			pdf.CellFormat(widths[i], 7, h, "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+	}
+	drawHeader()
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	for _, line := range st.Lines {
+		if pdf.GetY()+6 > pageHeight-bottom {
+			pdf.AddPage()
+			drawHeader()
+		}
+		cells := []string{
+			line.Date.Format(statementDateFormat),
+			tr(truncate(line.Counterparty, 26)),
+			tr(truncate(line.Description, 32)),
+			money(line.Amount),
+			money(line.Balance),
+		}
+		for i, cell := range cells {
+			align := "L"
+			if i >= 3 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 6, cell, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	pdf.Ln(4)
+	pdf.SetFont("Helvetica", "I", 8)
+	pdf.MultiCell(0, 4, fmt.Sprintf(
+		"Closing balance %s + movements after this period %s, current balance %s, reconciled: %s (generated %s)",
+		money(st.ClosingBalance), money(st.NetAfterPeriod), money(st.CurrentBalance), reconciled(st), st.GeneratedAt.Format(statementDateFormat),
+	), "", "L", false)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func reconciled(st *models.Statement) string {
+	if st.Reconciled {
+		return "yes"
+	}
+	return "no, off by " + money(st.Discrepancy)
+}
+
+func money(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-3]) + "..."
+}
@@ -0,0 +1,26 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+)
+
+// AdminActionRequest carries the reason an admin gives, it ends up in the audit log
+type AdminActionRequest struct {
+	Reason string `json:"reason"`
+}
+
+func ValidateAdminAction(req *AdminActionRequest) error {
+	if len(req.Reason) > 500 {
+		return errors.New("reason must be at most 500 characters")
+	}
+	return nil
+}
+
+// ValidateAdminReason is for actions that hurt the user, they always need a reason
+func ValidateAdminReason(req *AdminActionRequest) error {
+	if strings.TrimSpace(req.Reason) == "" {
+		return errors.New("reason is required")
+	}
+	return ValidateAdminAction(req)
+}
@@ -0,0 +1,49 @@
+package validator
+
+import "errors"
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+func ValidateRefreshToken(req *RefreshTokenRequest) error {
+	if len(req.RefreshToken) != 64 {
+		return errors.New("refresh token is malformed")
+	}
+	return nil
+}
+
+func ValidateVerifyEmail(req *VerifyEmailRequest) error {
+	return validateLinkToken(req.Token)
+}
+
+func ValidateForgotPassword(req *ForgotPasswordRequest) error {
+	return validateEmail(req.Email)
+}
+
+func ValidateResetPassword(req *ResetPasswordRequest) error {
+	if err := validateLinkToken(req.Token); err != nil {
+		return err
+	}
+	return validatePassword(req.NewPassword, true)
+}
+
+func validateLinkToken(token string) error {
+	if len(token) != 64 {
+		return errors.New("token is malformed")
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package validator
+
+import (
+	"errors"
+	"gopay-clone/models"
+	"regexp"
+)
+
+var validBillerCategories = map[models.BillerCategory]bool{
+	models.BillerElectricity: true,
+	models.BillerWater:       true,
+	models.BillerInternet:    true,
+	models.BillerPhoneCredit: true,
+}
+
+var customerNumberRegex = regexp.MustCompile(`^[0-9]{6,20}$`)
+
+type BillerQuery struct {
+	Category string `query:"category"`
+}
+
+type BillInquiryRequest struct {
+	BillerCode     string  `json:"biller_code" validate:"required"`
+	CustomerNumber string  `json:"customer_number" validate:"required"` // meter, customer or phone number
+	Amount         float64 `json:"amount,omitempty"`                    // only for prepaid billers
+}
+
+type BillPaymentRequest struct {
+	InquiryID       uint  `json:"inquiry_id" validate:"required"`
+	SenderAccountID *uint `json:"sender_account_id,omitempty"` // main balance when empty
+}
+
+func ValidateBillerQuery(req *BillerQuery) error {
+	if req.Category != "" && !validBillerCategories[models.BillerCategory(req.Category)] {
+		return errors.New("category must be electricity, water, internet or phone_credit")
+	}
+	return nil
+}
+
+func ValidateBillInquiry(req *BillInquiryRequest) error {
+	if req.BillerCode == "" {
+		return errors.New("biller code is required")
+	}
+	if !customerNumberRegex.MatchString(req.CustomerNumber) {
+		return errors.New("customer number must be 6 to 20 digits")
+	}
+	if req.Amount < 0 {
+		return errors.New("amount can not be negative")
+	}
+	return nil
+}
+
+func ValidateBillPayment(req *BillPaymentRequest) error {
+	if req.InquiryID == 0 {
+		return errors.New("inquiry id is required")
+	}
+	if req.SenderAccountID != nil && *req.SenderAccountID == 0 {
+		return errors.New("sender account id can not be 0")
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package validator
+
+import (
+	"errors"
+	"gopay-clone/models"
+	"time"
+)
+
+var validSpendingGroups = map[models.SpendingGroup]bool{
+	models.SpendingByCategory: true,
+	models.SpendingByMerchant: true,
+	models.SpendingByMonth:    true,
+}
+
+type SetBudgetRequest struct {
+	Category      models.TransactionCategory `json:"category" validate:"required"`
+	MonthlyLimit  float64                    `json:"monthly_limit" validate:"required"`
+	WarnAtPercent *int                       `json:"warn_at_percent,omitempty"`
+}
+
+type BudgetCheckQuery struct {
+	Category models.TransactionCategory `query:"category"`
+	Amount   float64                    `query:"amount"`
+}
+
+type SpendingQuery struct {
+	GroupBy models.SpendingGroup `query:"group_by"`
+	From    string               `query:"from"` // YYYY-MM-DD
+	To      string               `query:"to"`   // YYYY-MM-DD, inclusive
+}
+
+func ValidateSetBudget(req *SetBudgetRequest) error {
+	if !isValidCategory(req.Category) {
+		return errors.New("not a valid category")
+	}
+	if req.MonthlyLimit <= 0 {
+		return errors.New("monthly limit must be greater than 0")
+	}
+	if req.WarnAtPercent != nil && (*req.WarnAtPercent < 1 || *req.WarnAtPercent > 100) {
+		return errors.New("warn at percent must be between 1 and 100")
+	}
+	return nil
+}
+
+func ValidateBudgetCheck(req *BudgetCheckQuery) error {
+	if !isValidCategory(req.Category) {
+		return errors.New("not a valid category")
+	}
+	if req.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+	return nil
+}
+
+func ValidateSpendingQuery(req *SpendingQuery) error {
+	if req.GroupBy == "" {
+		req.GroupBy = models.SpendingByCategory
+	}
+	if !validSpendingGroups[req.GroupBy] {
+		return errors.New("group by must be category, merchant or month")
+	}
+	if req.From != "" {
+		if _, err := time.Parse("2006-01-02", req.From); err != nil {
+			return errors.New("from must be in YYYY-MM-DD format")
+		}
+	}
+	if req.To != "" {
+		if _, err := time.Parse("2006-01-02", req.To); err != nil {
+			return errors.New("to must be in YYYY-MM-DD format")
+		}
+	}
+	return nil
+}
+
+// Period defaults to the current month, or the last 6 months when grouping by month
+func (req *SpendingQuery) Period(now time.Time) (time.Time, time.Time) {
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if req.GroupBy == models.SpendingByMonth {
+		from = from.AddDate(0, -5, 0)
+	}
+	to := now
+	if req.From != "" {
+		from, _ = time.Parse("2006-01-02", req.From)
+	}
+	if req.To != "" {
+		to, _ = time.Parse("2006-01-02", req.To)
+		to = to.Add(24*time.Hour - time.Nanosecond)
+	}
+	return from, to
+}
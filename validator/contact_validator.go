@@ -0,0 +1,86 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+)
+
+type AddContactRequest struct {
+	TargetID *uint  `json:"target_id,omitempty"`
+	Phone    string `json:"phone,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Nickname string `json:"nickname,omitempty"`
+}
+
+type UpdateContactRequest struct {
+	Nickname    *string `json:"nickname,omitempty"`
+	IsFavourite *bool   `json:"is_favourite,omitempty"`
+}
+
+type LookupUserQuery struct {
+	Phone string `query:"phone"`
+	Email string `query:"email"`
+}
+
+type ContactTransferRequest struct {
+	Amount          float64 `json:"amount" validate:"required"`
+	SenderAccountID *uint   `json:"sender_account_id,omitempty"` // main balance when empty
+	Description     string  `json:"description,omitempty"`
+}
+
+// exactly one of target id, phone or email identifies who is added
+func ValidateAddContact(req *AddContactRequest) error {
+	given := 0
+	if req.TargetID != nil {
+		given++
+	}
+	if req.Phone != "" {
+		given++
+		if err := validatePhone(req.Phone); err != nil {
+			return err
+		}
+	}
+	if req.Email != "" {
+		given++
+		if err := validateEmail(req.Email); err != nil {
+			return err
+		}
+	}
+	if given != 1 {
+		return errors.New("provide exactly one of target_id, phone or email")
+	}
+	if len(req.Nickname) > 50 {
+		return errors.New("nickname can not be longer than 50 characters")
+	}
+	return nil
+}
+
+func ValidateUpdateContact(req *UpdateContactRequest) error {
+	if req.Nickname == nil && req.IsFavourite == nil {
+		return errors.New("nothing to update")
+	}
+	if req.Nickname != nil && len(strings.TrimSpace(*req.Nickname)) > 50 {
+		return errors.New("nickname can not be longer than 50 characters")
+	}
+	return nil
+}
+
+func ValidateLookupUser(req *LookupUserQuery) error {
+	if (req.Phone == "") == (req.Email == "") {
+		return errors.New("provide either phone or email")
+	}
+	if req.Phone != "" {
+		return validatePhone(req.Phone)
+	}
+	return validateEmail(req.Email)
+}
+
+func ValidateContactTransfer(req *ContactTransferRequest) error {
+	if req.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+	if req.SenderAccountID != nil && *req.SenderAccountID == 0 {
+		return errors.New("sender account id can not be 0")
+	}
+	return nil
+}
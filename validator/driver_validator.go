@@ -15,7 +15,6 @@ var validDriverStatuses = map[string]bool{
 	"online":  true,
 	"sending": true,
 	"offline": true,
-	"suspend": true,
 }
 
 type CreateDriverRequest struct {
@@ -44,6 +43,10 @@ type UpdateDriverStatusRequest struct {
 	Status string `json:"status" validate:"required"`
 }
 
+type UpdateRideStatusRequest struct {
+	Status models.RideStatus `json:"status" validate:"required"`
+}
+
 type UpdateDriverLocationRequest struct {
 	CurrentLocation string `json:"current_location" validate:"required"`
 }
@@ -90,6 +93,14 @@ func ValidateUpdateDriverStatus(req *UpdateDriverStatusRequest) error {
 	return nil
 }
 
+func ValidateUpdateRideStatus(req *UpdateRideStatusRequest) error {
+	switch req.Status {
+	case models.RidePickup, models.RideOngoing, models.RideCompleted, models.RideCancelled:
+		return nil
+	}
+	return errors.New("invalid ride status. Must be: pickup, ongoing, completed or cancelled")
+}
+
 func ValidateUpdateDriverLocation(req *UpdateDriverLocationRequest) error {
 	if strings.TrimSpace(req.CurrentLocation) == "" {
 		return errors.New("current location cannot be empty")
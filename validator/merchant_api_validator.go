@@ -0,0 +1,80 @@
+package validator
+
+import (
+	"errors"
+	"gopay-clone/models"
+	"slices"
+	"strings"
+)
+
+type CreateApiKeyRequest struct {
+	Name   string            `json:"name" validate:"required"`
+	Scopes []models.ApiScope `json:"scopes" validate:"required"`
+}
+
+type CreateDynamicQRRequest struct {
+	Amount           float64 `json:"amount" validate:"required"`
+	Reference        string  `json:"reference" validate:"required"` // the merchant's own order id
+	ExpiresInSeconds int     `json:"expires_in_seconds"`            // 0 uses the default lifetime
+}
+
+type RefundRequest struct {
+	TransactionID uint    `json:"transaction_id" validate:"required"`
+	Amount        float64 `json:"amount"` // 0 refunds what is left of the payment
+	Reason        string  `json:"reason"`
+	Reference     string  `json:"reference"` // the merchant's own refund id, a reused one is refused
+}
+
+func ValidateCreateApiKey(req *CreateApiKeyRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(req.Name) > 100 {
+		return errors.New("name must be at most 100 characters")
+	}
+	if len(req.Scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(models.ApiScopes, scope) {
+			return errors.New("unknown scope " + string(scope))
+		}
+	}
+	return nil
+}
+
+func ValidateCreateDynamicQR(req *CreateDynamicQRRequest) error {
+	if req.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+	if err := validateReference(req.Reference, true); err != nil {
+		return err
+	}
+	if req.ExpiresInSeconds < 0 {
+		return errors.New("expires_in_seconds can not be negative")
+	}
+	return nil
+}
+
+func ValidateRefund(req *RefundRequest) error {
+	if req.TransactionID == 0 {
+		return errors.New("transaction_id is required")
+	}
+	if req.Amount < 0 {
+		return errors.New("amount can not be negative")
+	}
+	if len(req.Reason) > 255 {
+		return errors.New("reason must be at most 255 characters")
+	}
+	return validateReference(req.Reference, false)
+}
+
+func validateReference(reference string, required bool) error {
+	if required && strings.TrimSpace(reference) == "" {
+		return errors.New("reference is required")
+	}
+	if len(reference) > 64 {
+		return errors.New("reference must be at most 64 characters")
+	}
+	return nil
+}
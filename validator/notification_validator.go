@@ -0,0 +1,25 @@
+package validator
+
+import (
+	"errors"
+	"gopay-clone/models"
+)
+
+type RegisterDeviceRequest struct {
+	Token    string                `json:"token" validate:"required"`
+	Platform models.DevicePlatform `json:"platform" validate:"required"`
+}
+
+func ValidateRegisterDevice(req *RegisterDeviceRequest) error {
+	if req.Token == "" {
+		return errors.New("token is required")
+	}
+	if len(req.Token) > 512 {
+		return errors.New("token must be at most 512 characters")
+	}
+	switch req.Platform {
+	case models.PlatformAndroid, models.PlatformIOS, models.PlatformWeb:
+		return nil
+	}
+	return errors.New("platform must be android, ios or web")
+}
@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"errors"
+	"gopay-clone/models"
+	"regexp"
+)
+
+var otpRegex = regexp.MustCompile(`^[0-9]{6}$`)
+
+type RequestOtpRequest struct {
+	Phone   string            `json:"phone" validate:"required"`
+	Purpose models.OtpPurpose `json:"purpose" validate:"required"` // register or login
+}
+
+type PhoneLoginRequest struct {
+	Phone   string `json:"phone" validate:"required"`
+	OtpCode string `json:"otp_code" validate:"required"`
+}
+
+type VerifyPhoneRequest struct {
+	OtpCode string `json:"otp_code" validate:"required"`
+}
+
+func ValidateRequestOtp(req *RequestOtpRequest) error {
+	if err := validatePhone(req.Phone); err != nil {
+		return err
+	}
+	if req.Purpose != models.OtpRegister && req.Purpose != models.OtpLogin {
+		return errors.New("purpose must be register or login")
+	}
+	return nil
+}
+
+func ValidatePhoneLogin(req *PhoneLoginRequest) error {
+	if err := validatePhone(req.Phone); err != nil {
+		return err
+	}
+	return validateOtpCode(req.OtpCode)
+}
+
+func ValidateVerifyPhone(req *VerifyPhoneRequest) error {
+	return validateOtpCode(req.OtpCode)
+}
+
+func validateOtpCode(code string) error {
+	if !otpRegex.MatchString(code) {
+		return errors.New("otp code must be 6 digits")
+	}
+	return nil
+}
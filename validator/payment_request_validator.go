@@ -0,0 +1,112 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+)
+
+const maxRequestParticipants = 20
+
+type CreatePaymentRequestRequest struct {
+	ContactIDs []uint  `json:"contact_ids" validate:"required"`
+	Amount     float64 `json:"amount" validate:"required"`
+	Note       string  `json:"note,omitempty"`
+}
+
+type PayRequestRequest struct {
+	SenderAccountID *uint `json:"sender_account_id,omitempty"` // main balance when empty
+}
+
+type SplitParticipant struct {
+	ContactID uint    `json:"contact_id"`
+	Amount    float64 `json:"amount,omitempty"` // only for custom splits
+}
+
+type CreateSplitBillRequest struct {
+	Title        string             `json:"title,omitempty"` // defaults to the order when splitting one
+	TotalAmount  float64            `json:"total_amount,omitempty"`
+	OrderID      *uint              `json:"order_id,omitempty"`
+	Mode         string             `json:"mode" validate:"required"`
+	Participants []SplitParticipant `json:"participants" validate:"required"`
+	IncludeOwner *bool              `json:"include_owner,omitempty"` // whether the owner pays a share too, default true
+}
+
+var validSplitModes = map[string]bool{
+	"even":   true,
+	"custom": true,
+}
+
+func ValidateCreatePaymentRequest(req *CreatePaymentRequestRequest) error {
+	if err := validateContactIDs(req.ContactIDs); err != nil {
+		return err
+	}
+	if req.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+	if len(req.Note) > 140 {
+		return errors.New("note can not be longer than 140 characters")
+	}
+	return nil
+}
+
+func ValidatePayRequest(req *PayRequestRequest) error {
+	if req.SenderAccountID != nil && *req.SenderAccountID == 0 {
+		return errors.New("sender account id can not be 0")
+	}
+	return nil
+}
+
+func ValidateCreateSplitBill(req *CreateSplitBillRequest) error {
+	if !validSplitModes[req.Mode] {
+		return errors.New("mode must be even or custom")
+	}
+	if req.OrderID == nil {
+		if req.TotalAmount <= 0 {
+			return errors.New("total amount must be greater than 0 when no order is given")
+		}
+		if req.Title == "" {
+			return errors.New("title is required when no order is given")
+		}
+	} else if req.TotalAmount != 0 {
+		return errors.New("total amount is taken from the order, do not send both")
+	}
+	if len(req.Title) > 100 {
+		return errors.New("title can not be longer than 100 characters")
+	}
+
+	ids := make([]uint, 0, len(req.Participants))
+	for _, p := range req.Participants {
+		ids = append(ids, p.ContactID)
+		if req.Mode == "custom" && p.Amount <= 0 {
+			return fmt.Errorf("amount for contact %d must be greater than 0", p.ContactID)
+		}
+		if req.Mode == "even" && p.Amount != 0 {
+			return errors.New("amounts are only used by custom splits")
+		}
+	}
+	return validateContactIDs(ids)
+}
+
+func (r *CreateSplitBillRequest) OwnerPays() bool {
+	return r.IncludeOwner == nil || *r.IncludeOwner
+}
+
+func validateContactIDs(ids []uint) error {
+	if len(ids) == 0 {
+		return errors.New("at least one contact is required")
+	}
+	if len(ids) > maxRequestParticipants {
+		return fmt.Errorf("at most %d contacts per request", maxRequestParticipants)
+	}
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if id == 0 {
+			return errors.New("contact id can not be 0")
+		}
+		if seen[id] {
+			return fmt.Errorf("contact %d is listed twice", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
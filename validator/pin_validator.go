@@ -0,0 +1,66 @@
+package validator
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var pinRegex = regexp.MustCompile(`^[0-9]{6}$`)
+
+type SetPinRequest struct {
+	Password string `json:"password" validate:"required"` // account password
+	Pin      string `json:"pin" validate:"required"`
+}
+
+type ChangePinRequest struct {
+	CurrentPin string `json:"current_pin" validate:"required"`
+	NewPin     string `json:"new_pin" validate:"required"`
+	OtpCode    string `json:"otp_code"` // required once the phone is verified, from POST /pin/otp
+}
+
+// ResetPinRequest is for a forgotten PIN, the OTP stands in for the current PIN
+type ResetPinRequest struct {
+	OtpCode string `json:"otp_code" validate:"required"`
+	NewPin  string `json:"new_pin" validate:"required"`
+}
+
+func ValidateSetPin(req *SetPinRequest) error {
+	if req.Password == "" {
+		return errors.New("password is required")
+	}
+	return validatePin(req.Pin)
+}
+
+func ValidateChangePin(req *ChangePinRequest) error {
+	if !pinRegex.MatchString(req.CurrentPin) {
+		return errors.New("current pin must be 6 digits")
+	}
+	if req.CurrentPin == req.NewPin {
+		return errors.New("new pin must be different from the current one")
+	}
+	if req.OtpCode != "" {
+		if err := validateOtpCode(req.OtpCode); err != nil {
+			return err
+		}
+	}
+	return validatePin(req.NewPin)
+}
+
+func ValidateResetPin(req *ResetPinRequest) error {
+	if err := validateOtpCode(req.OtpCode); err != nil {
+		return err
+	}
+	return validatePin(req.NewPin)
+}
+
+// a PIN is 6 digits and not one of the first ones anybody would guess
+func validatePin(pin string) error {
+	if !pinRegex.MatchString(pin) {
+		return errors.New("pin must be 6 digits")
+	}
+	if strings.Count(pin, pin[:1]) == len(pin) || strings.Contains("0123456789", pin) || strings.Contains("9876543210", pin) {
+		return errors.New("pin is too easy to guess")
+	}
+	return nil
+}
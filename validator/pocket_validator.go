@@ -0,0 +1,136 @@
+package validator
+
+import (
+	"errors"
+	"gopay-clone/models"
+	"strings"
+	"time"
+)
+
+type CreatePocketRequest struct {
+	Name         string     `json:"name" validate:"required"`
+	TargetAmount *float64   `json:"target_amount,omitempty"`
+	TargetDate   *time.Time `json:"target_date,omitempty"`
+	IsLocked     bool       `json:"is_locked,omitempty"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+}
+
+type UpdatePocketRequest struct {
+	Name         *string    `json:"name,omitempty"`
+	TargetAmount *float64   `json:"target_amount,omitempty"`
+	TargetDate   *time.Time `json:"target_date,omitempty"`
+	IsLocked     *bool      `json:"is_locked,omitempty"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+}
+
+type InternalTransferRequest struct {
+	FromAccountID uint    `json:"from_account_id" validate:"required"`
+	ToAccountID   uint    `json:"to_account_id" validate:"required"`
+	Amount        float64 `json:"amount" validate:"required"`
+	Description   string  `json:"description,omitempty"`
+}
+
+type AutoSaveRuleRequest struct {
+	Type            models.AutoSaveType `json:"type" validate:"required"`
+	SourceAccountID *uint               `json:"source_account_id,omitempty"` // main balance when empty
+	// round_up
+	RoundTo float64 `json:"round_to,omitempty"`
+	// scheduled
+	Amount    float64                  `json:"amount,omitempty"`
+	Frequency models.ScheduleFrequency `json:"frequency,omitempty"`
+	Interval  int                      `json:"interval,omitempty"`
+	StartAt   *time.Time               `json:"start_at,omitempty"`
+	EndAt     *time.Time               `json:"end_at,omitempty"`
+	Timezone  string                   `json:"timezone,omitempty"`
+}
+
+func ValidateCreatePocket(req *CreatePocketRequest) error {
+	if err := validatePocketName(req.Name); err != nil {
+		return err
+	}
+	return validatePocketGoal(req.TargetAmount, req.TargetDate, req.LockedUntil)
+}
+
+func ValidateUpdatePocket(req *UpdatePocketRequest) error {
+	if req.Name == nil && req.TargetAmount == nil && req.TargetDate == nil && req.IsLocked == nil && req.LockedUntil == nil {
+		return errors.New("nothing to update")
+	}
+	if req.Name != nil {
+		if err := validatePocketName(*req.Name); err != nil {
+			return err
+		}
+	}
+	return validatePocketGoal(req.TargetAmount, req.TargetDate, req.LockedUntil)
+}
+
+func ValidateInternalTransfer(req *InternalTransferRequest) error {
+	if req.FromAccountID == 0 || req.ToAccountID == 0 {
+		return errors.New("from_account_id and to_account_id are required")
+	}
+	if req.FromAccountID == req.ToAccountID {
+		return errors.New("from and to must be different accounts")
+	}
+	if req.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+	if len(req.Description) > 140 {
+		return errors.New("description can not be longer than 140 characters")
+	}
+	return nil
+}
+
+func ValidateAutoSaveRule(req *AutoSaveRuleRequest) error {
+	if req.SourceAccountID != nil && *req.SourceAccountID == 0 {
+		return errors.New("source account id can not be 0")
+	}
+	switch req.Type {
+	case models.AutoSaveRoundUp:
+		if req.RoundTo <= 0 {
+			return errors.New("round_to must be greater than 0, e.g. 1000")
+		}
+		if req.Amount != 0 || req.Frequency != "" || req.StartAt != nil {
+			return errors.New("round_up rules only take round_to")
+		}
+	case models.AutoSaveScheduled:
+		if req.RoundTo != 0 {
+			return errors.New("scheduled rules do not take round_to")
+		}
+		if req.StartAt == nil {
+			return errors.New("start_at is required")
+		}
+		if req.Frequency == models.ScheduleOnce {
+			return errors.New("auto-save must repeat, use a scheduled transfer for one off savings")
+		}
+		if req.Amount <= 0 {
+			return errors.New("amount must be greater than 0")
+		}
+		return validateScheduleTiming(req.Frequency, req.Interval, *req.StartAt, req.EndAt, nil, req.Timezone)
+	default:
+		return errors.New("type must be round_up or scheduled")
+	}
+	return nil
+}
+
+func validatePocketName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("pocket name is required")
+	}
+	if len(name) > 50 {
+		return errors.New("pocket name can not be longer than 50 characters")
+	}
+	return nil
+}
+
+func validatePocketGoal(targetAmount *float64, targetDate, lockedUntil *time.Time) error {
+	if targetAmount != nil && *targetAmount <= 0 {
+		return errors.New("target amount must be greater than 0")
+	}
+	if targetDate != nil && targetDate.Before(time.Now()) {
+		return errors.New("target date can not be in the past")
+	}
+	if lockedUntil != nil && lockedUntil.Before(time.Now()) {
+		return errors.New("locked_until can not be in the past")
+	}
+	return nil
+}
@@ -9,12 +9,32 @@ import (
 type CreateQRRequest struct {
 	ReceiverAccountID uint      `json:"receiver_account_id" gorm:"not null"`
 	Amount            float64   `json:"amount" gorm:"not null"`
-	URL               string    `json:"url" gorm:"not null"`
+	URL               string    `json:"url"` // optional deep link, the QR payload is generated
 	ExpiresAt         time.Time `json:"expires_at" gorm:"default:CURRENT_TIMESTAMP + INTERVAL 1 MINUTE"`
 }
 
+type ParseQRRequest struct {
+	Payload string `json:"payload" validate:"required"`
+}
+
+var validQRLevels = map[string]bool{"L": true, "M": true, "Q": true, "H": true}
+
+// QRImageQuery is how GET /qr/:qr_id/image is drawn, the size bounds are checked against the config
+type QRImageQuery struct {
+	Format string `query:"format"` // png (default) or svg
+	Size   int    `query:"size"`   // edge in pixels
+	Level  string `query:"level"`  // L, M (default), Q or H
+	Logo   bool   `query:"logo"`
+}
+
 type ScanQRRequest struct {
-	SenderAccountID uint `json:"sender_account_id" gorm:"not null"`
+	SenderAccountID uint    `json:"sender_account_id" gorm:"not null"`
+	Amount          float64 `json:"amount,omitempty"` // entered by the payer for static QRs
+}
+
+// CreateStaticQRRequest is a reusable merchant QR, the payer enters the amount
+type CreateStaticQRRequest struct {
+	Label string `json:"label"` // where it is put up, e.g. "Counter 1"
 }
 
 func ValidateCreateQR(req *CreateQRRequest) error {
@@ -26,10 +46,6 @@ func ValidateCreateQR(req *CreateQRRequest) error {
 	if req.ReceiverAccountID == 0 {
 		return errors.New("qr receiver id can't be empty")
 	}
-
-	if strings.TrimSpace(req.URL) == "" {
-		return errors.New("qr url is required")
-	}
 	return nil
 }
 
@@ -39,5 +55,44 @@ func ValidateScanQR(req *ScanQRRequest) error {
 		return errors.New("sender id can't be empty")
 	}
 
+	if req.Amount < 0 {
+		return errors.New("amount can't be negative")
+	}
+
+	return nil
+}
+
+func ValidateParseQR(req *ParseQRRequest) error {
+	if strings.TrimSpace(req.Payload) == "" {
+		return errors.New("payload is required")
+	}
+	if len(req.Payload) > 512 {
+		return errors.New("payload is too long")
+	}
+	return nil
+}
+
+func ValidateQRImageQuery(req *QRImageQuery) error {
+	if req.Format == "" {
+		req.Format = "png"
+	}
+	if req.Format != "png" && req.Format != "svg" {
+		return errors.New("format must be png or svg")
+	}
+	if req.Size < 0 {
+		return errors.New("size must be positive")
+	}
+	req.Level = strings.ToUpper(req.Level)
+	if req.Level != "" && !validQRLevels[req.Level] {
+		return errors.New("level must be L, M, Q or H")
+	}
+	return nil
+}
+
+func ValidateCreateStaticQR(req *CreateStaticQRRequest) error {
+	req.Label = strings.TrimSpace(req.Label)
+	if len(req.Label) > 50 {
+		return errors.New("label can be at most 50 characters")
+	}
 	return nil
 }
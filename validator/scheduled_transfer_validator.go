@@ -0,0 +1,85 @@
+package validator
+
+import (
+	"errors"
+	"gopay-clone/models"
+	"time"
+)
+
+var validScheduleFrequencies = map[models.ScheduleFrequency]bool{
+	models.ScheduleOnce:    true,
+	models.ScheduleDaily:   true,
+	models.ScheduleWeekly:  true,
+	models.ScheduleMonthly: true,
+}
+
+type CreateScheduleRequest struct {
+	SenderAccountID   *uint                       `json:"sender_account_id,omitempty"` // main balance when empty
+	ReceiverAccountID *uint                       `json:"receiver_account_id,omitempty"`
+	ContactID         *uint                       `json:"contact_id,omitempty"` // pays the contact's main balance
+	Amount            float64                     `json:"amount" validate:"required"`
+	Description       string                      `json:"description,omitempty"`
+	Category          *models.TransactionCategory `json:"category,omitempty"`
+	Frequency         models.ScheduleFrequency    `json:"frequency" validate:"required"`
+	Interval          int                         `json:"interval,omitempty"` // default 1
+	StartAt           time.Time                   `json:"start_at" validate:"required"`
+	EndAt             *time.Time                  `json:"end_at,omitempty"`
+	MaxRuns           *int                        `json:"max_runs,omitempty"`
+	Timezone          string                      `json:"timezone,omitempty"` // default Asia/Jakarta
+}
+
+func ValidateCreateSchedule(req *CreateScheduleRequest) error {
+	if (req.ReceiverAccountID == nil) == (req.ContactID == nil) {
+		return errors.New("provide either receiver_account_id or contact_id")
+	}
+	if req.SenderAccountID != nil && *req.SenderAccountID == 0 {
+		return errors.New("sender account id can not be 0")
+	}
+	if req.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+	if req.Category != nil && !validTransactionCategory[*req.Category] {
+		return errors.New("invalid category")
+	}
+	if err := validateScheduleTiming(req.Frequency, req.Interval, req.StartAt, req.EndAt, req.MaxRuns, req.Timezone); err != nil {
+		return err
+	}
+	if len(req.Description) > 140 {
+		return errors.New("description can not be longer than 140 characters")
+	}
+	return nil
+}
+
+// validateScheduleTiming is shared by scheduled transfers and scheduled auto-save rules
+func validateScheduleTiming(frequency models.ScheduleFrequency, interval int, startAt time.Time, endAt *time.Time, maxRuns *int, timezone string) error {
+	if !validScheduleFrequencies[frequency] {
+		return errors.New("frequency must be once, daily, weekly or monthly")
+	}
+	if interval < 0 || interval > 365 {
+		return errors.New("interval must be between 1 and 365")
+	}
+	if startAt.IsZero() {
+		return errors.New("start_at is required")
+	}
+	if startAt.Before(time.Now().Add(-time.Minute)) {
+		return errors.New("start_at can not be in the past")
+	}
+	if startAt.After(time.Now().AddDate(1, 0, 0)) {
+		return errors.New("start_at can not be more than a year ahead")
+	}
+	if frequency == models.ScheduleOnce && (endAt != nil || maxRuns != nil || interval > 1) {
+		return errors.New("one off transfers do not take interval, end_at or max_runs")
+	}
+	if endAt != nil && !endAt.After(startAt) {
+		return errors.New("end_at must be after start_at")
+	}
+	if maxRuns != nil && *maxRuns < 1 {
+		return errors.New("max_runs must be at least 1")
+	}
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return errors.New("invalid timezone")
+		}
+	}
+	return nil
+}
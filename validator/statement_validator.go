@@ -0,0 +1,78 @@
+package validator
+
+import (
+	"errors"
+	"time"
+)
+
+var validStatementFormats = map[string]bool{
+	"json": true,
+	"csv":  true,
+	"pdf":  true,
+}
+
+// StatementQuery picks either a whole month or a from/to range, the current month when both are empty
+type StatementQuery struct {
+	Month  string `query:"month"` // YYYY-MM
+	From   string `query:"from"`  // YYYY-MM-DD
+	To     string `query:"to"`    // YYYY-MM-DD, inclusive
+	Format string `query:"format"`
+}
+
+func ValidateStatementQuery(req *StatementQuery) error {
+	if req.Format == "" {
+		req.Format = "json"
+	}
+	if !validStatementFormats[req.Format] {
+		return errors.New("format must be json, csv or pdf")
+	}
+
+	if req.Month != "" {
+		if req.From != "" || req.To != "" {
+			return errors.New("use either month or from/to")
+		}
+		if _, err := time.Parse("2006-01", req.Month); err != nil {
+			return errors.New("month must be in YYYY-MM format")
+		}
+		return nil
+	}
+
+	if (req.From == "") != (req.To == "") {
+		return errors.New("both from and to are required")
+	}
+	if req.From != "" {
+		from, err := time.Parse("2006-01-02", req.From)
+		if err != nil {
+			return errors.New("from must be in YYYY-MM-DD format")
+		}
+		to, err := time.Parse("2006-01-02", req.To)
+		if err != nil {
+			return errors.New("to must be in YYYY-MM-DD format")
+		}
+		if to.Before(from) {
+			return errors.New("to must be after from")
+		}
+		if to.Sub(from) > 366*24*time.Hour {
+			return errors.New("statement period can not be longer than a year")
+		}
+	}
+	return nil
+}
+
+// Period turns a validated query into the inclusive time range of the statement
+func (req *StatementQuery) Period(now time.Time) (time.Time, time.Time) {
+	var from, to time.Time
+	switch {
+	case req.Month != "":
+		from, _ = time.Parse("2006-01", req.Month)
+		to = from.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	case req.From != "":
+		from, _ = time.Parse("2006-01-02", req.From)
+		to, _ = time.Parse("2006-01-02", req.To)
+		to = to.Add(24*time.Hour - time.Nanosecond)
+	default:
+		from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		to = now
+	}
+	return from, to
+}
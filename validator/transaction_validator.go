@@ -3,6 +3,7 @@ package validator
 import (
 	"errors"
 	"gopay-clone/models"
+	"strings"
 )
 
 var validTransactionStatuses = map[models.TransactionStatus]bool{
@@ -28,26 +29,46 @@ var validTransactionCategory = map[models.TransactionCategory]bool{
 	models.Other:         true,
 }
 
+var validServiceTypes = map[models.ServiceType]bool{
+	models.ServiceFood: true,
+	models.ServiceRide: true,
+	models.ServiceNone: true,
+}
+
+var validSummaryPeriods = map[string]bool{
+	"day":   true,
+	"week":  true,
+	"month": true,
+}
+
 type CreateTransactionRequest struct {
 	Amount            float64                     `json:"amount" gorm:"not null"`
 	SenderAccountID   uint                        `json:"sender_id" gorm:"not null"`
 	ReceiverAccountID uint                        `json:"receiver_id" gorm:"not null"`
 	Type              *models.TransactionType     `json:"type,omitempty"`
 	Category          *models.TransactionCategory `json:"category,omitempty"`
-	Status            *models.TransactionStatus   `json:"status,omitempty"`
-	QrCodeID          *uint                       `json:"qr_code_id,omitempty"`
 	Description       *string                     `json:"description,omitempty"`
 	ServiceID         *uint                       `json:"service_id,omitempty"`
 	ServiceType       *models.ServiceType         `json:"service_type,omitempty"`
 }
 
+// UpdateTransactionRequest only touches labels, the status and the QR follow the money and are never set by hand
 type UpdateTransactionRequest struct {
-	Status      *models.TransactionStatus   `json:"status,omitempty"`
-	QrCodeID    *uint                       `json:"qr_code_id,omitempty"`
 	Category    *models.TransactionCategory `json:"category,omitempty"`
 	Description *string                     `json:"description,omitempty"`
 }
 
+// TransactionHistoryQuery holds the query string of the account history endpoints,
+// the filters accept comma separated values
+type TransactionHistoryQuery struct {
+	Direction   models.TransactionDirection `query:"direction"`
+	Type        string                      `query:"type"`
+	Category    string                      `query:"category"`
+	Status      string                      `query:"status"`
+	ServiceType string                      `query:"service_type"`
+	Period      string                      `query:"period"`
+}
+
 func isValidType(t models.TransactionType) bool {
 	return validTransactionTypes[t]
 }
@@ -87,13 +108,52 @@ func ValidateCreateTransaction(req *CreateTransactionRequest) error {
 
 func ValidateUpdateTransaction(req *UpdateTransactionRequest) error {
 
-	if req.Status != nil && !isValidStatus(*req.Status) {
-		return errors.New("not a valid status")
-	}
-
 	if req.Category != nil && !isValidCategory(*req.Category) {
 		return errors.New("not a valid category")
 	}
 
 	return nil
 }
+
+func ValidateTransactionHistoryQuery(req *TransactionHistoryQuery) error {
+	if req.Direction != "" && req.Direction != models.DirectionIn && req.Direction != models.DirectionOut {
+		return errors.New("direction must be in or out")
+	}
+	for _, t := range splitFilter(req.Type) {
+		if !isValidType(models.TransactionType(t)) {
+			return errors.New("not a valid type")
+		}
+	}
+	for _, c := range splitFilter(req.Category) {
+		if !isValidCategory(models.TransactionCategory(c)) {
+			return errors.New("not a valid category")
+		}
+	}
+	for _, st := range splitFilter(req.Status) {
+		if !isValidStatus(models.TransactionStatus(st)) {
+			return errors.New("not a valid status")
+		}
+	}
+	for _, st := range splitFilter(req.ServiceType) {
+		if !validServiceTypes[models.ServiceType(st)] {
+			return errors.New("not a valid service type")
+		}
+	}
+	if req.Period == "" {
+		req.Period = "month"
+	}
+	if !validSummaryPeriods[req.Period] {
+		return errors.New("period must be day, week or month")
+	}
+	return nil
+}
+
+func splitFilter(raw string) []string {
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
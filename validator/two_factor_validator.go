@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"errors"
+	"regexp"
+)
+
+// a 6 digit code from the app or a recovery code like k3j9d-x8q2m
+var twoFactorCodeRegex = regexp.MustCompile(`^([0-9]{6}|[a-zA-Z2-7]{5}-?[a-zA-Z2-7]{5})$`)
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type CompleteLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+func ValidateTwoFactorCode(req *TwoFactorCodeRequest) error {
+	return validateTwoFactorCode(req.Code)
+}
+
+func ValidateDisableTwoFactor(req *DisableTwoFactorRequest) error {
+	if err := validatePassword(req.Password, true); err != nil {
+		return err
+	}
+	return validateTwoFactorCode(req.Code)
+}
+
+func ValidateCompleteLogin(req *CompleteLoginRequest) error {
+	if len(req.ChallengeToken) != 64 {
+		return errors.New("challenge token is malformed")
+	}
+	return validateTwoFactorCode(req.Code)
+}
+
+func validateTwoFactorCode(code string) error {
+	if !twoFactorCodeRegex.MatchString(code) {
+		return errors.New("code must be 6 digits or a recovery code")
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package validator
 
 import (
 	"errors"
+	"gopay-clone/models"
 	"regexp"
 	"strings"
 )
@@ -13,6 +14,7 @@ type CreateUserRequest struct {
 	Phone             string `json:"phone" validate:"required,len=10"`
 	Type              string `json:"user_type"`
 	ProfilePictureURL string `json:"profile_picture_url"`
+	OtpCode           string `json:"otp_code"` // code from POST /public/otp with purpose register, verifies the phone
 }
 
 type UpdateUserRequest struct {
@@ -25,6 +27,7 @@ type UpdateUserRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required"`
 	Password string `json:"password" validate:"required"`
+	OtpCode  string `json:"otp_code"` // only when login answered that a code was sent for a new device
 }
 
 func ValidateLogin(req *LoginRequest) error {
@@ -34,6 +37,11 @@ func ValidateLogin(req *LoginRequest) error {
 	if err := validatePassword(req.Password, true); err != nil {
 		return err
 	}
+	if req.OtpCode != "" {
+		if err := validateOtpCode(req.OtpCode); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -50,6 +58,17 @@ func ValidateCreateUser(req *CreateUserRequest) error {
 	if err := validatePhone(req.Phone); err != nil {
 		return err
 	}
+	if req.OtpCode != "" {
+		if err := validateOtpCode(req.OtpCode); err != nil {
+			return err
+		}
+	}
+	// admins are never self registered
+	switch models.UserType(req.Type) {
+	case "", models.Consumer, models.Driver, models.Merchant:
+	default:
+		return errors.New("user_type must be consumer, driver or merchant")
+	}
 	return nil
 }
 
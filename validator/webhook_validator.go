@@ -0,0 +1,62 @@
+package validator
+
+import (
+	"errors"
+	"gopay-clone/models"
+	"slices"
+)
+
+type CreateWebhookRequest struct {
+	URL    string                `json:"url" validate:"required"`
+	Events []models.WebhookEvent `json:"events" validate:"required"`
+}
+
+type UpdateWebhookRequest struct {
+	URL     *string               `json:"url"`
+	Events  []models.WebhookEvent `json:"events"`
+	Enabled *bool                 `json:"enabled"`
+}
+
+func ValidateCreateWebhook(req *CreateWebhookRequest) error {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return err
+	}
+	return validateWebhookEvents(req.Events)
+}
+
+func ValidateUpdateWebhook(req *UpdateWebhookRequest) error {
+	if req.URL == nil && req.Events == nil && req.Enabled == nil {
+		return errors.New("nothing to update")
+	}
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return err
+		}
+	}
+	if req.Events != nil {
+		return validateWebhookEvents(req.Events)
+	}
+	return nil
+}
+
+func validateWebhookURL(url string) error {
+	if url == "" {
+		return errors.New("url is required")
+	}
+	if len(url) > 2048 {
+		return errors.New("url must be at most 2048 characters")
+	}
+	return nil
+}
+
+func validateWebhookEvents(events []models.WebhookEvent) error {
+	if len(events) == 0 {
+		return errors.New("at least one event is required")
+	}
+	for _, event := range events {
+		if !slices.Contains(models.WebhookEvents, event) {
+			return errors.New("unknown event " + string(event))
+		}
+	}
+	return nil
+}